@@ -0,0 +1,87 @@
+// Package apierror describes failed ATC API requests using what the ATC
+// said went wrong.
+package apierror
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// bodyLimit is how much of a body that isn't an error envelope is shown
+const bodyLimit = 1024
+
+// Error is a non-2xx response from the ATC.
+type Error struct {
+	Doing  string
+	Status string
+
+	// Messages are from the ATC's {"errors": [...]} envelope; otherwise Body
+	// holds the start of the response body
+	Messages []string
+	Body     string
+}
+
+func (err Error) Error() string {
+	message := fmt.Sprintf("bad response %s (%s)", err.Doing, err.Status)
+
+	if len(err.Messages) > 0 {
+		return message + ":\n" + strings.Join(err.Messages, "\n")
+	}
+
+	if err.Body != "" {
+		return message + ":\n" + err.Body
+	}
+
+	return message
+}
+
+// FromResponse reads and closes the body of a failed response, describing
+// the failure with what the ATC said.
+func FromResponse(doing string, response *http.Response) error {
+	defer response.Body.Close()
+
+	body, _ := ioutil.ReadAll(io.LimitReader(response.Body, bodyLimit+1))
+
+	return fromBody(doing, response.Status, string(body))
+}
+
+// the format of the client's errors for unexpected responses
+var unexpectedResponse = regexp.MustCompile(`(?s)^Unexpected Response\nStatus: ([^\n]*)\nBody:\n(.*)$`)
+
+// Wrap describes an error returned by the client. Unexpected responses are
+// parsed like FromResponse's; any other error, e.g. failing to connect, is
+// just prefixed with what was being done.
+func Wrap(doing string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	match := unexpectedResponse.FindStringSubmatch(err.Error())
+	if match == nil {
+		return fmt.Errorf("error %s: %s", doing, err)
+	}
+
+	return fromBody(doing, match[1], match[2])
+}
+
+func fromBody(doing string, status string, body string) error {
+	var envelope struct {
+		Errors []string `json:"errors"`
+	}
+
+	if json.Unmarshal([]byte(body), &envelope) == nil && len(envelope.Errors) > 0 {
+		return Error{Doing: doing, Status: status, Messages: envelope.Errors}
+	}
+
+	body = strings.TrimSpace(body)
+	if len(body) > bodyLimit {
+		body = body[:bodyLimit] + "... (truncated)"
+	}
+
+	return Error{Doing: doing, Status: status, Body: body}
+}
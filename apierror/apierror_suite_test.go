@@ -0,0 +1,13 @@
+package apierror_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestAPIError(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "API Error Suite")
+}
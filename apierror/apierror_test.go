@@ -0,0 +1,60 @@
+package apierror_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/concourse/fly/apierror"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("API errors", func() {
+	response := func(status string, body string) *http.Response {
+		return &http.Response{
+			Status: status,
+			Body:   ioutil.NopCloser(strings.NewReader(body)),
+		}
+	}
+
+	Describe("FromResponse", func() {
+		It("prints the messages of the ATC's error envelope", func() {
+			err := apierror.FromResponse("creating build", response("400 Bad Request", `{"errors":["invalid task config: no platform","no run"]}`))
+			Expect(err).To(MatchError("bad response creating build (400 Bad Request):\ninvalid task config: no platform\nno run"))
+		})
+
+		It("prints the body when it isn't an envelope", func() {
+			err := apierror.FromResponse("creating pipe", response("502 Bad Gateway", "upstream went away\n"))
+			Expect(err).To(MatchError("bad response creating pipe (502 Bad Gateway):\nupstream went away"))
+		})
+
+		It("prints only the first KB of the body", func() {
+			err := apierror.FromResponse("creating pipe", response("500 Internal Server Error", strings.Repeat("x", 4096)))
+			Expect(err.Error()).To(HaveSuffix(strings.Repeat("x", 1024) + "... (truncated)"))
+		})
+
+		It("prints just the status when there is no body", func() {
+			err := apierror.FromResponse("streaming events", response("500 Internal Server Error", ""))
+			Expect(err).To(MatchError("bad response streaming events (500 Internal Server Error)"))
+		})
+	})
+
+	Describe("Wrap", func() {
+		It("parses the client's unexpected response errors", func() {
+			err := apierror.Wrap("aborting build", errors.New("Unexpected Response\nStatus: 500 Internal Server Error\nBody:\n{\"errors\":[\"database is gone\"]}"))
+			Expect(err).To(MatchError("bad response aborting build (500 Internal Server Error):\ndatabase is gone"))
+		})
+
+		It("prefixes any other error with what was being done", func() {
+			err := apierror.Wrap("creating pipe", errors.New("connection refused"))
+			Expect(err).To(MatchError("error creating pipe: connection refused"))
+		})
+
+		It("leaves no error alone", func() {
+			Expect(apierror.Wrap("creating pipe", nil)).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/eventstream"
+	"github.com/concourse/go-concourse/concourse"
+)
+
+type AbortBuildCommand struct {
+	Build string `short:"b" long:"build" required:"true" value-name:"BUILD_ID" description:"ID of the build to abort"`
+	Watch bool   `short:"w" long:"watch"                                       description:"Stream the build's output until it has been aborted"`
+}
+
+func (command *AbortBuildCommand) Execute([]string) error {
+	connection, err := targetConnection()
+	if err != nil {
+		return err
+	}
+
+	client := concourse.NewClient(connection)
+
+	build, found, err := client.Build(command.Build)
+	if err != nil {
+		return fmt.Errorf("failed to get build: %s", err)
+	}
+
+	if !found {
+		return errors.New("build not found")
+	}
+
+	if !buildRunning(build) {
+		return fmt.Errorf("build %d has already finished (%s)", build.ID, build.Status)
+	}
+
+	err = abortBuild(client, build.ID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("aborting build %d\n", build.ID)
+
+	if !command.Watch {
+		return nil
+	}
+
+	eventSource, err := buildEvents(connection, build.ID, eventstream.DefaultMaxReconnects)
+	if err != nil {
+		return fmt.Errorf("failed to attach to stream: %s", err)
+	}
+
+	summarized := &summaryEvents{Events: eventSource}
+
+	exitCode := eventstream.Render(os.Stdout, summarized, eventstream.RenderOptions{
+		ShowTimestamp: showTimestamps(false),
+		Color:         useColor("auto", os.Stdout),
+	})
+
+	eventSource.Close()
+
+	// a build that finished some other way before the abort landed keeps its
+	// own exit code
+	if summarized.status == atc.StatusAborted {
+		exitCode = 0
+	}
+
+	os.Exit(exitCode)
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/eventstream"
+	"github.com/concourse/fly/ui"
+	"github.com/concourse/go-concourse/concourse"
+	"github.com/fatih/color"
+)
+
+const buildTimeFormat = "2006-01-02 15:04:05"
+
+type BuildsCommand struct {
+	Count int  `short:"n" long:"count" default:"50" description:"Number of builds to print"`
+	All   bool `          long:"all"                description:"Print every build, ignoring --count"`
+	JSON  bool `          long:"json"               description:"Print the builds as returned by the API, as JSON"`
+}
+
+func (command *BuildsCommand) Execute([]string) error {
+	connection, err := targetConnection()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	client := concourse.NewClient(connection)
+
+	builds, err := client.AllBuilds()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	sort.Sort(buildsByNewest(builds))
+
+	if !command.All && len(builds) > command.Count {
+		builds = builds[:command.Count]
+	}
+
+	if command.JSON {
+		return json.NewEncoder(os.Stdout).Encode(builds)
+	}
+
+	table := ui.Table{
+		Headers: ui.Headers("id", "pipeline/job", "build", "status", "start", "duration"),
+	}
+
+	for _, b := range builds {
+		var job ui.TableCell
+		if b.JobName == "" {
+			job.Contents = "one-off"
+			job.Color = color.New(color.Faint)
+		} else {
+			job.Contents = b.PipelineName + "/" + b.JobName
+		}
+
+		row := ui.TableRow{
+			{Contents: strconv.Itoa(b.ID)},
+			job,
+			{Contents: b.Name},
+			{Contents: b.Status, Color: eventstream.StatusColor(atc.BuildStatus(b.Status))},
+			buildStartTime(b),
+			buildDuration(b),
+		}
+
+		table.Data = append(table.Data, row)
+	}
+
+	return table.Render(os.Stdout)
+}
+
+type buildsByNewest []atc.Build
+
+func (bs buildsByNewest) Len() int               { return len(bs) }
+func (bs buildsByNewest) Swap(i int, j int)      { bs[i], bs[j] = bs[j], bs[i] }
+func (bs buildsByNewest) Less(i int, j int) bool { return bs[i].ID > bs[j].ID }
+
+func buildStartTime(build atc.Build) ui.TableCell {
+	if build.StartTime == 0 {
+		return ui.TableCell{Contents: "n/a", Color: color.New(color.Faint)}
+	}
+
+	return ui.TableCell{Contents: time.Unix(build.StartTime, 0).Format(buildTimeFormat)}
+}
+
+func buildDuration(build atc.Build) ui.TableCell {
+	if build.StartTime == 0 {
+		return ui.TableCell{Contents: "n/a", Color: color.New(color.Faint)}
+	}
+
+	// builds that are still running have been going for at least this long
+	if build.EndTime == 0 {
+		elapsed := time.Since(time.Unix(build.StartTime, 0))
+		return ui.TableCell{Contents: roundDuration(elapsed).String() + "+"}
+	}
+
+	return ui.TableCell{Contents: (time.Duration(build.EndTime-build.StartTime) * time.Second).String()}
+}
@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/apierror"
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/go-concourse/concourse"
+	"github.com/tedsuo/rata"
+)
+
+type CheckResourceCommand struct {
+	Resource flaghelpers.ResourceFlag `short:"r" long:"resource" required:"true" value-name:"PIPELINE/RESOURCE" description:"Resource to check"`
+	From     atc.Version              `          long:"from"                     value-name:"KEY:VALUE"         description:"Version of the resource to check from, e.g. ref:abcd1234 (can be specified multiple times)"`
+}
+
+type checkRequestBody struct {
+	From atc.Version `json:"from"`
+}
+
+// checkResponseBody is what the ATC returns when the resource's check script
+// fails
+type checkResponseBody struct {
+	ExitStatus int    `json:"exit_status"`
+	Stderr     string `json:"stderr"`
+}
+
+func (command *CheckResourceCommand) Execute(args []string) error {
+	connection, err := targetConnection()
+	if err != nil {
+		log.Fatalln(err)
+		return nil
+	}
+
+	pipelineName := command.Resource.PipelineName
+	resourceName := command.Resource.ResourceName
+
+	payload, err := json.Marshal(checkRequestBody{From: command.From})
+	if err != nil {
+		return err
+	}
+
+	requestGenerator := rata.NewRequestGenerator(connection.URL(), atc.Routes)
+
+	request, err := requestGenerator.CreateRequest(atc.CheckResource, rata.Params{
+		"pipeline_name": pipelineName,
+		"resource_name": resourceName,
+	}, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := connection.HTTPClient().Do(request)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		fmt.Printf("checked '%s/%s'\n", pipelineName, resourceName)
+		return nil
+
+	case http.StatusBadRequest:
+		var result checkResponseBody
+		err := json.NewDecoder(response.Body).Decode(&result)
+		if err != nil {
+			log.Fatalln("check failed, and the ATC's response was invalid:", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "check failed with exit status %d:\n%s", result.ExitStatus, result.Stderr)
+		os.Exit(1)
+
+	case http.StatusUnauthorized:
+		log.Fatalln(concourse.ErrUnauthorized)
+
+	case http.StatusNotFound:
+		log.Fatalln(resourceNotFound(connection, pipelineName, resourceName))
+	}
+
+	log.Fatalln(apierror.FromResponse("checking resource", response))
+
+	return nil
+}
+
+// resourceNotFound explains a 404, listing the pipeline's resources to help
+// spot typos
+func resourceNotFound(connection concourse.Connection, pipelineName string, resourceName string) error {
+	requestGenerator := rata.NewRequestGenerator(connection.URL(), atc.Routes)
+
+	request, err := requestGenerator.CreateRequest(atc.ListResources, rata.Params{"pipeline_name": pipelineName}, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := connection.HTTPClient().Do(request)
+	if err != nil {
+		return fmt.Errorf("resource '%s' not found in pipeline '%s'", resourceName, pipelineName)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("pipeline '%s' not found", pipelineName)
+	}
+
+	var resources []atc.Resource
+	if response.StatusCode != http.StatusOK || json.NewDecoder(response.Body).Decode(&resources) != nil || len(resources) == 0 {
+		return fmt.Errorf("resource '%s' not found in pipeline '%s'", resourceName, pipelineName)
+	}
+
+	names := make([]string, len(resources))
+	for i, resource := range resources {
+		names[i] = resource.Name
+	}
+
+	return fmt.Errorf("resource '%s' not found in pipeline '%s'; its resources are: %s", resourceName, pipelineName, strings.Join(names, ", "))
+}
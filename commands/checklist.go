@@ -5,7 +5,6 @@ import (
 	"log"
 
 	"github.com/concourse/atc"
-	"github.com/concourse/fly/rc"
 	"github.com/concourse/go-concourse/concourse"
 )
 
@@ -14,7 +13,7 @@ type ChecklistCommand struct {
 }
 
 func (command *ChecklistCommand) Execute([]string) error {
-	connection, err := rc.TargetConnection(Fly.Target)
+	connection, err := targetConnection()
 	if err != nil {
 		log.Fatalln(err)
 	}
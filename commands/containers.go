@@ -1,51 +1,64 @@
 package commands
 
 import (
+	"encoding/json"
 	"log"
 	"os"
 	"sort"
 	"strconv"
 
 	"github.com/concourse/atc"
-	"github.com/concourse/fly/rc"
 	"github.com/concourse/fly/ui"
 	"github.com/concourse/go-concourse/concourse"
 	"github.com/fatih/color"
 )
 
-type ContainersCommand struct{}
+type ContainersCommand struct {
+	Pipeline string `long:"pipeline"                  description:"Only list the containers of this pipeline"`
+	Type     string `long:"type" value-name:"TYPE"    choice:"check" choice:"get" choice:"put" choice:"task" description:"Only list containers of this type: check, get, put or task"`
+	Sort     string `long:"sort" value-name:"COLUMN" default:"handle" choice:"handle" choice:"worker" choice:"pipeline" choice:"job" choice:"build" choice:"type" description:"Order the containers by handle, worker, pipeline, job, build or type"`
+	JSON     bool   `long:"json"                      description:"Print the containers as returned by the API, as JSON"`
+}
 
 func (command *ContainersCommand) Execute([]string) error {
-	connection, err := rc.TargetConnection(Fly.Target)
+	connection, err := targetConnection()
 	if err != nil {
 		log.Fatalln(err)
 	}
 
 	client := concourse.NewClient(connection)
 
-	containers, err := client.ListContainers(map[string]string{})
+	query := map[string]string{}
+	if command.Pipeline != "" {
+		query["pipeline_name"] = command.Pipeline
+	}
+	if command.Type != "" {
+		query["type"] = command.Type
+	}
+
+	containers, err := client.ListContainers(query)
 	if err != nil {
-		log.Fatalln(err)
+		log.Fatalln(explainMissingEndpoint(err, "containers"))
 	}
 
-	table := ui.Table{
-		Headers: ui.TableRow{
-			{Contents: "handle", Color: color.New(color.Bold)},
-			{Contents: "name", Color: color.New(color.Bold)},
-			{Contents: "pipeline", Color: color.New(color.Bold)},
-			{Contents: "type", Color: color.New(color.Bold)},
-			{Contents: "build id", Color: color.New(color.Bold)},
-			{Contents: "worker", Color: color.New(color.Bold)},
-		},
+	containers = command.filter(containers)
+
+	sort.Sort(containersBy{containers, containerOrderings[command.Sort]})
+
+	if command.JSON {
+		return json.NewEncoder(os.Stdout).Encode(containers)
 	}
 
-	sort.Sort(containersByHandle(containers))
+	table := ui.Table{
+		Headers: ui.Headers("handle", "name", "pipeline", "job", "type", "build id", "worker"),
+	}
 
 	for _, c := range containers {
 		row := ui.TableRow{
 			{Contents: c.ID},
 			{Contents: c.Name},
-			{Contents: c.PipelineName},
+			stringOrNone(c.PipelineName),
+			stringOrNone(c.JobName),
 			{Contents: c.Type},
 			buildIDOrNone(c.BuildID),
 			{Contents: c.WorkerName},
@@ -57,11 +70,54 @@ func (command *ContainersCommand) Execute([]string) error {
 	return table.Render(os.Stdout)
 }
 
-type containersByHandle []atc.Container
+// filter applies the filters locally too, as older ATCs ignore the query
+func (command *ContainersCommand) filter(containers []atc.Container) []atc.Container {
+	filtered := []atc.Container{}
 
-func (cs containersByHandle) Len() int               { return len(cs) }
-func (cs containersByHandle) Swap(i int, j int)      { cs[i], cs[j] = cs[j], cs[i] }
-func (cs containersByHandle) Less(i int, j int) bool { return cs[i].ID < cs[j].ID }
+	for _, c := range containers {
+		if command.Pipeline != "" && c.PipelineName != command.Pipeline {
+			continue
+		}
+
+		if command.Type != "" && c.Type != command.Type {
+			continue
+		}
+
+		filtered = append(filtered, c)
+	}
+
+	return filtered
+}
+
+var containerOrderings = map[string]func(a, b atc.Container) bool{
+	"handle":   func(a, b atc.Container) bool { return a.ID < b.ID },
+	"worker":   func(a, b atc.Container) bool { return a.WorkerName < b.WorkerName },
+	"pipeline": func(a, b atc.Container) bool { return a.PipelineName < b.PipelineName },
+	"job":      func(a, b atc.Container) bool { return a.JobName < b.JobName },
+	"build":    func(a, b atc.Container) bool { return a.BuildID < b.BuildID },
+	"type":     func(a, b atc.Container) bool { return a.Type < b.Type },
+}
+
+// containersBy orders containers by one column, falling back on the handle
+type containersBy struct {
+	containers []atc.Container
+	less       func(a, b atc.Container) bool
+}
+
+func (cs containersBy) Len() int { return len(cs.containers) }
+func (cs containersBy) Swap(i int, j int) {
+	cs.containers[i], cs.containers[j] = cs.containers[j], cs.containers[i]
+}
+func (cs containersBy) Less(i int, j int) bool {
+	a, b := cs.containers[i], cs.containers[j]
+	if cs.less(a, b) {
+		return true
+	}
+	if cs.less(b, a) {
+		return false
+	}
+	return a.ID < b.ID
+}
 
 func buildIDOrNone(id int) ui.TableCell {
 	var column ui.TableCell
@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/concourse/fly/rc"
+	"github.com/vito/go-interact/interact"
+)
+
+type DeleteTargetCommand struct {
+	All            bool `short:"a" long:"all"             description:"Delete every saved target"`
+	NonInteractive bool `short:"n" long:"non-interactive" description:"With --all, do not ask for confirmation"`
+}
+
+func (command *DeleteTargetCommand) Execute(args []string) error {
+	if command.All {
+		if !command.NonInteractive {
+			fmt.Println("!!! this will remove every target from .flyrc")
+			fmt.Println("")
+
+			confirm := false
+			err := interact.NewInteraction("are you sure?").Resolve(&confirm)
+			if err != nil || !confirm {
+				fmt.Println("bailing out")
+				return err
+			}
+		}
+
+		err := rc.DeleteAllTargets()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("deleted all targets")
+
+		return nil
+	}
+
+	if Fly.Target == "" {
+		return rc.NoTargetError()
+	}
+
+	err := rc.DeleteTarget(Fly.Target)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("deleted target `%s`\n", Fly.Target)
+
+	return nil
+}
@@ -2,45 +2,87 @@ package commands
 
 import (
 	"fmt"
+	"os"
 
-	"github.com/concourse/fly/rc"
 	"github.com/concourse/go-concourse/concourse"
 	"github.com/vito/go-interact/interact"
 )
 
 type DestroyPipelineCommand struct {
-	Pipeline string `short:"p"  long:"pipeline" required:"true" description:"Pipeline to destroy"`
+	Pipeline           string `short:"p" long:"pipeline"               required:"true" description:"Pipeline to destroy"`
+	NonInteractive     bool   `short:"n" long:"non-interactive"                        description:"Destroy the pipeline without confirmation"`
+	ShowWhatWillBeLost bool   `          long:"show-what-will-be-lost"                 description:"List the pipeline's jobs and resources instead of destroying it"`
 }
 
 func (command *DestroyPipelineCommand) Execute(args []string) error {
 	pipelineName := command.Pipeline
 
-	fmt.Printf("!!! this will remove all data for pipeline `%s`\n\n", pipelineName)
+	connection, err := targetConnection()
+	if err != nil {
+		failDestroy(err)
+	}
+
+	client := concourse.NewClient(connection)
+
+	if command.ShowWhatWillBeLost {
+		return command.showWhatWillBeLost(client)
+	}
 
-	confirm := false
-	err := interact.NewInteraction("are you sure?").Resolve(&confirm)
-	if err != nil || !confirm {
-		fmt.Println("bailing out")
-		return err
+	if !command.NonInteractive {
+		fmt.Printf("!!! this will remove all data for pipeline `%s`\n\n", pipelineName)
+
+		var typed string
+		err := interact.NewInteraction("type the pipeline name to confirm").Resolve(&typed)
+		if err != nil || typed != pipelineName {
+			fmt.Println("bailing out")
+			return err
+		}
 	}
 
-	connection, err := rc.TargetConnection(Fly.Target)
+	found, err := client.DeletePipeline(pipelineName)
 	if err != nil {
-		return err
+		failDestroy(err)
 	}
 
-	client := concourse.NewClient(connection)
+	if !found {
+		fmt.Fprintf(os.Stderr, "`%s` does not exist\n", pipelineName)
+		os.Exit(1)
+	}
 
-	found, err := client.DeletePipeline(pipelineName)
+	fmt.Printf("`%s` deleted\n", pipelineName)
+
+	return nil
+}
+
+func (command *DestroyPipelineCommand) showWhatWillBeLost(client concourse.Client) error {
+	config, _, found, err := client.PipelineConfig(command.Pipeline)
 	if err != nil {
-		return err
+		failDestroy(err)
 	}
 
 	if !found {
-		fmt.Printf("`%s` does not exist\n", pipelineName)
-	} else {
-		fmt.Printf("`%s` deleted\n", pipelineName)
+		fmt.Fprintf(os.Stderr, "`%s` does not exist\n", command.Pipeline)
+		os.Exit(1)
+	}
+
+	fmt.Printf("destroying `%s` would remove all builds and versions of:\n", command.Pipeline)
+
+	fmt.Println("\njobs:")
+	for _, job := range config.Jobs {
+		fmt.Printf("  %s\n", job.Name)
+	}
+
+	fmt.Println("\nresources:")
+	for _, resource := range config.Resources {
+		fmt.Printf("  %s\n", resource.Name)
 	}
 
 	return nil
 }
+
+// failDestroy exits 2 so scripts can tell a failure to talk to the ATC apart
+// from the pipeline not existing.
+func failDestroy(err error) {
+	fmt.Fprintf(os.Stderr, "error: %s\n", err)
+	os.Exit(2)
+}
@@ -1,35 +1,109 @@
 package commands
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
 	"os/signal"
+	"sort"
 	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/concourse/atc"
 	"github.com/concourse/fly/commands/internal/deprecated"
+	"github.com/concourse/fly/commands/internal/displayhelpers"
 	"github.com/concourse/fly/commands/internal/executehelpers"
 	"github.com/concourse/fly/commands/internal/flaghelpers"
 	"github.com/concourse/fly/config"
+	"github.com/concourse/fly/eventstream"
 	"github.com/concourse/fly/rc"
+	"github.com/concourse/fly/template"
+	"github.com/concourse/fly/ui"
 	"github.com/concourse/go-concourse/concourse"
-	"github.com/concourse/go-concourse/concourse/eventstream"
+	"github.com/mattn/go-isatty"
+	"github.com/vito/go-interact/interact"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	maxDefaultUploadParallelism = 4
+
+	// how many of a large input's biggest entries to list
+	maxLargeInputEntries = 10
+
+	// exit code used when detaching from a build with a second interrupt,
+	// distinct from the build's own exit codes
+	detachedExitCode = 4
+
+	abortGracePeriod = 5 * time.Second
+
+	defaultBuildName = "one-off"
 )
 
 type ExecuteCommand struct {
-	TaskConfig     flaghelpers.PathFlag         `short:"c" long:"config" required:"true"                description:"The task config to execute"`
-	Privileged     bool                         `short:"p" long:"privileged"                            description:"Run the task with full privileges"`
-	ExcludeIgnored bool                         `short:"x" long:"exclude-ignored"                       description:"Skip uploading .gitignored paths"`
-	Inputs         []flaghelpers.InputPairFlag  `short:"i" long:"input"       value-name:"NAME=PATH"    description:"An input to provide to the task (can be specified multiple times)"`
-	InputsFrom     flaghelpers.JobFlag          `short:"j" long:"inputs-from" value-name:"PIPELINE/JOB" description:"A job to base the inputs on"`
-	Outputs        []flaghelpers.OutputPairFlag `short:"o" long:"output"      value-name:"NAME=PATH"    description:"An output to fetch from the task (can be specified multiple times)"`
-	Tags           []string                     `          long:"tag"         value-name:"TAG"          description:"A tag for a specific environment (can be specified multiple times)"`
+	TaskConfig     flaghelpers.TaskConfigFlag     `short:"c" long:"config"                                description:"The task config to execute, or - to read it from stdin"`
+	ConfigFrom     flaghelpers.JobFlag            `          long:"config-from" value-name:"PIPELINE/JOB" description:"A job whose task step config should be executed"`
+	Step           string                         `          long:"step"        value-name:"NAME"         description:"The task step to execute when using --config-from (required if the job has more than one)"`
+	BuildName      flaghelpers.BuildNameFlag      `          long:"build-name"  value-name:"NAME" default:"one-off" description:"The name of the task step, as shown in the web UI and used for hijacking"`
+	Privileged     bool                           `short:"p" long:"privileged"                            description:"Run the task with full privileges"`
+	ExcludeIgnored bool                           `short:"x" long:"exclude-ignored"                       description:"Skip uploading .gitignored paths"`
+	RespectIgnore  bool                           `          long:"respect-gitignore"                     description:"Skip uploading paths matched by .gitignore files, without requiring git"`
+	IncludeIgnored bool                           `          long:"include-ignored"                       description:"Upload .gitignored paths even if told to skip them"`
+	Inputs         []flaghelpers.InputPairFlag    `short:"i" long:"input"       value-name:"NAME=PATH"    description:"An input to provide to the task (can be specified multiple times)"`
+	InputFromStdin flaghelpers.StdinInputFlag     `          long:"input-from-stdin" value-name:"NAME=FILE" description:"Provide the task input named NAME as a single file named FILE, read from stdin"`
+	InputsFrom     flaghelpers.JobFlag            `short:"j" long:"inputs-from" value-name:"PIPELINE/JOB" description:"A job to base the inputs on"`
+	InputMappings  []flaghelpers.VariablePairFlag `          long:"input-mapping" value-name:"TASK=LOCAL" description:"Provide the local input named LOCAL as the task input named TASK (can be specified multiple times)"`
+	Includes       []flaghelpers.IncludeFlag      `          long:"include"     value-name:"NAME=PATH:DEST" description:"Upload the file or directory at PATH as part of the input NAME, under DEST within it, without copying it there (can be specified multiple times)"`
+	Dereference    bool                           `          long:"dereference"                           description:"Upload what symlinks in inputs point to rather than the links, failing on broken links and cycles (or follow them in one input with -i NAME=PATH!follow)"`
+	Outputs        []flaghelpers.OutputPairFlag   `short:"o" long:"output"      value-name:"NAME=PATH"    description:"An output to fetch from the task (can be specified multiple times)"`
+	OutputMappings []flaghelpers.VariablePairFlag `          long:"output-mapping" value-name:"TASK=LOCAL" description:"Fetch the task output named TASK into the local directory LOCAL (can be specified multiple times)"`
+	OutputArchive  bool                           `          long:"output-archive"                        description:"Save outputs as .tgz archives instead of extracting them (implied for paths ending in .tgz or .tar.gz)"`
+	ExternalLinks  bool                           `          long:"allow-external-symlinks"               description:"Extract symlinks in outputs that point outside of the output's directory, warning about each, rather than failing"`
+	Tags           []string                       `          long:"tag"         value-name:"TAG"          description:"A tag for a specific environment (can be specified multiple times)"`
+	Excludes       []string                       `          long:"exclude"     value-name:"PATTERN"      description:"A glob pattern, relative to each input, of paths to skip uploading (can be specified multiple times)"`
+	Var            []flaghelpers.VariablePairFlag `short:"v" long:"var"         value-name:"NAME=VALUE"   description:"Variable flag that can be used for filling in template values in configuration"`
+	VarsFrom       []flaghelpers.PathFlag         `short:"l" long:"load-vars-from"                        description:"Variable flag that can be used for filling in template values in configuration from a YAML file"`
+	Params         []flaghelpers.VariablePairFlag `          long:"param"       value-name:"NAME=VALUE"   description:"Override a param declared by the task config (can be specified multiple times)"`
+	Env            []string                       `          long:"env"         value-name:"NAME"         description:"Forward an environment variable into the task's params, even if not declared (can be specified multiple times)"`
+	NoEnvParams    bool                           `          long:"no-env-params"                         description:"Do not override declared params with values from the environment"`
+	ShowParams     bool                           `          long:"show-params"                           description:"Show the values of the params the task will receive"`
+	PrintConfig    bool                           `          long:"print-config"                          description:"Print the task config as it will be sent, after all overrides and templating, to stderr before executing it"`
+	RedactParams   bool                           `          long:"redact-params"                         description:"Hide the values of params in the config printed by --print-config"`
+	Image          string                         `          long:"image"       value-name:"IMAGE"        description:"Override the image the task runs in"`
+	Timeout        time.Duration                  `          long:"timeout"     value-name:"DURATION"     description:"Abort the build if it runs for longer than this (e.g. 30m)"`
+	Parallelism    int                            `          long:"upload-parallelism" value-name:"N"     description:"Upload at most N inputs at a time (default: the number of inputs, up to 4)"`
+	Compression    string                         `          long:"compression" value-name:"LEVEL" default:"default" choice:"none" choice:"fast" choice:"default" choice:"best" description:"How hard to compress inputs while uploading them: none, fast, default or best"`
+	NoCompress     bool                           `          long:"no-compress"                           description:"Same as --compression=none, for fast networks or inputs that are already compressed"`
+	DryRun         bool                           `          long:"dry-run"                               description:"Print the build plan that would be executed, without executing it"`
+	MaxInputSize   flaghelpers.ByteSizeFlag       `          long:"max-input-size" value-name:"BYTES" default:"1GiB" description:"Ask for confirmation before uploading an input larger than this, after exclusions (0 for no limit)"`
+	ForceUpload    bool                           `          long:"force-upload"                          description:"Upload inputs larger than --max-input-size without asking"`
+	LimitRate      flaghelpers.ByteSizeFlag       `          long:"limit-rate"  value-name:"BYTES"        description:"Limit uploading inputs and downloading outputs to this many bytes per second in total, e.g. 2MiB (0 for no limit)"`
+	NoProgress     bool                           `          long:"no-progress"                           description:"Do not show progress while uploading inputs and downloading outputs"`
+	SkipAuthCheck  bool                           `          long:"skip-auth-check"                       description:"Do not check that the target's token is still valid before uploading inputs"`
+	Attach         string                         `          long:"attach"      value-name:"BUILD_ID"     description:"Reattach to the output of a running one-off build instead of executing a new one"`
+	Replay         bool                           `          long:"replay"                                description:"With --attach, replay the output of a build that has already finished"`
+	MergeOutput    bool                           `          long:"merge-output"                          description:"Write the task's stderr and fly's own messages to stdout along with the task's stdout"`
+	Quiet          bool                           `short:"q" long:"quiet"                                 description:"Do not print the build's logs, only its status (they are still written to --log-file)"`
+	HijackOnFail   bool                           `          long:"hijack-on-failure"                     description:"Open a shell in the task's container if the build fails or errors"`
+	LogFile        string                         `          long:"log-file"    value-name:"PATH"         description:"Also write the build's output, without colors, to the given file"`
+	Format         string                         `          long:"format"      value-name:"FORMAT" default:"text" choice:"text" choice:"json" description:"Output format: text, or json to print each event received as a line of JSON"`
+	Timestamps     bool                           `          long:"timestamps"                            description:"Prefix each line of output with the time it was logged (or set FLY_TIMESTAMPS=1)"`
+	Color          string                         `          long:"color"       value-name:"WHEN" default:"auto" choice:"always" choice:"never" choice:"auto" description:"Color the build's output: always, never, or auto to color it only on a terminal"`
+	MaxReconnects  int                            `          long:"max-reconnects" value-name:"N" default:"5" description:"Give up on the build's output after failing to reconnect N times"`
+	Retries        int                            `          long:"retry"       value-name:"N" default:"5" env:"FLY_HTTP_RETRIES" description:"Make up to N attempts at creating pipes and the build, and at uploading each input, while the ATC is briefly unavailable (or set FLY_HTTP_RETRIES)"`
 }
 
 func (command *ExecuteCommand) Execute(args []string) error {
-	connection, err := rc.TargetConnection(Fly.Target)
+	// builds are bounded by execute's own --timeout, not the global one
+	rc.SetCommandTimeout(0)
+
+	connection, err := targetConnection()
 
 	if err != nil {
 		log.Fatalln(err)
@@ -38,35 +112,280 @@ func (command *ExecuteCommand) Execute(args []string) error {
 
 	client := concourse.NewClient(connection)
 
+	if command.Attach != "" {
+		return command.attach(client, connection)
+	}
+
+	if command.Replay {
+		return errors.New("--replay can only be used with --attach")
+	}
+
+	if command.RedactParams && !command.PrintConfig {
+		return errors.New("--redact-params can only be used with --print-config")
+	}
+
 	taskConfigFile := command.TaskConfig
+	configFromJob := command.ConfigFrom.PipelineName != "" || command.ConfigFrom.JobName != ""
+
+	if taskConfigFile != "" && configFromJob {
+		return errors.New("only one of --config and --config-from may be specified")
+	}
+
+	if taskConfigFile == "" && !configFromJob {
+		return errors.New("either --config or --config-from must be specified")
+	}
+
+	if command.LogFile == "-" {
+		return errors.New("--log-file cannot be -; use --format json for output meant for other programs")
+	}
+
+	if command.Format == "json" {
+		if command.Quiet {
+			return errors.New("--quiet cannot be used with --format json")
+		}
+
+		for _, output := range command.Outputs {
+			if output.Path == "-" {
+				return errors.New("--format json cannot be used with an output written to stdout")
+			}
+		}
+
+		for _, mapping := range command.OutputMappings {
+			if mapping.Value == "-" {
+				return errors.New("--format json cannot be used with an output written to stdout")
+			}
+		}
+	}
+
+	if taskConfigFile == "-" {
+		if command.InputFromStdin.Name != "" {
+			return errors.New("stdin cannot be used for both --config and --input-from-stdin")
+		}
+	}
+
+	if command.InputFromStdin.Name != "" {
+		// rather than waiting for input that was never going to be piped in
+		if isatty.IsTerminal(os.Stdin.Fd()) {
+			return errors.New("--input-from-stdin needs data piped or redirected into fly")
+		}
+	}
+
 	excludeIgnored := command.ExcludeIgnored
+	respectGitignore := command.RespectIgnore
+	excludes := command.Excludes
+
+	if command.IncludeIgnored {
+		excludeIgnored = false
+		respectGitignore = false
+	}
+
+	logs, taskStdout, taskStderr := command.outputStreams()
+
+	compressionLevel := executehelpers.CompressionLevels[command.Compression]
+	if command.NoCompress {
+		compressionLevel = executehelpers.CompressionLevels["none"]
+	}
 
 	atcRequester := deprecated.NewAtcRequester(connection.URL(), connection.HTTPClient())
 
-	taskConfig := config.LoadTaskConfig(string(taskConfigFile), args)
+	// inputs and outputs share a single limit, however many are in flight
+	bitsRequester := atcRequester
+	if command.LimitRate > 0 {
+		limitedClient := *connection.HTTPClient()
+		limitedClient.Transport = executehelpers.NewRateLimiter(int64(command.LimitRate)).Transport(limitedClient.Transport)
+
+		bitsRequester = deprecated.NewAtcRequester(connection.URL(), &limitedClient)
+	}
+
+	fileVariables, flagVariables := loadVariables(command.VarsFrom, command.Var)
+
+	var taskConfig atc.TaskConfig
+	if configFromJob {
+		taskConfig, err = executehelpers.TaskConfigFromJob(
+			client,
+			command.ConfigFrom,
+			command.Step,
+			command.Inputs,
+			fileVariables,
+			flagVariables,
+		)
+		if err != nil {
+			return err
+		}
+	} else {
+		configPath, err := config.ResolveTaskConfigPath(string(taskConfigFile))
+		if err != nil {
+			return err
+		}
+
+		taskConfig = config.ReadTaskConfig(configPath, fileVariables, flagVariables)
+	}
+
+	paramOverrides := map[string]string{}
+	for _, p := range command.Params {
+		paramOverrides[p.Name] = p.Value
+	}
+
+	taskConfig, err = config.ResolveTaskConfig(taskConfig, config.Overrides{
+		Args:      args,
+		EnvParams: !command.NoEnvParams,
+		Env:       command.Env,
+		Params:    paramOverrides,
+		Image:     command.Image,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the plan is the only thing printed to stdout
+	if command.DryRun {
+		logs = os.Stderr
+	}
+
+	printParams(logs, taskConfig.Params, command.ShowParams)
+
+	// checked before any pipes are created or bits uploaded
+	if !command.DryRun && !command.SkipAuthCheck {
+		err := checkToken(client, Fly.Target)
+		if err != nil {
+			return err
+		}
+	}
+
+	// creating the build itself is retried too, as is uploading each input
+	retryingClient := executehelpers.RetryingClient(client, command.Retries, executehelpers.DefaultRetryBackoff, logs)
+
+	pipeClient := retryingClient
+	if command.DryRun {
+		pipeClient = executehelpers.DryRunClient(client)
+	}
+
+	inputMappings := command.Inputs
+
+	// stdin is written to a file that's then uploaded like any other input
+	var stdinInputPath string
+	if command.InputFromStdin.Name != "" {
+		stdinInputPath, err = executehelpers.SpoolInput(os.Stdin, command.InputFromStdin.FileName)
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %s", err)
+		}
+
+		defer os.RemoveAll(stdinInputPath)
+
+		inputMappings = append(inputMappings, flaghelpers.InputPairFlag{
+			Name: command.InputFromStdin.Name,
+			Path: stdinInputPath,
+		})
+	}
 
 	inputs, err := executehelpers.DetermineInputs(
-		client,
+		pipeClient,
 		taskConfig.Inputs,
-		command.Inputs,
+		inputMappings,
+		command.InputMappings,
 		command.InputsFrom,
 	)
 	if err != nil {
 		return err
 	}
 
+	if command.Dereference {
+		for i := range inputs {
+			inputs[i].Dereference = inputs[i].Path != ""
+		}
+	}
+
+	inputs, err = executehelpers.AddIncludes(inputs, command.Includes)
+	if err != nil {
+		return err
+	}
+
 	outputs, err := executehelpers.DetermineOutputs(
-		client,
+		pipeClient,
 		taskConfig.Outputs,
 		command.Outputs,
+		command.OutputMappings,
+		command.OutputArchive,
 	)
 	if err != nil {
 		return err
 	}
 
+	// what's printed is exactly what goes into the plan
+	if command.PrintConfig {
+		err := config.PrintTaskConfig(os.Stderr, taskConfig, command.RedactParams)
+		if err != nil {
+			return err
+		}
+	}
+
+	if command.DryRun {
+		plan, err := executehelpers.BuildPlan(
+			atcRequester,
+			string(command.BuildName),
+			command.Privileged,
+			inputs,
+			outputs,
+			taskConfig,
+			command.Tags,
+			Fly.Target,
+		)
+		if err != nil {
+			return err
+		}
+
+		payload, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(payload))
+
+		return nil
+	}
+
+	if !command.ForceUpload && command.MaxInputSize > 0 {
+		err := command.confirmLargeInputs(inputs, excludeIgnored, respectGitignore, excludes)
+		if err != nil {
+			return err
+		}
+	}
+
+	hijackOnFailure := command.HijackOnFail
+	if hijackOnFailure && !isatty.IsTerminal(os.Stdin.Fd()) {
+		fmt.Fprintln(os.Stderr, "warning: ignoring --hijack-on-failure as stdin is not a terminal")
+		hijackOnFailure = false
+	}
+
+	colorOutput := useColor(command.Color, taskStdout)
+	colorLogs := useColor(command.Color, logs)
+
+	if command.Quiet {
+		taskStdout = ioutil.Discard
+		taskStderr = ioutil.Discard
+	}
+
+	// opened before creating the build so that a bad path fails fast
+	var logFile *os.File
+	if command.LogFile != "" {
+		logFile, err = os.Create(command.LogFile)
+		if err != nil {
+			return fmt.Errorf("failed to create log file: %s", err)
+		}
+
+		defer logFile.Close()
+
+		plainLog := displayhelpers.StripANSI(logFile)
+
+		logs = io.MultiWriter(logs, plainLog)
+		taskStdout = io.MultiWriter(taskStdout, plainLog)
+		taskStderr = io.MultiWriter(taskStderr, plainLog)
+	}
+
 	build, err := executehelpers.CreateBuild(
 		atcRequester,
-		client,
+		retryingClient,
+		string(command.BuildName),
 		command.Privileged,
 		inputs,
 		outputs,
@@ -78,78 +397,473 @@ func (command *ExecuteCommand) Execute(args []string) error {
 		return err
 	}
 
-	fmt.Println("executing build", build.ID)
+	url := buildURL(connection.URL(), build)
+
+	if logFile != nil {
+		err := writeLogHeader(logFile, build, url, taskConfig, command.ShowParams)
+		if err != nil {
+			return fmt.Errorf("failed to write log file: %s", err)
+		}
+	}
+
+	executing := fmt.Sprintf("executing build %d", build.ID)
+	if command.BuildName != defaultBuildName {
+		executing += fmt.Sprintf(" as '%s'", command.BuildName)
+	}
+
+	if taskConfig.Image != "" {
+		executing += fmt.Sprintf(" (image: %s)", taskConfig.Image)
+	}
+
+	fmt.Fprintln(logs, executing)
+
+	fmt.Fprintln(logs, url)
 
 	terminate := make(chan os.Signal, 1)
 
-	go abortOnSignal(client, terminate, build)
+	cancelUploads := make(chan struct{})
+
+	go abortOnSignal(client, terminate, build, cancelUploads, url)
 
 	signal.Notify(terminate, syscall.SIGINT, syscall.SIGTERM)
 
-	inputChan := make(chan interface{})
+	timedOut := make(chan struct{})
+	if command.Timeout > 0 {
+		time.AfterFunc(command.Timeout, func() {
+			fmt.Fprintf(os.Stderr, "\ntimed out after %s\n", command.Timeout)
+			close(timedOut)
+
+			// abort just as if interrupted
+			select {
+			case terminate <- syscall.SIGTERM:
+			default:
+			}
+		})
+	}
+
+	localInputs := []executehelpers.Input{}
+	for _, i := range inputs {
+		if i.Path != "" {
+			localInputs = append(localInputs, i)
+		}
+	}
+
+	parallelism := command.Parallelism
+	if parallelism == 0 {
+		parallelism = len(localInputs)
+		if parallelism > maxDefaultUploadParallelism {
+			parallelism = maxDefaultUploadParallelism
+		}
+	}
+
+	// the build starts while inputs are still uploading, so its output and
+	// the progress go through the console to keep from garbling each other
+	var console *executehelpers.Console
+	if !command.NoProgress && !command.Quiet {
+		console = executehelpers.NewConsole(os.Stderr, isatty.IsTerminal(os.Stderr.Fd()))
+		logs = console.Writer(logs)
+		taskStdout = console.Writer(taskStdout)
+		taskStderr = console.Writer(taskStderr)
+	}
+
+	started := time.Now()
+
+	var uploadsFinished time.Time
+	inputChan := make(chan error, 1)
 	go func() {
-		for _, i := range inputs {
-			if i.Path != "" {
-				executehelpers.Upload(i, excludeIgnored, atcRequester)
+		err := executehelpers.UploadAll(localInputs, parallelism, func(input executehelpers.Input) error {
+			// exclusions are meant for directories, not the file from stdin
+			if input.Path == stdinInputPath {
+				return executehelpers.Upload(input, false, false, nil, compressionLevel, console, cancelUploads, bitsRequester, command.Retries, executehelpers.DefaultRetryBackoff, logs)
 			}
+
+			return executehelpers.Upload(input, excludeIgnored, respectGitignore, excludes, compressionLevel, console, cancelUploads, bitsRequester, command.Retries, executehelpers.DefaultRetryBackoff, logs)
+		})
+
+		// fly exits without running deferred calls once the build is running
+		if stdinInputPath != "" {
+			os.RemoveAll(stdinInputPath)
 		}
-		close(inputChan)
-	}()
 
-	var outputChans []chan (interface{})
-	if len(outputs) > 0 {
-		for i, output := range outputs {
-			outputChans = append(outputChans, make(chan interface{}, 1))
-			go func(o executehelpers.Output, outputChan chan<- interface{}) {
-				if o.Path != "" {
-					executehelpers.Download(o, atcRequester)
+		select {
+		case <-cancelUploads:
+			// the build is already being aborted
+			err = nil
+		default:
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "failed to upload inputs:", err)
+
+				abortErr := abortBuild(client, build.ID)
+				if abortErr != nil {
+					fmt.Fprintln(os.Stderr, "failed to abort:", abortErr)
 				}
+			}
+		}
+
+		uploadsFinished = time.Now()
+		inputChan <- err
+	}()
 
-				close(outputChan)
-			}(output, outputChans[i])
+	localOutputs := []executehelpers.Output{}
+	for _, o := range outputs {
+		if o.Path != "" {
+			localOutputs = append(localOutputs, o)
 		}
 	}
 
-	eventSource, err := client.BuildEvents(fmt.Sprintf("%d", build.ID))
+	var downloadsFinished time.Time
+	outputChan := make(chan error, 1)
+	go func() {
+		succeeded, err := executehelpers.DownloadAll(localOutputs, func(output executehelpers.Output) error {
+			return executehelpers.Download(output, command.ExternalLinks, console, bitsRequester, logs)
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to download outputs:", err)
+
+			if len(succeeded) > 0 {
+				fmt.Fprintln(os.Stderr, "downloaded outputs:", strings.Join(succeeded, ", "))
+			}
+		}
+
+		downloadsFinished = time.Now()
+		outputChan <- err
+	}()
+
+	eventSource, err := buildEvents(connection, build.ID, command.MaxReconnects)
 
 	if err != nil {
 		log.Println("failed to attach to stream:", err)
 		os.Exit(1)
 	}
 
-	exitCode := eventstream.Render(os.Stdout, eventSource)
+	summarized := &summaryEvents{Events: eventSource}
+
+	exitCode := command.render(summarized, build.ID, logs, taskStdout, taskStderr, colorOutput)
 	eventSource.Close()
 
-	<-inputChan
+	finished := time.Now()
 
-	if len(outputs) > 0 {
-		for _, outputChan := range outputChans {
-			<-outputChan
+	uploadErr := <-inputChan
+	downloadErr := <-outputChan
+
+	if uploadErr != nil && exitCode == 0 {
+		exitCode = 1
+	}
+
+	// the build succeeded, but what it produced didn't arrive intact
+	if downloadErr != nil && exitCode == 0 {
+		exitCode = eventstream.ExitErrored
+	}
+
+	select {
+	case <-timedOut:
+		exitCode = 2
+	default:
+	}
+
+	timings := buildTimings{
+		total:      time.Since(started),
+		upload:     uploadsFinished.Sub(started),
+		run:        finished.Sub(uploadsFinished),
+		download:   downloadsFinished.Sub(finished),
+		uploaded:   len(localInputs) > 0,
+		downloaded: len(localOutputs) > 0,
+	}
+
+	if timings.run < 0 {
+		timings.run = finished.Sub(started)
+	}
+
+	if timings.download < 0 {
+		timings.download = 0
+	}
+
+	fmt.Fprintln(logs, buildSummary(build.ID, summarized, exitCode, timings, colorLogs))
+
+	// repeat the URL near the bottom of the scrollback
+	if exitCode != 0 {
+		fmt.Fprintln(logs, url)
+	}
+
+	if hijackOnFailure && (summarized.status == atc.StatusFailed || summarized.status == atc.StatusErrored) {
+		err := command.hijackBuild(client, build)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to hijack:", err)
+		}
+	}
+
+	// the shell's exit status is of no interest to whatever ran fly
+	os.Exit(exitCode)
+
+	return nil
+}
+
+// confirmLargeInputs guards against accidentally uploading far more than
+// intended, e.g. by running execute from a home directory.
+func (command *ExecuteCommand) confirmLargeInputs(inputs []executehelpers.Input, excludeIgnored bool, respectGitignore bool, excludes []string) error {
+	for _, input := range inputs {
+		if input.Path == "" {
+			continue
+		}
+
+		size, err := executehelpers.SizeOf(input, excludeIgnored, respectGitignore, excludes)
+		if err != nil {
+			return fmt.Errorf("could not determine the size of input '%s': %s", input.Name, err)
+		}
+
+		if size.Total <= int64(command.MaxInputSize) {
+			continue
+		}
+
+		fmt.Fprintf(os.Stderr, "input '%s' is %s, more than --max-input-size (%s):\n", input.Name, ui.FormatBytes(float64(size.Total)), ui.FormatBytes(float64(command.MaxInputSize)))
+
+		for i, entry := range size.Entries {
+			if i == maxLargeInputEntries {
+				fmt.Fprintf(os.Stderr, "  ...and %d more\n", len(size.Entries)-i)
+				break
+			}
+
+			fmt.Fprintf(os.Stderr, "  %10s  %s\n", ui.FormatBytes(float64(entry.Size)), entry.Name)
+		}
+
+		fmt.Fprintln(os.Stderr, "")
+
+		if !isatty.IsTerminal(os.Stdin.Fd()) {
+			return fmt.Errorf("not uploading input '%s'; exclude paths with --exclude, raise --max-input-size, or pass --force-upload to upload it anyway", input.Name)
+		}
+
+		confirm := false
+		err = interact.NewInteraction("upload it anyway?").Resolve(&confirm)
+		if err != nil {
+			return err
+		}
+
+		if !confirm {
+			return fmt.Errorf("not uploading input '%s'", input.Name)
 		}
 	}
 
+	return nil
+}
+
+// hijackBuild opens a shell in the container the build's task ran in
+func (command *ExecuteCommand) hijackBuild(client concourse.Client, build atc.Build) error {
+	target, err := rc.SelectTarget(Fly.Target)
+	if err != nil {
+		return err
+	}
+
+	containers, err := client.ListContainers(map[string]string{
+		"build-id": strconv.Itoa(build.ID),
+		"name":     string(command.BuildName),
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(containers) == 0 {
+		return fmt.Errorf("no container found for build %d; it may have already been removed", build.ID)
+	}
+
+	fmt.Fprintf(os.Stderr, "hijacking build %d's container; exit the shell when done\n", build.ID)
+
+	hijackContainer(target, containers[0].ID, nil)
+
+	return nil
+}
+
+// attach streams the output of an existing build from the beginning, exiting
+// just as if it had been executed
+func (command *ExecuteCommand) attach(client concourse.Client, connection concourse.Connection) error {
+	build, found, err := client.Build(command.Attach)
+	if err != nil {
+		return fmt.Errorf("failed to get build: %s", err)
+	}
+
+	if !found {
+		return errors.New("build not found")
+	}
+
+	running := buildRunning(build)
+	if !running && !command.Replay {
+		return fmt.Errorf("build %d has already finished (%s); use --replay to see its output", build.ID, build.Status)
+	}
+
+	url := buildURL(connection.URL(), build)
+
+	logs, taskStdout, taskStderr := command.outputStreams()
+
+	fmt.Fprintln(logs, "attaching to build", build.ID)
+	fmt.Fprintln(logs, url)
+
+	if running {
+		terminate := make(chan os.Signal, 1)
+
+		go abortOnSignal(client, terminate, build, make(chan struct{}), url)
+
+		signal.Notify(terminate, syscall.SIGINT, syscall.SIGTERM)
+	}
+
+	eventSource, err := buildEvents(connection, build.ID, command.MaxReconnects)
+	if err != nil {
+		log.Println("failed to attach to stream:", err)
+		os.Exit(1)
+	}
+
+	exitCode := command.render(eventSource, build.ID, logs, taskStdout, taskStderr, useColor(command.Color, taskStdout))
+	eventSource.Close()
+
+	if exitCode != 0 {
+		fmt.Fprintln(logs, url)
+	}
+
 	os.Exit(exitCode)
 
 	return nil
 }
 
+func (command *ExecuteCommand) render(eventSource concourse.Events, buildID int, logs io.Writer, taskStdout io.Writer, taskStderr io.Writer, colorOutput bool) int {
+	if command.Format == "json" {
+		return eventstream.RenderJSON(taskStdout, eventSource, buildID)
+	}
+
+	return eventstream.Render(logs, eventSource, eventstream.RenderOptions{
+		Stdout:        taskStdout,
+		Stderr:        taskStderr,
+		ShowTimestamp: showTimestamps(command.Timestamps),
+		Color:         colorOutput,
+	})
+}
+
+// outputStreams determines where fly's own messages, the task's stdout, and
+// the task's stderr are written. Only the task's stdout goes to stdout,
+// unless told to merge everything into it as fly used to.
+func (command *ExecuteCommand) outputStreams() (io.Writer, io.Writer, io.Writer) {
+	// stdout is reserved for events, and fly's messages are of no use to
+	// whatever is reading them
+	if command.Format == "json" {
+		return ioutil.Discard, os.Stdout, os.Stderr
+	}
+
+	// keep stdout clean for an output being streamed to it
+	for _, output := range command.Outputs {
+		if output.Path == "-" {
+			return os.Stderr, os.Stderr, os.Stderr
+		}
+	}
+
+	for _, mapping := range command.OutputMappings {
+		if mapping.Value == "-" {
+			return os.Stderr, os.Stderr, os.Stderr
+		}
+	}
+
+	if command.MergeOutput {
+		return os.Stdout, os.Stdout, os.Stdout
+	}
+
+	return os.Stderr, os.Stdout, os.Stderr
+}
+
+// writeLogHeader describes the build at the top of its log file
+func writeLogHeader(dst io.Writer, build atc.Build, url string, taskConfig atc.TaskConfig, showParams bool) error {
+	if !showParams {
+		taskConfig = config.RedactParams(taskConfig)
+	}
+
+	payload, err := yaml.Marshal(taskConfig)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(dst, "build: %d\ntarget: %s\nurl: %s\nconfig:\n%s\n", build.ID, Fly.Target, url, indent(string(payload)))
+	return err
+}
+
+func indent(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	return "  " + strings.Join(lines, "\n  ")
+}
+
+func printParams(dst io.Writer, params map[string]string, showValues bool) {
+	if len(params) == 0 {
+		return
+	}
+
+	names := []string{}
+	for name := range params {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	fmt.Fprintln(dst, "params:")
+
+	for _, name := range names {
+		value := "[redacted]"
+		if showValues {
+			value = params[name]
+		}
+
+		fmt.Fprintf(dst, "  %s: %s\n", name, value)
+	}
+}
+
 func abortOnSignal(
 	client concourse.Client,
 	terminate <-chan os.Signal,
 	build atc.Build,
+	cancelUploads chan<- struct{},
+	buildURL string,
 ) {
 	<-terminate
 
 	fmt.Fprintf(os.Stderr, "\naborting...\n")
 
-	err := client.AbortBuild(strconv.Itoa(build.ID))
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "failed to abort:", err)
-		return
-	}
+	close(cancelUploads)
 
-	// if told to terminate again, exit immediately
+	aborted := make(chan struct{})
+	go func() {
+		defer close(aborted)
+
+		err := abortBuild(client, build.ID)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to abort:", err)
+		}
+	}()
+
+	// if told to terminate again, stop waiting for the build to finish, but
+	// give the abort request a chance to make it through
 	<-terminate
-	fmt.Fprintln(os.Stderr, "exiting immediately")
-	os.Exit(2)
+
+	select {
+	case <-aborted:
+	case <-time.After(abortGracePeriod):
+	}
+
+	fmt.Fprintln(os.Stderr, "detached from build", build.ID, "at", buildURL)
+	os.Exit(detachedExitCode)
+}
+
+func buildURL(targetURL string, build atc.Build) string {
+	return strings.TrimRight(targetURL, "/") + "/builds/" + strconv.Itoa(build.ID)
+}
+
+func loadVariables(varsFrom []flaghelpers.PathFlag, vars []flaghelpers.VariablePairFlag) (template.Variables, template.Variables) {
+	var fileVariables template.Variables
+	for _, path := range varsFrom {
+		fileVars, err := template.LoadVariablesFromFile(string(path))
+		if err != nil {
+			displayhelpers.FailWithErrorf("failed to load variables from file (%s)", err, string(path))
+		}
+
+		fileVariables = fileVariables.Merge(fileVars)
+	}
+
+	flagVariables := template.Variables{}
+	for _, v := range vars {
+		flagVariables[v.Name] = v.Value
+	}
+
+	return fileVariables, flagVariables
 }
@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/atc/event"
+	"github.com/concourse/fly/eventstream"
+	"github.com/concourse/go-concourse/concourse"
+)
+
+// summaryEvents notes the build's status and first error as its events are
+// rendered
+type summaryEvents struct {
+	concourse.Events
+
+	status     atc.BuildStatus
+	firstError string
+}
+
+func (events *summaryEvents) NextEvent() (atc.Event, error) {
+	ev, err := events.Events.NextEvent()
+	if err != nil {
+		return ev, err
+	}
+
+	switch e := ev.(type) {
+	case event.Status:
+		events.status = e.Status
+	case event.Error:
+		if events.firstError == "" {
+			events.firstError = e.Message
+		}
+	}
+
+	return ev, nil
+}
+
+type buildTimings struct {
+	total    time.Duration
+	upload   time.Duration
+	run      time.Duration
+	download time.Duration
+
+	uploaded   bool
+	downloaded bool
+}
+
+// buildSummary describes how the build went, e.g. "build 128 succeeded in
+// 4m32s (upload 12s, run 4m8s, download 12s)"
+func buildSummary(buildID int, events *summaryEvents, exitCode int, timings buildTimings, colorOutput bool) string {
+	status := events.status
+	if status == "" || status == atc.StatusStarted || status == atc.StatusPending {
+		switch {
+		case exitCode == 0:
+			status = atc.StatusSucceeded
+		case events.firstError != "":
+			status = atc.StatusErrored
+		default:
+			status = atc.StatusFailed
+		}
+	}
+
+	phases := []string{}
+	if timings.uploaded {
+		phases = append(phases, "upload "+roundDuration(timings.upload).String())
+	}
+
+	phases = append(phases, "run "+roundDuration(timings.run).String())
+
+	if timings.downloaded {
+		phases = append(phases, "download "+roundDuration(timings.download).String())
+	}
+
+	summary := fmt.Sprintf(
+		"build %d %s in %s (%s)",
+		buildID,
+		eventstream.PaintStatus(status, string(status), colorOutput),
+		roundDuration(timings.total),
+		strings.Join(phases, ", "),
+	)
+
+	if (status == atc.StatusFailed || status == atc.StatusErrored) && events.firstError != "" {
+		summary += ": " + events.firstError
+	}
+
+	return summary
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	return (d + time.Second/2) / time.Second * time.Second
+}
@@ -1,15 +1,43 @@
 package commands
 
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/concourse/fly/rc"
+)
+
 type FlyCommand struct {
-	Target string `short:"t" long:"target" description:"Concourse target name or URL" default:"http://192.168.100.4:8080"`
+	Target string    `short:"t" long:"target" description:"Concourse target name or URL (default: $FLY_TARGET, or else the target set with set-default-target)"`
+	Flyrc  FlyrcFlag `long:"flyrc" description:"Path to the file targets are saved in (default: $FLYRC, or else ~/.flyrc)"`
+	Proxy  string    `long:"proxy" description:"URL of the proxy to reach the target through, instead of the one from HTTP_PROXY, HTTPS_PROXY and NO_PROXY"`
+
+	IgnoreVersionMismatch bool        `long:"ignore-version-mismatch" description:"Don't warn when fly's version doesn't match the target's"`
+	Verbose               VerboseFlag `long:"verbose" value-name:"LEVEL" optional:"true" optional-value:"1" env:"FLY_VERBOSE" description:"Log every HTTP request's method, URL, status and duration to stderr; --verbose=2 also logs headers and bodies (or set FLY_VERBOSE)"`
+	Version               func()      `long:"version" description:"Print the version of fly and exit"`
 
-	Login LoginCommand `command:"login" alias:"l" description:"Authenticate with the target"`
-	Sync  SyncCommand  `command:"sync"  alias:"s" description:"Download and replace the current fly from the target"`
+	Timeout           CommandTimeoutFlag `long:"timeout"             value-name:"DURATION" env:"FLY_TIMEOUT"                          description:"Give up on the command if it takes longer, e.g. 30s; execute, watch, hijack and trigger-job --watch aren't bounded (or set FLY_TIMEOUT)"`
+	RequestTimeout    RequestTimeoutFlag `long:"request-timeout"     value-name:"DURATION" env:"FLY_REQUEST_TIMEOUT"     default:"1m" description:"Give up on connecting to the target, or on a response to a request, after this long; 0 waits forever (or set FLY_REQUEST_TIMEOUT)"`
+	StreamIdleTimeout time.Duration      `long:"stream-idle-timeout" value-name:"DURATION" env:"FLY_STREAM_IDLE_TIMEOUT" default:"5m" description:"Reconnect to a build's event stream when it sends nothing for this long; 0 never does (or set FLY_STREAM_IDLE_TIMEOUT)"`
+
+	Login            LoginCommand            `command:"login"              alias:"l"   description:"Authenticate with the target"`
+	Targets          TargetsCommand          `command:"targets"            alias:"ts"  description:"List the saved targets"`
+	DeleteTarget     DeleteTargetCommand     `command:"delete-target"      alias:"dtg" description:"Delete the target from .flyrc"`
+	RenameTarget     RenameTargetCommand     `command:"rename-target"      alias:"rt"  description:"Rename a target saved in .flyrc"`
+	SetDefaultTarget SetDefaultTargetCommand `command:"set-default-target" alias:"sdt" description:"Use the target when none is given with -t or FLY_TARGET"`
+	Sync             SyncCommand             `command:"sync"               alias:"s"   description:"Download and replace the current fly from the target"`
+	Status           StatusCommand           `command:"status"             alias:"st"  description:"Check that the target is reachable and accepts the token"`
+	VersionInfo      VersionCommand          `command:"version"            alias:"v"   description:"Print the version of fly"`
 
 	Checklist ChecklistCommand `command:"checklist" alias:"cl" description:"Print a Checkfile of the given pipeline"`
 
-	Execute ExecuteCommand `command:"execute" alias:"e" description:"Execute a one-off build using local bits"`
-	Watch   WatchCommand   `command:"watch"   alias:"w" description:"Stream a build's output"`
+	Execute      ExecuteCommand      `command:"execute"       alias:"e"  description:"Execute a one-off build using local bits"`
+	ValidateTask ValidateTaskCommand `command:"validate-task" alias:"vt" description:"Validate a task config without executing it"`
+	Watch        WatchCommand        `command:"watch"         alias:"w"  description:"Stream a build's output"`
+
+	Builds     BuildsCommand     `command:"builds"      alias:"bs" description:"List the most recent builds"`
+	AbortBuild AbortBuildCommand `command:"abort-build" alias:"ab" description:"Abort a running build"`
 
 	Containers ContainersCommand `command:"containers" alias:"cs" description:"Print the active containers"`
 	Hijack     HijackCommand     `command:"hijack"     alias:"intercept" alias:"i" description:"Execute a command in a container"`
@@ -21,8 +49,116 @@ type FlyCommand struct {
 	PausePipeline   PausePipelineCommand   `command:"pause-pipeline"   alias:"pp" description:"Pause a pipeline"`
 	UnpausePipeline UnpausePipelineCommand `command:"unpause-pipeline" alias:"up" description:"Un-pause a pipeline"`
 
+	TriggerJob TriggerJobCommand `command:"trigger-job" alias:"tj" description:"Start a build of a job"`
+	PauseJob   PauseJobCommand   `command:"pause-job"   alias:"pj" description:"Pause a job"`
+	UnpauseJob UnpauseJobCommand `command:"unpause-job" alias:"uj" description:"Un-pause a job"`
+
+	CheckResource CheckResourceCommand `command:"check-resource" alias:"cr" description:"Check a resource for new versions"`
+
 	Volumes VolumesCommand `command:"volumes" alias:"vs" description:"List the active volumes"`
 	Workers WorkersCommand `command:"workers" alias:"ws" description:"List the registered workers"`
+
+	defaultTarget       string
+	defaultTargetSource string
 }
 
 var Fly FlyCommand
+
+// LoadDefaultTarget sets the target to use unless -t is given; it must be
+// called before parsing flags, and is called again by --flyrc
+func (fly *FlyCommand) LoadDefaultTarget() error {
+	target, source, err := rc.DefaultTarget()
+	if err != nil {
+		return err
+	}
+
+	if fly.Target == fly.defaultTarget {
+		fly.Target = target
+	}
+
+	fly.defaultTarget = target
+	fly.defaultTargetSource = source
+
+	return nil
+}
+
+// FlyrcFlag switches to the file as soon as it is parsed, so that the
+// default target comes from it too
+type FlyrcFlag string
+
+func (flag *FlyrcFlag) UnmarshalFlag(value string) error {
+	*flag = FlyrcFlag(value)
+
+	rc.SetFlyrc(value)
+
+	return Fly.LoadDefaultTarget()
+}
+
+// VerboseFlag turns on tracing as soon as it is parsed, so that every
+// connection the command makes is traced
+type VerboseFlag int
+
+func (flag *VerboseFlag) UnmarshalFlag(value string) error {
+	verbosity, err := strconv.Atoi(value)
+	if err != nil || verbosity < 0 {
+		return fmt.Errorf("invalid verbosity '%s' (must be 0, 1 or 2)", value)
+	}
+
+	*flag = VerboseFlag(verbosity)
+
+	rc.SetVerbosity(verbosity)
+
+	return nil
+}
+
+// CommandTimeoutFlag starts the clock as soon as it is parsed, so that the
+// whole command is bounded
+type CommandTimeoutFlag time.Duration
+
+func (flag *CommandTimeoutFlag) UnmarshalFlag(value string) error {
+	timeout, err := parseTimeout(value)
+	if err != nil {
+		return err
+	}
+
+	*flag = CommandTimeoutFlag(timeout)
+
+	rc.SetCommandTimeout(timeout)
+
+	return nil
+}
+
+// RequestTimeoutFlag applies as soon as it is parsed, so that every
+// connection the command makes uses it
+type RequestTimeoutFlag time.Duration
+
+func (flag *RequestTimeoutFlag) UnmarshalFlag(value string) error {
+	timeout, err := parseTimeout(value)
+	if err != nil {
+		return err
+	}
+
+	*flag = RequestTimeoutFlag(timeout)
+
+	rc.SetRequestTimeout(timeout)
+
+	return nil
+}
+
+func parseTimeout(value string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("invalid timeout '%s' (must be a duration such as 30s or 5m)", value)
+	}
+
+	return timeout, nil
+}
+
+// targetSource says where the target came from, for showing to the user
+func (fly *FlyCommand) targetSource() string {
+	if fly.defaultTarget == "" || fly.Target != fly.defaultTarget {
+		return "-t"
+	}
+
+	return fly.defaultTargetSource
+}
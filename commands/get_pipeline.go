@@ -9,7 +9,6 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"github.com/concourse/atc"
-	"github.com/concourse/fly/rc"
 	"github.com/concourse/go-concourse/concourse"
 )
 
@@ -22,34 +21,47 @@ func (command *GetPipelineCommand) Execute(args []string) error {
 	asJSON := command.JSON
 	pipelineName := command.Pipeline
 
-	connection, err := rc.TargetConnection(Fly.Target)
+	connection, err := targetConnection()
 	if err != nil {
 		log.Fatalln(err)
 	}
 
 	client := concourse.NewClient(connection)
-	config, _, _, err := client.PipelineConfig(pipelineName)
+	config, version, found, err := client.PipelineConfig(pipelineName)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	dump(config, asJSON)
+	if !found {
+		fmt.Fprintf(os.Stderr, "pipeline '%s' not found\n", pipelineName)
+		os.Exit(1)
+	}
+
+	dump(config, version, asJSON)
 	return nil
 }
 
-func dump(config atc.Config, asJSON bool) {
+// dump prints the config with the fields in atc.Config's declaration order.
+// YAML output is headed by the config version as a comment, so it is still
+// valid input for set-pipeline.
+func dump(config atc.Config, version string, asJSON bool) {
 	var payload []byte
 	var err error
 	if asJSON {
-		payload, err = json.Marshal(config)
+		payload, err = json.MarshalIndent(config, "", "  ")
+		payload = append(payload, '\n')
 	} else {
 		payload, err = yaml.Marshal(config)
 	}
 
 	if err != nil {
-		log.Println("failed to marshal config to YAML:", err)
+		log.Println("failed to marshal config:", err)
 		os.Exit(1)
 	}
 
+	if !asJSON && version != "" {
+		fmt.Printf("# version: %s\n", version)
+	}
+
 	fmt.Printf("%s", payload)
 }
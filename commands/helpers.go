@@ -3,21 +3,24 @@ package commands
 import (
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/concourse/atc"
+	"github.com/concourse/fly/apierror"
+	"github.com/concourse/fly/eventstream"
+	"github.com/concourse/fly/rc"
 	"github.com/concourse/go-concourse/concourse"
+	"github.com/mattn/go-isatty"
+	"github.com/tedsuo/rata"
 )
 
 func handleBadResponse(process string, resp *http.Response) {
-	b, err := ioutil.ReadAll(resp.Body)
-	resp.Body.Close()
-	if err != nil {
-		log.Fatalln("failed to read response when %s:", process, err)
-	}
-	log.Fatalf("bad response when %s:\n%s\n%s", process, resp.Status, b)
+	log.Fatalln(apierror.FromResponse(process, resp))
 }
 
 func GetBuild(client concourse.Client, jobName string, buildNameOrID string, pipelineName string) (atc.Build, error) {
@@ -81,3 +84,124 @@ func GetBuild(client concourse.Client, jobName string, buildNameOrID string, pip
 		return atc.Build{}, errors.New("no builds match job")
 	}
 }
+
+// latestBuild returns the most recent build visible to the target, whether
+// or not it belongs to a job
+func latestBuild(client concourse.Client) (atc.Build, error) {
+	allBuilds, err := client.AllBuilds()
+	if err != nil {
+		return atc.Build{}, fmt.Errorf("failed to get builds %s", err)
+	}
+
+	if len(allBuilds) == 0 {
+		return atc.Build{}, errors.New("no builds found")
+	}
+
+	return allBuilds[0], nil
+}
+
+// buildRunning determines whether the build has yet to finish
+func buildRunning(build atc.Build) bool {
+	return build.Status == string(atc.StatusPending) || build.Status == string(atc.StatusStarted)
+}
+
+// buildEvents streams the events of a build, surviving dropped connections
+func buildEvents(connection concourse.Connection, buildID int, maxReconnects int) (concourse.Events, error) {
+	requestGenerator := rata.NewRequestGenerator(connection.URL(), atc.Routes)
+
+	var trace io.Writer
+	if Fly.Verbose > 0 {
+		trace = os.Stderr
+	}
+
+	return eventstream.Connect(connection.HTTPClient(), func() (*http.Request, error) {
+		return requestGenerator.CreateRequest(atc.BuildEvents, rata.Params{"build_id": strconv.Itoa(buildID)}, nil)
+	}, maxReconnects, Fly.StreamIdleTimeout, reauthenticate, trace)
+}
+
+// reauthenticate has the user log in again when their token is rejected
+// partway through a command; the target's connection picks up the new token
+// from .flyrc.
+func reauthenticate() error {
+	if !isatty.IsTerminal(os.Stdin.Fd()) {
+		return fmt.Errorf("not authorized to access target '%s'; run 'fly -t %s login' and try again", Fly.Target, Fly.Target)
+	}
+
+	target, err := rc.SelectTarget(Fly.Target)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "\nnot authorized to access target '%s'; log in again to continue\n", Fly.Target)
+
+	login := &LoginCommand{Insecure: target.Insecure}
+
+	return login.Execute(nil)
+}
+
+// abortBuild aborts the build, logging in again if need be
+func abortBuild(client concourse.Client, buildID int) error {
+	err := client.AbortBuild(strconv.Itoa(buildID))
+	if err == concourse.ErrUnauthorized {
+		err = reauthenticate()
+		if err != nil {
+			return err
+		}
+
+		err = client.AbortBuild(strconv.Itoa(buildID))
+	}
+
+	return apierror.Wrap("aborting build", err)
+}
+
+// checkToken fails if the target's saved token has expired, judging by its
+// expiry if it's a JWT and otherwise by making a cheap authenticated request
+func checkToken(client concourse.Client, targetName string) error {
+	target, err := rc.SelectTarget(targetName)
+	if err != nil {
+		return err
+	}
+
+	if target.Token == nil || target.Token.Value == "" {
+		return nil
+	}
+
+	err = probeToken(client, target.Token)
+	if err == errTokenRejected {
+		return fmt.Errorf("token for target '%s' has expired, run fly login -t %s", targetName, targetName)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to check the token for target '%s' (use --skip-auth-check to skip this): %s", targetName, err)
+	}
+
+	return nil
+}
+
+// explainMissingEndpoint makes the client's error for a 404 say that the
+// target's ATC is too old to list the given things
+func explainMissingEndpoint(err error, things string) error {
+	if strings.Contains(err.Error(), "404 Not Found") {
+		return fmt.Errorf("the target's ATC does not support listing %s; it may need to be upgraded", things)
+	}
+
+	return err
+}
+
+// timestamps can be turned on for good by setting FLY_TIMESTAMPS=1
+func showTimestamps(flag bool) bool {
+	return flag || os.Getenv("FLY_TIMESTAMPS") == "1"
+}
+
+// useColor resolves --color, where auto only colors output to a terminal
+func useColor(when string, dst io.Writer) bool {
+	switch when {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+
+	file, ok := dst.(*os.File)
+	return ok && isatty.IsTerminal(file.Fd())
+}
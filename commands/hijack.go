@@ -15,11 +15,13 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/concourse/atc"
 	"github.com/concourse/fly/commands/internal/flaghelpers"
 	"github.com/concourse/fly/pty"
 	"github.com/concourse/fly/rc"
+	"github.com/concourse/fly/version"
 	"github.com/concourse/go-concourse/concourse"
 	"github.com/mgutz/ansi"
 	"github.com/tedsuo/rata"
@@ -160,7 +162,7 @@ func getContainerIDs(c *HijackCommand) []atc.Container {
 		checkName:    check,
 	}
 
-	connection, err := rc.TargetConnection(Fly.Target)
+	connection, err := targetConnection()
 	if err != nil {
 		log.Fatalln("failed to create client:", err)
 	}
@@ -179,6 +181,9 @@ func getContainerIDs(c *HijackCommand) []atc.Container {
 }
 
 func (command *HijackCommand) Execute(args []string) error {
+	// interactive sessions last as long as the user wants
+	rc.SetCommandTimeout(0)
+
 	target, err := rc.SelectTarget(Fly.Target)
 	if err != nil {
 		log.Fatalln(err)
@@ -224,11 +229,22 @@ func (command *HijackCommand) Execute(args []string) error {
 		id = containers[0].ID
 	}
 
-	path, args := remoteCommand(args)
+	os.Exit(hijackContainer(target, id, args))
+
+	return nil
+}
+
+// hijackContainer runs the command in the container, attached to the
+// terminal, and returns its exit status
+func hijackContainer(target rc.TargetProps, id string, argv []string) int {
+	path, args := remoteCommand(argv)
 	privileged := true
 
 	reqGenerator := rata.NewRequestGenerator(target.API, atc.Routes)
-	tlsConfig := &tls.Config{InsecureSkipVerify: target.Insecure}
+	tlsConfig, err := rc.TLSConfig(target)
+	if err != nil {
+		log.Fatalln(err)
+	}
 
 	var ttySpec *atc.HijackTTYSpec
 	rows, cols, err := pty.Getsize(os.Stdin)
@@ -252,14 +268,18 @@ func (command *HijackCommand) Execute(args []string) error {
 	}
 
 	hijackReq := constructRequest(reqGenerator, spec, id, target.Token)
-	hijackResult := performHijack(hijackReq, tlsConfig)
-	os.Exit(hijackResult)
+	hijackReq.Header.Set("User-Agent", version.UserAgent())
 
-	return nil
+	proxyURL, err := rc.ProxyURL(Fly.Proxy, hijackReq)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	return performHijack(hijackReq, tlsConfig, proxyURL)
 }
 
-func performHijack(hijackReq *http.Request, tlsConfig *tls.Config) int {
-	conn, err := dialEndpoint(hijackReq.URL, tlsConfig)
+func performHijack(hijackReq *http.Request, tlsConfig *tls.Config, proxyURL *url.URL) int {
+	conn, err := dialEndpoint(hijackReq.URL, tlsConfig, proxyURL)
 	if err != nil {
 		log.Fatalln("failed to dial hijack endpoint:", err)
 	}
@@ -290,7 +310,7 @@ func hijack(conn net.Conn, br *bufio.Reader) int {
 		in = os.Stdin
 	}
 
-	encoder := json.NewEncoder(conn)
+	encoder := &inputEncoder{enc: json.NewEncoder(conn)}
 	decoder := json.NewDecoder(br)
 
 	resized := pty.ResizeNotifier()
@@ -298,12 +318,16 @@ func hijack(conn net.Conn, br *bufio.Reader) int {
 	go func() {
 		for {
 			<-resized
-			// TODO json race
 			sendSize(encoder)
 		}
 	}()
 
-	go io.Copy(&stdinWriter{encoder}, in)
+	go func() {
+		io.Copy(&stdinWriter{encoder}, in)
+
+		// let the process see EOF, e.g. when input is piped in
+		encoder.Encode(atc.HijackInput{Closed: true})
+	}()
 
 	var exitStatus int
 	for {
@@ -328,7 +352,20 @@ func hijack(conn net.Conn, br *bufio.Reader) int {
 	return exitStatus
 }
 
-func sendSize(enc *json.Encoder) {
+// inputEncoder serializes input sent by the stdin and resize goroutines
+type inputEncoder struct {
+	enc *json.Encoder
+	l   sync.Mutex
+}
+
+func (e *inputEncoder) Encode(input atc.HijackInput) error {
+	e.l.Lock()
+	defer e.l.Unlock()
+
+	return e.enc.Encode(input)
+}
+
+func sendSize(enc *inputEncoder) {
 	rows, cols, err := pty.Getsize(os.Stdin)
 	if err == nil {
 		enc.Encode(atc.HijackInput{
@@ -343,7 +380,7 @@ func sendSize(enc *json.Encoder) {
 }
 
 type stdinWriter struct {
-	enc *json.Encoder
+	enc *inputEncoder
 }
 
 func (w *stdinWriter) Write(d []byte) (int, error) {
@@ -362,14 +399,87 @@ var canonicalPortMap = map[string]string{
 	"https": "443",
 }
 
-func dialEndpoint(url *url.URL, tlsConfig *tls.Config) (net.Conn, error) {
+func dialEndpoint(url *url.URL, tlsConfig *tls.Config, proxyURL *url.URL) (net.Conn, error) {
 	addr := canonicalAddr(url)
 
-	if url.Scheme == "https" {
-		return tls.Dial("tcp", addr, tlsConfig)
+	if proxyURL == nil {
+		if url.Scheme == "https" {
+			return tls.Dial("tcp", addr, tlsConfig)
+		}
+
+		return net.Dial("tcp", addr)
+	}
+
+	conn, err := dialProxy(proxyURL, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if url.Scheme != "https" {
+		return conn, nil
+	}
+
+	config := &tls.Config{}
+	if tlsConfig != nil {
+		config = tlsConfig.Clone()
+	}
+
+	if config.ServerName == "" {
+		config.ServerName, _, _ = net.SplitHostPort(addr)
+	}
+
+	tlsConn := tls.Client(conn, config)
+
+	err = tlsConn.Handshake()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return tlsConn, nil
+}
+
+// dialProxy opens a tunnel to the address through the proxy
+func dialProxy(proxyURL *url.URL, addr string) (net.Conn, error) {
+	conn, err := net.Dial("tcp", canonicalAddr(proxyURL))
+	if err != nil {
+		return nil, err
+	}
+
+	connectReq := &http.Request{
+		Method: "CONNECT",
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		connectReq.SetBasicAuth(proxyURL.User.Username(), password)
+		connectReq.Header.Set("Proxy-Authorization", connectReq.Header.Get("Authorization"))
+		connectReq.Header.Del("Authorization")
+	}
+
+	err = connectReq.Write(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), connectReq)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy failed to connect to %s: %s", addr, resp.Status)
 	}
 
-	return net.Dial("tcp", addr)
+	return conn, nil
 }
 
 func canonicalAddr(url *url.URL) string {
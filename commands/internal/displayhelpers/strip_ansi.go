@@ -0,0 +1,27 @@
+package displayhelpers
+
+import (
+	"io"
+	"regexp"
+)
+
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*[a-zA-Z]")
+
+type ansiStripper struct {
+	dst io.Writer
+}
+
+// StripANSI writes to dst without any ANSI escape codes, e.g. colors, for
+// output that isn't going to a terminal.
+func StripANSI(dst io.Writer) io.Writer {
+	return ansiStripper{dst: dst}
+}
+
+func (stripper ansiStripper) Write(p []byte) (int, error) {
+	_, err := stripper.dst.Write(ansiEscape.ReplaceAll(p, nil))
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
@@ -2,6 +2,7 @@ package executehelpers
 
 import (
 	"github.com/concourse/atc"
+	"github.com/concourse/fly/apierror"
 	"github.com/concourse/fly/commands/internal/deprecated"
 	"github.com/concourse/fly/rc"
 	"github.com/concourse/go-concourse/concourse"
@@ -11,6 +12,7 @@ import (
 func CreateBuild(
 	atcRequester *deprecated.AtcRequester,
 	client concourse.Client,
+	name string,
 	privileged bool,
 	inputs []Input,
 	outputs []Output,
@@ -18,35 +20,46 @@ func CreateBuild(
 	tags []string,
 	target string,
 ) (atc.Build, error) {
-	if err := config.Validate(); err != nil {
+	plan, err := BuildPlan(atcRequester, name, privileged, inputs, outputs, config, tags, target)
+	if err != nil {
 		return atc.Build{}, err
 	}
 
+	build, err := client.CreateBuild(plan)
+	return build, apierror.Wrap("creating build", err)
+}
+
+// BuildPlan constructs the plan for a one-off build. Inputs and outputs
+// without a pipe, i.e. those determined with DryRunClient, get a placeholder
+// URI.
+func BuildPlan(
+	atcRequester *deprecated.AtcRequester,
+	name string,
+	privileged bool,
+	inputs []Input,
+	outputs []Output,
+	config atc.TaskConfig,
+	tags []string,
+	target string,
+) (atc.Plan, error) {
+	if err := config.Validate(); err != nil {
+		return atc.Plan{}, err
+	}
+
 	targetProps, err := rc.SelectTarget(target)
 	if err != nil {
-		return atc.Build{}, err
+		return atc.Plan{}, err
 	}
 
 	buildInputs := atc.AggregatePlan{}
 	for i, input := range inputs {
 		var getPlan atc.GetPlan
 		if input.Path != "" {
-			readPipe, err := atcRequester.CreateRequest(
-				atc.ReadPipe,
-				rata.Params{"pipe_id": input.Pipe.ID},
-				nil,
-			)
+			source, err := pipeSource(atcRequester, atc.ReadPipe, input.Name, input.Pipe, targetProps)
 			if err != nil {
-				return atc.Build{}, err
+				return atc.Plan{}, err
 			}
 
-			source := atc.Source{
-				"uri": readPipe.URL.String(),
-			}
-
-			if targetProps.Token != nil {
-				source["authorization"] = targetProps.Token.Type + " " + targetProps.Token.Value
-			}
 			getPlan = atc.GetPlan{
 				Name:   input.Name,
 				Type:   "archive",
@@ -81,7 +94,7 @@ func CreateBuild(
 			ParentID: 0,
 		},
 		Task: &atc.TaskPlan{
-			Name:       "one-off",
+			Name:       name,
 			Privileged: privileged,
 			Config:     &config,
 		},
@@ -93,26 +106,15 @@ func CreateBuild(
 
 	buildOutputs := atc.AggregatePlan{}
 	for i, output := range outputs {
-		writePipe, err := atcRequester.CreateRequest(
-			atc.WritePipe,
-			rata.Params{"pipe_id": output.Pipe.ID},
-			nil,
-		)
+		source, err := pipeSource(atcRequester, atc.WritePipe, output.Name, output.Pipe, targetProps)
 		if err != nil {
-			return atc.Build{}, err
-		}
-		source := atc.Source{
-			"uri": writePipe.URL.String(),
+			return atc.Plan{}, err
 		}
 
 		params := atc.Params{
 			"directory": output.Name,
 		}
 
-		if targetProps.Token != nil {
-			source["authorization"] = targetProps.Token.Type + " " + targetProps.Token.Value
-		}
-
 		buildOutputs = append(buildOutputs, atc.Plan{
 			Location: &atc.Location{
 				ID:            taskPlan.Location.ID + 2 + uint(i),
@@ -128,33 +130,75 @@ func CreateBuild(
 		})
 	}
 
-	var plan atc.Plan
-	if len(buildOutputs) == 0 {
+	plan := taskPlan
+	if len(buildOutputs) != 0 {
 		plan = atc.Plan{
-			OnSuccess: &atc.OnSuccessPlan{
-				Step: atc.Plan{
-					Aggregate: &buildInputs,
+			Ensure: &atc.EnsurePlan{
+				Step: taskPlan,
+				Next: atc.Plan{
+					Aggregate: &buildOutputs,
 				},
-				Next: taskPlan,
 			},
 		}
-	} else {
+	}
+
+	// a task without inputs just runs
+	if len(buildInputs) != 0 {
 		plan = atc.Plan{
 			OnSuccess: &atc.OnSuccessPlan{
 				Step: atc.Plan{
 					Aggregate: &buildInputs,
 				},
-				Next: atc.Plan{
-					Ensure: &atc.EnsurePlan{
-						Step: taskPlan,
-						Next: atc.Plan{
-							Aggregate: &buildOutputs,
-						},
-					},
-				},
+				Next: plan,
 			},
 		}
 	}
 
-	return client.CreateBuild(plan)
+	return plan, nil
+}
+
+// createPipe creates a pipe for streaming an input or output, naming the
+// endpoint if the ATC refuses
+func createPipe(client concourse.Client, purpose string) (atc.Pipe, error) {
+	pipe, err := client.CreatePipe()
+	return pipe, apierror.Wrap("creating pipe for "+purpose+" (POST /api/v1/pipes)", err)
+}
+
+func pipeSource(atcRequester *deprecated.AtcRequester, route string, name string, pipe atc.Pipe, targetProps rc.TargetProps) (atc.Source, error) {
+	if pipe.ID == "" {
+		return atc.Source{"uri": "((pipe:" + name + "))"}, nil
+	}
+
+	request, err := atcRequester.CreateRequest(
+		route,
+		rata.Params{"pipe_id": pipe.ID},
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	source := atc.Source{
+		"uri": request.URL.String(),
+	}
+
+	if targetProps.Token != nil {
+		source["authorization"] = targetProps.Token.Type + " " + targetProps.Token.Value
+	}
+
+	return source, nil
+}
+
+type dryRunClient struct {
+	concourse.Client
+}
+
+// DryRunClient wraps the client such that no pipes are created; inputs and
+// outputs determined with it can only be used to construct a BuildPlan.
+func DryRunClient(client concourse.Client) concourse.Client {
+	return dryRunClient{client}
+}
+
+func (dryRunClient) CreatePipe() (atc.Pipe, error) {
+	return atc.Pipe{}, nil
 }
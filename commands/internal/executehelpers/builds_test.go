@@ -33,11 +33,11 @@ var _ = Describe("Builds", func() {
 	Context("when tags are provided", func() {
 		It("add the tags to the plan", func() {
 			tags := []string{"tag", "tag2"}
-			_, err := CreateBuild(requester, fakeClient, false, []Input{}, []Output{}, config, tags, "https://target.com")
+			_, err := CreateBuild(requester, fakeClient, "one-off", false, []Input{}, []Output{}, config, tags, "https://target.com")
 			Expect(err).ToNot(HaveOccurred())
 
 			plan := fakeClient.CreateBuildArgsForCall(0)
-			for index, tag := range plan.OnSuccess.Next.Task.Tags {
+			for index, tag := range plan.Task.Tags {
 				Expect(tag).To(Equal(tags[index]))
 			}
 		})
@@ -46,11 +46,46 @@ var _ = Describe("Builds", func() {
 	Context("when tags are not provided", func() {
 		It("should not add tags to the plan", func() {
 			tags := []string{}
-			_, err := CreateBuild(requester, fakeClient, false, []Input{}, []Output{}, config, tags, "https://target.com")
+			_, err := CreateBuild(requester, fakeClient, "one-off", false, []Input{}, []Output{}, config, tags, "https://target.com")
 			Expect(err).ToNot(HaveOccurred())
 
 			plan := fakeClient.CreateBuildArgsForCall(0)
-			Expect(plan.OnSuccess.Next.Task.Tags).To(BeNil())
+			Expect(plan.Task.Tags).To(BeNil())
+		})
+	})
+
+	Context("when there are no inputs", func() {
+		It("only runs the task", func() {
+			_, err := CreateBuild(requester, fakeClient, "one-off", false, []Input{}, []Output{}, config, nil, "https://target.com")
+			Expect(err).ToNot(HaveOccurred())
+
+			plan := fakeClient.CreateBuildArgsForCall(0)
+			Expect(plan.OnSuccess).To(BeNil())
+			Expect(plan.Task).NotTo(BeNil())
+			Expect(plan.Task.Name).To(Equal("one-off"))
+		})
+	})
+
+	Context("when there are inputs", func() {
+		It("gets them before running the task", func() {
+			inputs := []Input{
+				{
+					Name: "some-repo",
+					BuildInput: atc.BuildInput{
+						Name:   "some-repo",
+						Type:   "git",
+						Source: atc.Source{"uri": "https://example.com/some-repo.git"},
+					},
+				},
+			}
+
+			_, err := CreateBuild(requester, fakeClient, "one-off", false, inputs, []Output{}, config, nil, "https://target.com")
+			Expect(err).ToNot(HaveOccurred())
+
+			plan := fakeClient.CreateBuildArgsForCall(0)
+			Expect(*plan.OnSuccess.Step.Aggregate).To(HaveLen(1))
+			Expect((*plan.OnSuccess.Step.Aggregate)[0].Get.Name).To(Equal("some-repo"))
+			Expect(plan.OnSuccess.Next.Task.Name).To(Equal("one-off"))
 		})
 	})
 })
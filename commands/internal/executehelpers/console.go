@@ -0,0 +1,190 @@
+package executehelpers
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// how often progress is reported when it can't be redrawn in place
+const plainProgressInterval = 30 * time.Second
+
+// Console lets the progress of uploads and downloads share the terminal with
+// the build's output, which can start while inputs are still uploading.
+//
+// On a terminal, progress is a single status line that is cleared before
+// anything else is written through the console and redrawn once that output
+// has reached the end of a line. Elsewhere, e.g. in CI logs, progress is
+// reduced to an occasional plain line.
+type Console struct {
+	dst io.Writer
+	tty bool
+
+	lock     sync.Mutex
+	active   []*Progress
+	statuses map[*Progress]string
+	reported map[*Progress]time.Time
+	tick     int
+	drawn    bool
+	midLine  bool
+	interval time.Duration
+}
+
+// NewConsole writes progress to dst, which should be stderr; tty says whether
+// it is a terminal.
+func NewConsole(dst io.Writer, tty bool) *Console {
+	return &Console{
+		dst:      dst,
+		tty:      tty,
+		statuses: map[*Progress]string{},
+		reported: map[*Progress]time.Time{},
+		interval: plainProgressInterval,
+	}
+}
+
+// Writer returns a writer for output that shares the terminal with the
+// progress, e.g. stdout or stderr.
+func (console *Console) Writer(dst io.Writer) io.Writer {
+	return consoleWriter{console: console, dst: dst}
+}
+
+// NewProgress reports the bytes read through it via the console until it is
+// finished or stopped.
+func (console *Console) NewProgress(active string, done string) *Progress {
+	progress := &Progress{
+		active:  active,
+		done:    done,
+		console: console,
+		start:   time.Now(),
+		stop:    make(chan struct{}),
+	}
+
+	console.lock.Lock()
+	console.active = append(console.active, progress)
+	console.reported[progress] = progress.start
+	console.lock.Unlock()
+
+	progress.wg.Add(1)
+	go progress.report()
+
+	return progress
+}
+
+func (console *Console) update(progress *Progress, tick int, status string) {
+	console.lock.Lock()
+	defer console.lock.Unlock()
+
+	console.statuses[progress] = status
+	console.tick = tick
+
+	if console.midLine {
+		return
+	}
+
+	if console.tty {
+		console.draw()
+		return
+	}
+
+	if time.Since(console.reported[progress]) >= console.interval {
+		fmt.Fprintln(console.dst, status)
+		console.reported[progress] = time.Now()
+	}
+}
+
+// finish removes the progress, printing its summary on a line of its own
+func (console *Console) finish(progress *Progress, summary string) {
+	console.lock.Lock()
+	defer console.lock.Unlock()
+
+	console.remove(progress)
+	console.clear()
+
+	if console.midLine {
+		fmt.Fprintln(console.dst)
+		console.midLine = false
+	}
+
+	fmt.Fprintln(console.dst, summary)
+
+	console.draw()
+}
+
+func (console *Console) stop(progress *Progress) {
+	console.lock.Lock()
+	defer console.lock.Unlock()
+
+	console.remove(progress)
+	console.clear()
+
+	if !console.midLine {
+		console.draw()
+	}
+}
+
+func (console *Console) remove(progress *Progress) {
+	for i, p := range console.active {
+		if p == progress {
+			console.active = append(console.active[:i], console.active[i+1:]...)
+			break
+		}
+	}
+
+	delete(console.statuses, progress)
+	delete(console.reported, progress)
+}
+
+func (console *Console) draw() {
+	if !console.tty {
+		return
+	}
+
+	statuses := []string{}
+	for _, progress := range console.active {
+		if status, found := console.statuses[progress]; found {
+			statuses = append(statuses, status)
+		}
+	}
+
+	if len(statuses) == 0 {
+		return
+	}
+
+	fmt.Fprintf(console.dst, "\r\x1b[K%s %s", spinner[console.tick%len(spinner)], strings.Join(statuses, "; "))
+	console.drawn = true
+}
+
+func (console *Console) clear() {
+	if console.drawn {
+		fmt.Fprint(console.dst, "\r\x1b[K")
+		console.drawn = false
+	}
+}
+
+type consoleWriter struct {
+	console *Console
+	dst     io.Writer
+}
+
+func (writer consoleWriter) Write(p []byte) (int, error) {
+	console := writer.console
+
+	console.lock.Lock()
+	defer console.lock.Unlock()
+
+	console.clear()
+
+	n, err := writer.dst.Write(p)
+
+	if n > 0 {
+		console.midLine = p[n-1] != '\n'
+	}
+
+	if !console.midLine {
+		console.draw()
+	}
+
+	return n, err
+}
@@ -0,0 +1,90 @@
+package executehelpers_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	. "github.com/concourse/fly/commands/internal/executehelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Console", func() {
+	var out *gbytes.Buffer
+
+	BeforeEach(func() {
+		out = gbytes.NewBuffer()
+	})
+
+	Context("on a terminal", func() {
+		var console *Console
+
+		BeforeEach(func() {
+			console = NewConsole(out, true)
+		})
+
+		It("clears the status line before output and redraws it after", func() {
+			progress := console.NewProgress("uploading some-input", "uploaded some-input")
+			defer progress.Stop()
+
+			ioutil.ReadAll(progress.Reader(strings.NewReader("some-bits")))
+
+			Eventually(out).Should(gbytes.Say(`\r\x1b\[K. uploading some-input: 9 B`))
+
+			fmt.Fprint(console.Writer(out), "initializing\n")
+
+			Expect(out).To(gbytes.Say(`\r\x1b\[Kinitializing\n\r\x1b\[K. uploading some-input`))
+		})
+
+		It("does not draw the status line in the middle of a line of output", func() {
+			progress := console.NewProgress("uploading some-input", "uploaded some-input")
+			defer progress.Stop()
+
+			writer := console.Writer(out)
+
+			fmt.Fprint(writer, "half a ")
+			Consistently(out, "600ms").ShouldNot(gbytes.Say("uploading"))
+
+			fmt.Fprint(writer, "line\n")
+			Expect(out).To(gbytes.Say(`half a line\n\r\x1b\[K. uploading some-input`))
+		})
+
+		It("shows every transfer in progress on the one line", func() {
+			first := console.NewProgress("uploading first-input", "uploaded first-input")
+			defer first.Stop()
+
+			second := console.NewProgress("uploading second-input", "uploaded second-input")
+			defer second.Stop()
+
+			Eventually(out).Should(gbytes.Say(`uploading first-input: 0 B \(0 B/s\); uploading second-input`))
+		})
+
+		It("prints the summary of a finished transfer on a line of its own", func() {
+			progress := console.NewProgress("uploading some-input", "uploaded some-input")
+
+			fmt.Fprint(console.Writer(out), "half a ")
+
+			progress.Finish()
+
+			Expect(out).To(gbytes.Say(`half a \nuploaded some-input \(0 B in \d+\.\ds\)\n$`))
+		})
+	})
+
+	Context("elsewhere", func() {
+		It("reports progress without terminal control, only on finishing", func() {
+			console := NewConsole(out, false)
+
+			progress := console.NewProgress("uploading some-input", "uploaded some-input")
+
+			fmt.Fprint(console.Writer(out), "initializing\n")
+			Consistently(out, "600ms").ShouldNot(gbytes.Say("uploading"))
+
+			progress.Finish()
+
+			Expect(string(out.Contents())).To(MatchRegexp(`^initializing\nuploaded some-input \(0 B in \d+\.\ds\)\n$`))
+		})
+	})
+})
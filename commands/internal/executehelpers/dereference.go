@@ -0,0 +1,144 @@
+package executehelpers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// walk is filepath.Walk, except that when following symlinks it visits what
+// they point to under the link's own path, descending into linked
+// directories as if they were there
+func walk(root string, follow bool, walkFn filepath.WalkFunc) error {
+	if !follow {
+		return filepath.Walk(root, walkFn)
+	}
+
+	return walkFollowing(root, map[string]string{}, walkFn)
+}
+
+// walkFollowing keeps the real paths of the directories it's within, so that
+// a link back to one of them is an error rather than an endless walk
+func walkFollowing(path string, within map[string]string, walkFn filepath.WalkFunc) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return walkFn(path, nil, err)
+	}
+
+	if !info.IsDir() {
+		return walkFn(path, info, nil)
+	}
+
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return walkFn(path, info, err)
+	}
+
+	if outer, found := within[real]; found {
+		return symlinkCycleError{path: path, dir: outer}
+	}
+
+	err = walkFn(path, info, nil)
+	if err == filepath.SkipDir {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.Open(path)
+	if err != nil {
+		return walkFn(path, info, err)
+	}
+
+	names, err := dir.Readdirnames(-1)
+	dir.Close()
+	if err != nil {
+		return walkFn(path, info, err)
+	}
+
+	sort.Strings(names)
+
+	within[real] = path
+	defer delete(within, real)
+
+	for _, name := range names {
+		err := walkFollowing(filepath.Join(path, name), within, walkFn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type symlinkCycleError struct {
+	path string
+	dir  string
+}
+
+func (err symlinkCycleError) Error() string {
+	return fmt.Sprintf("cannot follow symlinks: '%s' leads back to '%s', which it is within", err.path, err.dir)
+}
+
+// checkFollowable walks everything that would be archived with symlinks
+// followed, so that every broken link is reported at once rather than the
+// upload failing partway through on the first
+func checkFollowable(workDir string, paths []string, includes []Include) error {
+	broken := []string{}
+
+	collect := func(path string, name string, err error) error {
+		if !os.IsNotExist(err) {
+			return err
+		}
+
+		target, linkErr := os.Readlink(path)
+		if linkErr != nil {
+			return err
+		}
+
+		broken = append(broken, fmt.Sprintf("%s -> %s", filepath.ToSlash(name), target))
+
+		return nil
+	}
+
+	for _, p := range paths {
+		err := walk(filepath.Join(workDir, p), true, func(path string, info os.FileInfo, err error) error {
+			if err == nil {
+				return nil
+			}
+
+			relative, relErr := filepath.Rel(workDir, path)
+			if relErr != nil {
+				return relErr
+			}
+
+			return collect(path, relative, err)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, include := range includes {
+		err := walkInclude(include, true, func(path string, name string, info os.FileInfo, err error) error {
+			if err == nil {
+				return nil
+			}
+
+			return collect(path, name, err)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(broken) > 0 {
+		return fmt.Errorf("cannot follow broken symlinks:\n  %s", strings.Join(broken, "\n  "))
+	}
+
+	return nil
+}
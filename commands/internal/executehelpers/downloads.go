@@ -1,16 +1,25 @@
 package executehelpers
 
 import (
+	"compress/gzip"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
+	"sort"
 
 	"github.com/concourse/atc"
+	"github.com/concourse/fly/apierror"
 	"github.com/concourse/fly/commands/internal/deprecated"
+	"github.com/hashicorp/go-multierror"
 	"github.com/tedsuo/rata"
 )
 
-func Download(output Output, atcRequester *deprecated.AtcRequester) {
+// Download fetches the output to its path, warning about anything unusual in
+// it on log.
+func Download(output Output, allowExternalSymlinks bool, console *Console, atcRequester *deprecated.AtcRequester, log io.Writer) error {
 	path := output.Path
 	pipe := output.Pipe
 
@@ -20,28 +29,192 @@ func Download(output Output, atcRequester *deprecated.AtcRequester) {
 		nil,
 	)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	response, err := atcRequester.HttpClient.Do(downloadBits)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "download request failed:", err)
+		return fmt.Errorf("download request failed: %s", err)
 	}
 
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		fmt.Fprintln(os.Stderr, badResponseError("downloading bits", response))
-		panic("unexpected-response-code")
+		return apierror.FromResponse("downloading bits", response)
 	}
 
-	err = os.MkdirAll(path, 0755)
+	var body io.Reader = response.Body
+
+	var progress *Progress
+	if console != nil {
+		progress = console.NewProgress("downloading "+output.Name, "downloaded "+output.Name)
+		defer progress.Stop()
+
+		body = progress.Reader(response.Body)
+	}
+
+	switch {
+	case path == "-":
+		err = copyVerified(os.Stdout, body)
+	case output.Archive:
+		err = writeArchive(path, body)
+	default:
+		err = extractOutput(path, body, allowExternalSymlinks, log)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if progress != nil {
+		progress.Finish()
+	}
+
+	return nil
+}
+
+// DownloadAll runs download for each of the outputs at once, returning the
+// names of those that succeeded along with the errors of those that didn't.
+func DownloadAll(outputs []Output, download func(Output) error) ([]string, error) {
+	type result struct {
+		name string
+		err  error
+	}
+
+	results := make(chan result, len(outputs))
+	for _, output := range outputs {
+		go func(output Output) {
+			results <- result{name: output.Name, err: download(output)}
+		}(output)
+	}
+
+	succeeded := []string{}
+
+	var downloadErrors error
+	for range outputs {
+		result := <-results
+		if result.err != nil {
+			downloadErrors = multierror.Append(downloadErrors, fmt.Errorf("%s: %s", result.name, result.err))
+		} else {
+			succeeded = append(succeeded, result.name)
+		}
+	}
+
+	sort.Strings(succeeded)
+
+	return succeeded, downloadErrors
+}
+
+// PartialMarker is left in an output's directory until the output has been
+// extracted in full, so that whatever uses the directory can tell when it is
+// incomplete
+const PartialMarker = ".fly-partial"
+
+func extractOutput(path string, src io.Reader, allowExternalSymlinks bool, log io.Writer) error {
+	err := os.MkdirAll(path, 0755)
+	if err != nil {
+		return err
+	}
+
+	marker := filepath.Join(path, PartialMarker)
+
+	err = ioutil.WriteFile(marker, []byte("this output was not downloaded in full\n"), 0644)
+	if err != nil {
+		return err
+	}
+
+	err = tarStreamTo(path, src, allowExternalSymlinks, log)
+	if err != nil {
+		return fmt.Errorf("%s (the incomplete output in %s is marked with %s)", err, path, PartialMarker)
+	}
+
+	return os.Remove(marker)
+}
+
+// copyVerified copies a gzipped stream without extracting it, checking that
+// it is complete along the way; dst gets everything read even if it isn't
+func copyVerified(dst io.Writer, src io.Reader) error {
+	verifier := newGzipVerifier()
+
+	_, err := io.Copy(dst, io.TeeReader(src, verifier))
+
+	verifyErr := verifier.Close()
 	if err != nil {
-		panic(err)
+		return err
+	}
+
+	return verifyErr
+}
+
+// gzipVerifier decompresses whatever is written to it, reporting whether it
+// was complete when closed
+type gzipVerifier struct {
+	pipe *io.PipeWriter
+	done chan error
+}
+
+func newGzipVerifier() *gzipVerifier {
+	reader, writer := io.Pipe()
+
+	verifier := &gzipVerifier{
+		pipe: writer,
+		done: make(chan error, 1),
+	}
+
+	go func() {
+		gr, err := gzip.NewReader(reader)
+		if err == nil {
+			_, err = io.Copy(ioutil.Discard, gr)
+		}
+
+		// keep accepting writes, so the copy isn't held up
+		io.Copy(ioutil.Discard, reader)
+
+		verifier.done <- err
+	}()
+
+	return verifier
+}
+
+func (verifier *gzipVerifier) Write(p []byte) (int, error) {
+	return verifier.pipe.Write(p)
+}
+
+func (verifier *gzipVerifier) Close() error {
+	verifier.pipe.Close()
+	return <-verifier.done
+}
+
+// writeArchive saves the stream to a temporary file next to path, renaming
+// it into place only once it's complete so that an interrupted download
+// doesn't leave a truncated archive behind
+func writeArchive(path string, src io.Reader) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+
+	err = tmpFile.Chmod(0644)
+	if err == nil {
+		err = copyVerified(tmpFile, src)
 	}
 
-	err = tarStreamTo(path, response.Body)
 	if err != nil {
-		panic(err)
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return err
 	}
+
+	err = tmpFile.Close()
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), path)
 }
@@ -0,0 +1,31 @@
+package executehelpers_test
+
+import (
+	"errors"
+
+	. "github.com/concourse/fly/commands/internal/executehelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DownloadAll", func() {
+	It("downloads every output, reporting which succeeded and which failed", func() {
+		outputs := []Output{
+			{Name: "c", Path: "c"},
+			{Name: "a", Path: "a"},
+			{Name: "b", Path: "b"},
+		}
+
+		succeeded, err := DownloadAll(outputs, func(output Output) error {
+			if output.Name == "b" {
+				return errors.New("truncated")
+			}
+
+			return nil
+		})
+		Expect(succeeded).To(Equal([]string{"a", "c"}))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("b: truncated"))
+	})
+})
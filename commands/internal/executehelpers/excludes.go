@@ -0,0 +1,165 @@
+package executehelpers
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// MatchesExclude reports whether the slash-separated path, relative to the
+// input root, is matched by any of the given patterns. Patterns follow
+// path.Match, with the addition of "**" matching any number of directories,
+// except at the end of a pattern, where as in .gitignore it matches only what's
+// beneath the directory and not the directory itself.
+func MatchesExclude(patterns []string, relativePath string) (bool, error) {
+	relativePath = filepath.ToSlash(relativePath)
+
+	for _, pattern := range patterns {
+		matched, err := matchSegments(
+			strings.Split(strings.Trim(filepath.ToSlash(pattern), "/"), "/"),
+			strings.Split(relativePath, "/"),
+		)
+		if err != nil {
+			return false, err
+		}
+
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func matchSegments(pattern []string, segments []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			if len(pattern) == 1 {
+				return len(segments) > 0, nil
+			}
+
+			for i := 0; i <= len(segments); i++ {
+				matched, err := matchSegments(pattern[1:], segments[i:])
+				if err != nil || matched {
+					return matched, err
+				}
+			}
+
+			return false, nil
+		}
+
+		if len(segments) == 0 {
+			return false, nil
+		}
+
+		matched, err := path.Match(pattern[0], segments[0])
+		if err != nil || !matched {
+			return false, err
+		}
+
+		pattern = pattern[1:]
+		segments = segments[1:]
+	}
+
+	return len(segments) == 0, nil
+}
+
+func excludedOrWithinExcluded(patterns []string, relativePath string) (bool, error) {
+	segments := strings.Split(filepath.ToSlash(relativePath), "/")
+
+	for i := range segments {
+		matched, err := MatchesExclude(patterns, strings.Join(segments[:i+1], "/"))
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+
+	return false, nil
+}
+
+func filterExcluded(files []string, patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		return files, nil
+	}
+
+	filtered := []string{}
+	for _, file := range files {
+		excluded, err := excludedOrWithinExcluded(patterns, file)
+		if err != nil {
+			return nil, err
+		}
+
+		if !excluded {
+			filtered = append(filtered, file)
+		}
+	}
+
+	return filtered, nil
+}
+
+// WalkFiles lists the files beneath dir that should be uploaded, skipping
+// excluded (and, if requested, .gitignored) directories without walking them.
+func WalkFiles(dir string, patterns []string, respectGitignore bool) ([]string, error) {
+	files := []string{}
+
+	ignore := &gitignore{}
+	if respectGitignore {
+		err := ignore.load(dir, ".")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		if relative == "." {
+			return nil
+		}
+
+		excluded, err := MatchesExclude(patterns, relative)
+		if err != nil {
+			return err
+		}
+
+		if !excluded && respectGitignore {
+			excluded, err = ignore.ignored(relative, info.IsDir())
+			if err != nil {
+				return err
+			}
+		}
+
+		if excluded {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if info.IsDir() {
+			if respectGitignore {
+				return ignore.load(dir, relative)
+			}
+
+			return nil
+		}
+
+		files = append(files, relative)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
@@ -0,0 +1,43 @@
+package executehelpers_test
+
+import (
+	. "github.com/concourse/fly/commands/internal/executehelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MatchesExclude", func() {
+	It("matches paths relative to the input root", func() {
+		Expect(MatchesExclude([]string{"node_modules"}, "node_modules")).To(BeTrue())
+		Expect(MatchesExclude([]string{"node_modules"}, "src/node_modules")).To(BeFalse())
+	})
+
+	It("matches single segments with globs", func() {
+		Expect(MatchesExclude([]string{"*.log"}, "build.log")).To(BeTrue())
+		Expect(MatchesExclude([]string{"*.log"}, "logs/build.log")).To(BeFalse())
+		Expect(MatchesExclude([]string{"logs/*.log"}, "logs/build.log")).To(BeTrue())
+	})
+
+	It("matches any number of directories with **", func() {
+		Expect(MatchesExclude([]string{"vendor/**"}, "vendor/a/b/c.go")).To(BeTrue())
+		Expect(MatchesExclude([]string{"**/node_modules"}, "node_modules")).To(BeTrue())
+		Expect(MatchesExclude([]string{"**/node_modules"}, "a/b/node_modules")).To(BeTrue())
+		Expect(MatchesExclude([]string{"**/*.o"}, "a/b/c.go")).To(BeFalse())
+	})
+
+	It("matches only what's beneath a directory with a trailing **, as .gitignore does", func() {
+		Expect(MatchesExclude([]string{"vendor/**"}, "vendor")).To(BeFalse())
+		Expect(MatchesExclude([]string{"vendor/**"}, "vendor/a")).To(BeTrue())
+		Expect(MatchesExclude([]string{"a/**/b"}, "a/b")).To(BeTrue())
+	})
+
+	It("ignores leading and trailing slashes in patterns", func() {
+		Expect(MatchesExclude([]string{"/tmp/"}, "tmp")).To(BeTrue())
+	})
+
+	It("returns an error for malformed patterns", func() {
+		_, err := MatchesExclude([]string{"[oops"}, "oops")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,3 @@
+package executehelpers
+
+var NativeTarGZStreamFrom = nativeTarGZStreamFrom
@@ -0,0 +1,309 @@
+package executehelpers
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Extract unpacks a gzipped tarball into dir, preserving modes, mtimes,
+// symlinks, and hard links. Entries with absolute names, or that would be written outside of
+// dir, either directly or through a previously extracted symlink, are
+// rejected. So are symlinks pointing outside of dir, unless
+// allowExternalSymlinks is given, in which case they are extracted with a
+// warning written to log. The stream is read to its end, so that a truncated
+// one fails gzip's checks even if the tarball happens to end at an entry's
+// boundary.
+func Extract(dir string, stream io.Reader, allowExternalSymlinks bool, log io.Writer) error {
+	gr, err := gzip.NewReader(stream)
+	if err != nil {
+		return err
+	}
+
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(gr)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			_, err := io.Copy(ioutil.Discard, gr)
+			return err
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// e.g. git archive's, which hold nothing to extract
+		if hdr.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+
+		err = extractEntry(root, hdr, tr, allowExternalSymlinks, log)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func extractEntry(root string, hdr *tar.Header, src io.Reader, allowExternalSymlinks bool, log io.Writer) error {
+	if isAbs(hdr.Name) {
+		return fmt.Errorf("refusing to extract '%s', which has an absolute path", hdr.Name)
+	}
+
+	path := filepath.Join(root, filepath.FromSlash(hdr.Name))
+	if !within(root, path) {
+		return fmt.Errorf("refusing to extract '%s' outside of the output directory", hdr.Name)
+	}
+
+	if path == root {
+		return nil
+	}
+
+	err := checkParents(root, path, hdr.Name)
+	if err != nil {
+		return err
+	}
+
+	mode := hdr.FileInfo().Mode()
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		err = os.MkdirAll(path, 0755)
+		if err != nil {
+			return err
+		}
+
+		err = os.Chmod(path, mode.Perm())
+
+	case tar.TypeReg, tar.TypeRegA:
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return err
+		}
+
+		err = writeFile(path, mode.Perm(), src)
+
+	case tar.TypeSymlink:
+		if !linksWithin(root, path, hdr.Linkname) {
+			if !allowExternalSymlinks {
+				return fmt.Errorf("refusing to extract '%s', a symlink to '%s' outside of the output directory (pass --allow-external-symlinks to extract it anyway)", hdr.Name, hdr.Linkname)
+			}
+
+			fmt.Fprintf(log, "warning: '%s' is a symlink to '%s', outside of the output directory\n", hdr.Name, hdr.Linkname)
+		}
+
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return err
+		}
+
+		os.Remove(path)
+
+		return os.Symlink(hdr.Linkname, path)
+
+	case tar.TypeLink:
+		if isAbs(hdr.Linkname) {
+			return fmt.Errorf("refusing to extract '%s', a hard link to '%s', which has an absolute path", hdr.Name, hdr.Linkname)
+		}
+
+		target := filepath.Join(root, filepath.FromSlash(hdr.Linkname))
+		if !within(root, target) {
+			return fmt.Errorf("refusing to extract '%s', a hard link to '%s' outside of the output directory", hdr.Name, hdr.Linkname)
+		}
+
+		err = checkParents(root, target, hdr.Linkname)
+		if err != nil {
+			return err
+		}
+
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return err
+		}
+
+		os.Remove(path)
+
+		// shares the target's mode and mtime, already set
+		return os.Link(target, path)
+
+	default:
+		// devices, fifos, etc. have no business in an output
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return os.Chtimes(path, hdr.ModTime, hdr.ModTime)
+}
+
+func writeFile(path string, mode os.FileMode, src io.Reader) error {
+	// don't write through a symlink that already exists at the path
+	os.Remove(path)
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(file, src)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	// the mode passed to OpenFile is subject to the umask
+	return os.Chmod(path, mode)
+}
+
+// checkParents ensures none of the path's parent directories are symlinks
+// resolving outside of root. Parents that don't exist yet will be created
+// beneath the nearest one that does, so that's the one checked.
+func checkParents(root string, path string, name string) error {
+	parent := filepath.Dir(path)
+
+	for parent != root {
+		_, err := os.Lstat(parent)
+		if err == nil {
+			break
+		}
+
+		if !os.IsNotExist(err) {
+			return err
+		}
+
+		parent = filepath.Dir(parent)
+	}
+
+	resolved, err := filepath.EvalSymlinks(parent)
+	if err != nil {
+		return err
+	}
+
+	if !within(root, resolved) {
+		return fmt.Errorf("refusing to extract '%s' through a symlink leading outside of the output directory", name)
+	}
+
+	return nil
+}
+
+// linksWithin determines whether a symlink at path to target stays within
+// root; an absolute target is taken to be outside, as it was made relative to
+// the filesystem of the container the output came from
+func linksWithin(root string, path string, target string) bool {
+	if isAbs(target) {
+		return false
+	}
+
+	// joining would clean away the ..s lexically, which is only right if
+	// nothing before them is a symlink
+	resolved, err := resolvePath(filepath.Dir(path)+string(filepath.Separator)+filepath.FromSlash(target), 0)
+	if err != nil {
+		return false
+	}
+
+	return within(root, resolved)
+}
+
+// symlinks followed before giving up, as the OS does
+const maxLinksFollowed = 40
+
+// resolvePath resolves the absolute path one component at a time, following
+// any symlinks among those that exist; whatever doesn't exist yet is taken
+// as it is
+func resolvePath(path string, followed int) (string, error) {
+	volume := filepath.VolumeName(path)
+	resolved := volume + string(filepath.Separator)
+
+	for _, component := range strings.Split(path[len(volume):], string(filepath.Separator)) {
+		switch component {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, component)
+
+		info, err := os.Lstat(next)
+		if os.IsNotExist(err) {
+			resolved = next
+			continue
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		if info.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		linked, err := filepath.EvalSymlinks(next)
+		if err == nil {
+			resolved = linked
+			continue
+		}
+
+		// a link to something not yet extracted is followed by hand
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		followed++
+		if followed > maxLinksFollowed {
+			return "", fmt.Errorf("too many levels of symlinks resolving '%s'", path)
+		}
+
+		target, err := os.Readlink(next)
+		if err != nil {
+			return "", err
+		}
+
+		target = filepath.FromSlash(target)
+
+		if !isAbs(target) {
+			target = resolved + string(filepath.Separator) + target
+		}
+
+		resolved, err = resolvePath(target, followed)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return resolved, nil
+}
+
+// isAbs checks for paths that are absolute on either side, as archives come
+// from Linux containers whichever platform fly is running on
+func isAbs(name string) bool {
+	return strings.HasPrefix(name, "/") || filepath.IsAbs(filepath.FromSlash(name))
+}
+
+func within(root string, path string) bool {
+	return path == root || strings.HasPrefix(path, root+string(filepath.Separator))
+}
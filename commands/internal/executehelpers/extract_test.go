@@ -0,0 +1,233 @@
+package executehelpers_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/concourse/fly/commands/internal/executehelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Extract", func() {
+	var dir string
+	var modTime time.Time
+
+	tgz := func(entries ...tar.Header) *bytes.Buffer {
+		buf := new(bytes.Buffer)
+
+		gw := gzip.NewWriter(buf)
+		tw := tar.NewWriter(gw)
+
+		for _, hdr := range entries {
+			contents := []byte{}
+			if hdr.Typeflag == tar.TypeReg {
+				contents = []byte("contents of " + hdr.Name)
+				hdr.Size = int64(len(contents))
+			}
+
+			err := tw.WriteHeader(&hdr)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = tw.Write(contents)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(tw.Close()).To(Succeed())
+		Expect(gw.Close()).To(Succeed())
+
+		return buf
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "fly-extract")
+		Expect(err).NotTo(HaveOccurred())
+
+		modTime = time.Now().Add(-time.Hour).Truncate(time.Second)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("preserves modes, mtimes, directories, and symlinks", func() {
+		err := Extract(dir, tgz(
+			tar.Header{Name: "bin/", Typeflag: tar.TypeDir, Mode: 0750, ModTime: modTime},
+			tar.Header{Name: "bin/app", Typeflag: tar.TypeReg, Mode: 0755, ModTime: modTime},
+			tar.Header{Name: "app", Typeflag: tar.TypeSymlink, Linkname: "bin/app", ModTime: modTime},
+		), false, ioutil.Discard)
+		Expect(err).NotTo(HaveOccurred())
+
+		info, err := os.Stat(filepath.Join(dir, "bin"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.IsDir()).To(BeTrue())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0750)))
+
+		info, err = os.Stat(filepath.Join(dir, "bin", "app"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0755)))
+		Expect(info.ModTime().Unix()).To(Equal(modTime.Unix()))
+
+		link, err := os.Readlink(filepath.Join(dir, "app"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(link).To(Equal("bin/app"))
+	})
+
+	It("cleans names that stay within the directory", func() {
+		err := Extract(dir, tgz(
+			tar.Header{Name: "a/../b/./file", Typeflag: tar.TypeReg, Mode: 0644},
+			tar.Header{Name: "b/link", Typeflag: tar.TypeSymlink, Linkname: "../b/file"},
+		), false, ioutil.Discard)
+		Expect(err).NotTo(HaveOccurred())
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, "b", "link"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("contents of a/../b/./file"))
+	})
+
+	It("accepts long names written as PAX or GNU entries", func() {
+		longName := strings.Repeat("nested/", 40) + "file"
+
+		err := Extract(dir, tgz(
+			tar.Header{Name: longName, Typeflag: tar.TypeReg, Mode: 0644, Format: tar.FormatPAX},
+			tar.Header{Name: longName + "-gnu", Typeflag: tar.TypeReg, Mode: 0644, Format: tar.FormatGNU},
+			tar.Header{Name: "caf\xe9", Typeflag: tar.TypeReg, Mode: 0644, Format: tar.FormatGNU},
+		), false, ioutil.Discard)
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, name := range []string{longName, longName + "-gnu", "caf\xe9"} {
+			data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal("contents of " + name))
+		}
+	})
+
+	It("skips PAX global headers", func() {
+		err := Extract(dir, tgz(
+			tar.Header{Name: "pax_global_header", Typeflag: tar.TypeXGlobalHeader, PAXRecords: map[string]string{"comment": "some-commit"}},
+			tar.Header{Name: "file", Typeflag: tar.TypeReg, Mode: 0644},
+		), false, ioutil.Discard)
+		Expect(err).NotTo(HaveOccurred())
+
+		files, err := ioutil.ReadDir(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+		Expect(files[0].Name()).To(Equal("file"))
+	})
+
+	It("rejects entries outside of the directory", func() {
+		err := Extract(dir, tgz(
+			tar.Header{Name: "../../etc/passwd", Typeflag: tar.TypeReg, Mode: 0644},
+		), false, ioutil.Discard)
+		Expect(err).To(MatchError("refusing to extract '../../etc/passwd' outside of the output directory"))
+
+		err = Extract(dir, tgz(
+			tar.Header{Name: "a/../../escape", Typeflag: tar.TypeReg, Mode: 0644},
+		), false, ioutil.Discard)
+		Expect(err).To(MatchError("refusing to extract 'a/../../escape' outside of the output directory"))
+	})
+
+	It("rejects hard links to files outside of the directory", func() {
+		err := Extract(dir, tgz(
+			tar.Header{Name: "passwd", Typeflag: tar.TypeLink, Linkname: "../../etc/passwd"},
+		), false, ioutil.Discard)
+		Expect(err).To(MatchError("refusing to extract 'passwd', a hard link to '../../etc/passwd' outside of the output directory"))
+
+		err = Extract(dir, tgz(
+			tar.Header{Name: "passwd", Typeflag: tar.TypeLink, Linkname: "/etc/passwd"},
+		), false, ioutil.Discard)
+		Expect(err).To(MatchError("refusing to extract 'passwd', a hard link to '/etc/passwd', which has an absolute path"))
+	})
+
+	It("rejects entries with absolute names", func() {
+		err := Extract(dir, tgz(
+			tar.Header{Name: "/home/user/.ssh/authorized_keys", Typeflag: tar.TypeReg, Mode: 0644},
+		), false, ioutil.Discard)
+		Expect(err).To(MatchError("refusing to extract '/home/user/.ssh/authorized_keys', which has an absolute path"))
+
+		_, err = os.Stat(filepath.Join(dir, "home"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	Context("with symlinks pointing outside of the directory", func() {
+		It("rejects absolute ones", func() {
+			err := Extract(dir, tgz(
+				tar.Header{Name: "passwd", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+			), false, ioutil.Discard)
+			Expect(err).To(MatchError("refusing to extract 'passwd', a symlink to '/etc/passwd' outside of the output directory (pass --allow-external-symlinks to extract it anyway)"))
+
+			_, err = os.Lstat(filepath.Join(dir, "passwd"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("rejects relative ones", func() {
+			err := Extract(dir, tgz(
+				tar.Header{Name: "sub/ssh", Typeflag: tar.TypeSymlink, Linkname: "../../.ssh"},
+			), false, ioutil.Discard)
+			Expect(err).To(MatchError("refusing to extract 'sub/ssh', a symlink to '../../.ssh' outside of the output directory (pass --allow-external-symlinks to extract it anyway)"))
+		})
+
+		It("rejects ones that only look like they stay within by going through other symlinks", func() {
+			err := Extract(dir, tgz(
+				tar.Header{Name: "a", Typeflag: tar.TypeSymlink, Linkname: "."},
+				tar.Header{Name: "b", Typeflag: tar.TypeSymlink, Linkname: "a/.."},
+				tar.Header{Name: "b/escaped/file", Typeflag: tar.TypeReg, Mode: 0644},
+			), false, ioutil.Discard)
+			Expect(err).To(MatchError("refusing to extract 'b', a symlink to 'a/..' outside of the output directory (pass --allow-external-symlinks to extract it anyway)"))
+
+			_, err = os.Stat(filepath.Join(filepath.Dir(dir), "escaped"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("extracts them with a warning when allowed to", func() {
+			log := new(bytes.Buffer)
+
+			err := Extract(dir, tgz(
+				tar.Header{Name: "passwd", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+			), true, log)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(log.String()).To(Equal("warning: 'passwd' is a symlink to '/etc/passwd', outside of the output directory\n"))
+
+			link, err := os.Readlink(filepath.Join(dir, "passwd"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(link).To(Equal("/etc/passwd"))
+		})
+	})
+
+	It("rejects entries written through a symlink leading outside of the directory, even when such symlinks are allowed", func() {
+		outside, err := ioutil.TempDir("", "fly-extract-outside")
+		Expect(err).NotTo(HaveOccurred())
+
+		defer os.RemoveAll(outside)
+
+		err = Extract(dir, tgz(
+			tar.Header{Name: "escape", Typeflag: tar.TypeSymlink, Linkname: outside},
+			tar.Header{Name: "escape/file", Typeflag: tar.TypeReg, Mode: 0644},
+		), true, ioutil.Discard)
+		Expect(err).To(MatchError("refusing to extract 'escape/file' through a symlink leading outside of the output directory"))
+
+		_, err = os.Stat(filepath.Join(outside, "file"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("rejects entries written through a chain of symlinks leading outside of the directory into directories that don't exist yet", func() {
+		err := Extract(dir, tgz(
+			tar.Header{Name: "a", Typeflag: tar.TypeSymlink, Linkname: "."},
+			tar.Header{Name: "b", Typeflag: tar.TypeSymlink, Linkname: "a/.."},
+			tar.Header{Name: "b/escaped/file", Typeflag: tar.TypeReg, Mode: 0644},
+		), true, ioutil.Discard)
+		Expect(err).To(MatchError("refusing to extract 'b/escaped/file' through a symlink leading outside of the output directory"))
+
+		_, err = os.Stat(filepath.Join(filepath.Dir(dir), "escaped"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+})
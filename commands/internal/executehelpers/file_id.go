@@ -0,0 +1,19 @@
+// +build !windows
+
+package executehelpers
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileIDOf identifies a file with more than one link to it, so that later
+// links can be archived as links rather than as copies
+func fileIDOf(info os.FileInfo) (fileID, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || stat.Nlink < 2 {
+		return fileID{}, false
+	}
+
+	return fileID{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, true
+}
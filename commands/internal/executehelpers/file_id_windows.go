@@ -0,0 +1,9 @@
+package executehelpers
+
+import "os"
+
+// fileIDOf can't tell hard links apart on Windows, so each is archived as a
+// copy of the file
+func fileIDOf(info os.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
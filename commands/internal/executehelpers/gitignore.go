@@ -0,0 +1,114 @@
+package executehelpers
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type gitignoreRule struct {
+	base    string
+	pattern []string
+	negate  bool
+	dirOnly bool
+}
+
+type gitignore struct {
+	rules []gitignoreRule
+}
+
+func (ignore *gitignore) load(root string, dir string) error {
+	file, err := os.Open(filepath.Join(root, dir, ".gitignore"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	base := ""
+	if dir != "." {
+		base = filepath.ToSlash(dir)
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		rule, ok := parseGitignoreLine(base, scanner.Text())
+		if ok {
+			ignore.rules = append(ignore.rules, rule)
+		}
+	}
+
+	return scanner.Err()
+}
+
+func parseGitignoreLine(base string, line string) (gitignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return gitignoreRule{}, false
+	}
+
+	rule := gitignoreRule{base: base}
+
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	if line == "" {
+		return gitignoreRule{}, false
+	}
+
+	if strings.Contains(line, "/") {
+		// patterns containing a slash are relative to the .gitignore's directory
+		rule.pattern = strings.Split(strings.TrimLeft(line, "/"), "/")
+	} else {
+		rule.pattern = []string{"**", line}
+	}
+
+	return rule, true
+}
+
+// ignored follows git's semantics: the last matching rule wins, so negated
+// rules can re-include paths excluded by earlier ones.
+func (ignore gitignore) ignored(relativePath string, isDir bool) (bool, error) {
+	relativePath = filepath.ToSlash(relativePath)
+
+	ignored := false
+	for _, rule := range ignore.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		pathFromBase := relativePath
+		if rule.base != "" {
+			if !strings.HasPrefix(relativePath, rule.base+"/") {
+				continue
+			}
+
+			pathFromBase = strings.TrimPrefix(relativePath, rule.base+"/")
+		}
+
+		matched, err := matchSegments(rule.pattern, strings.Split(pathFromBase, "/"))
+		if err != nil {
+			return false, err
+		}
+
+		if matched {
+			ignored = !rule.negate
+		}
+	}
+
+	return ignored, nil
+}
@@ -0,0 +1,89 @@
+package executehelpers_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/concourse/fly/commands/internal/executehelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WalkFiles", func() {
+	var dir string
+
+	writeFile := func(path string, contents string) {
+		fullPath := filepath.Join(dir, path)
+
+		err := os.MkdirAll(filepath.Dir(fullPath), 0755)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(fullPath, []byte(contents), 0644)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "fly-walk-files")
+		Expect(err).NotTo(HaveOccurred())
+
+		writeFile("task.yml", "")
+		writeFile("target/app.jar", "")
+		writeFile("tmp/keep-this", "")
+		writeFile("tmp/scratch", "")
+		writeFile("src/main.go", "")
+		writeFile("src/debug.log", "")
+		writeFile(".git/HEAD", "")
+		writeFile("vendor/lib/lib.go", "")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("lists every file when nothing is excluded", func() {
+		files, err := WalkFiles(dir, nil, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(ConsistOf(
+			"task.yml",
+			filepath.Join("target", "app.jar"),
+			filepath.Join("tmp", "keep-this"),
+			filepath.Join("tmp", "scratch"),
+			filepath.Join("src", "main.go"),
+			filepath.Join("src", "debug.log"),
+			filepath.Join(".git", "HEAD"),
+			filepath.Join("vendor", "lib", "lib.go"),
+		))
+	})
+
+	It("skips paths matching the exclude patterns", func() {
+		files, err := WalkFiles(dir, []string{"vendor/**", "**/*.log"}, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).NotTo(ContainElement(filepath.Join("vendor", "lib", "lib.go")))
+		Expect(files).NotTo(ContainElement(filepath.Join("src", "debug.log")))
+		Expect(files).To(ContainElement(filepath.Join("src", "main.go")))
+	})
+
+	Context("when respecting .gitignore", func() {
+		BeforeEach(func() {
+			writeFile(".gitignore", "target/\ntmp/*\n!tmp/keep-this\n")
+			writeFile("src/.gitignore", "*.log\n")
+		})
+
+		It("skips ignored paths, honoring negations and nested .gitignore files", func() {
+			files, err := WalkFiles(dir, nil, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(ConsistOf(
+				".gitignore",
+				"task.yml",
+				filepath.Join("tmp", "keep-this"),
+				filepath.Join("src", ".gitignore"),
+				filepath.Join("src", "main.go"),
+				filepath.Join(".git", "HEAD"),
+				filepath.Join("vendor", "lib", "lib.go"),
+			))
+		})
+	})
+})
@@ -0,0 +1,128 @@
+package executehelpers
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+)
+
+// Include is a file or directory from outside of an input that is uploaded
+// as part of it, under Dest.
+type Include struct {
+	Path string
+	Dest string
+}
+
+// AddIncludes attaches each include to the input it names, which must be
+// uploaded from a local directory. Nothing may be included over a file
+// already in the input, whether or not it's excluded, or over another
+// include; directories are merged.
+func AddIncludes(inputs []Input, includes []flaghelpers.IncludeFlag) ([]Input, error) {
+	for _, include := range includes {
+		found := false
+
+		for i, input := range inputs {
+			if input.Name != include.Input {
+				continue
+			}
+
+			if input.Path == "" {
+				return nil, fmt.Errorf("input '%s' is not uploaded, so nothing can be included in it", input.Name)
+			}
+
+			inputs[i].Includes = append(inputs[i].Includes, Include{
+				Path: include.Path,
+				Dest: include.Dest,
+			})
+
+			found = true
+		}
+
+		if !found {
+			return nil, fmt.Errorf("unknown input '%s' given to --include", include.Input)
+		}
+	}
+
+	for _, input := range inputs {
+		err := checkIncludes(input)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return inputs, nil
+}
+
+func checkIncludes(input Input) error {
+	included := map[string]bool{}
+
+	for _, include := range input.Includes {
+		err := walkInclude(include, input.Dereference, func(path string, name string, info os.FileInfo, err error) error {
+			// broken links are all reported together when uploading
+			if os.IsNotExist(err) {
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() {
+				existing, err := os.Lstat(filepath.Join(input.Path, filepath.FromSlash(name)))
+				if err == nil && !existing.IsDir() {
+					return fmt.Errorf("cannot include '%s' in input '%s': '%s' is already a file in it", path, input.Name, name)
+				}
+
+				return nil
+			}
+
+			if included[name] {
+				return fmt.Errorf("cannot include '%s' in input '%s': '%s' is already included", path, input.Name, name)
+			}
+
+			included[name] = true
+
+			_, err = os.Lstat(filepath.Join(input.Path, filepath.FromSlash(name)))
+			if err == nil {
+				return fmt.Errorf("cannot include '%s' in input '%s': '%s' is already in it", path, input.Name, name)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walkInclude visits everything in the include along with the name it has
+// within the input. A directory's contents go under Dest; a file goes in it.
+func walkInclude(include Include, follow bool, visit func(path string, name string, info os.FileInfo, err error) error) error {
+	root, err := filepath.EvalSymlinks(include.Path)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return visit(root, path.Join(include.Dest, filepath.Base(include.Path)), info, nil)
+	}
+
+	return walk(root, follow, func(walked string, info os.FileInfo, err error) error {
+		relative, relErr := filepath.Rel(root, walked)
+		if relErr != nil {
+			return relErr
+		}
+
+		return visit(walked, path.Join(include.Dest, filepath.ToSlash(relative)), info, err)
+	})
+}
@@ -0,0 +1,147 @@
+package executehelpers_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/concourse/atc"
+	. "github.com/concourse/fly/commands/internal/executehelpers"
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Includes", func() {
+	var inputDir string
+	var extraDir string
+
+	BeforeEach(func() {
+		var err error
+		inputDir, err = ioutil.TempDir("", "fly-include-input")
+		Expect(err).NotTo(HaveOccurred())
+
+		extraDir, err = ioutil.TempDir("", "fly-include-extra")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(inputDir, "task.yml"), []byte("task"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.Mkdir(filepath.Join(inputDir, "vendor"), 0755)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.Mkdir(filepath.Join(extraDir, "bin"), 0700)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(extraDir, "bin", "tool"), []byte("tool"), 0755)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(extraDir, "README"), []byte("readme"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(inputDir)
+		os.RemoveAll(extraDir)
+	})
+
+	inputs := func() []Input {
+		return []Input{
+			{Name: "some-input", Path: inputDir},
+			{Name: "remote-input", BuildInput: atc.BuildInput{Name: "remote-input"}},
+		}
+	}
+
+	Describe("AddIncludes", func() {
+		It("attaches includes to the inputs they name", func() {
+			withIncludes, err := AddIncludes(inputs(), []flaghelpers.IncludeFlag{
+				{Input: "some-input", Path: extraDir, Dest: "vendor/extra"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(withIncludes[0].Includes).To(Equal([]Include{{Path: extraDir, Dest: "vendor/extra"}}))
+			Expect(withIncludes[1].Includes).To(BeEmpty())
+		})
+
+		It("merges directories with those already in the input", func() {
+			_, err := AddIncludes(inputs(), []flaghelpers.IncludeFlag{
+				{Input: "some-input", Path: extraDir, Dest: "."},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects includes over files already in the input", func() {
+			err := ioutil.WriteFile(filepath.Join(inputDir, "README"), []byte("real readme"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = AddIncludes(inputs(), []flaghelpers.IncludeFlag{
+				{Input: "some-input", Path: extraDir, Dest: "."},
+			})
+			Expect(err).To(MatchError(ContainSubstring("'README' is already in it")))
+		})
+
+		It("rejects includes over each other", func() {
+			_, err := AddIncludes(inputs(), []flaghelpers.IncludeFlag{
+				{Input: "some-input", Path: filepath.Join(extraDir, "README"), Dest: "docs"},
+				{Input: "some-input", Path: filepath.Join(extraDir, "README"), Dest: "docs"},
+			})
+			Expect(err).To(MatchError(ContainSubstring("'docs/README' is already included")))
+		})
+
+		It("rejects unknown inputs", func() {
+			_, err := AddIncludes(inputs(), []flaghelpers.IncludeFlag{
+				{Input: "bogus-input", Path: extraDir, Dest: "extra"},
+			})
+			Expect(err).To(MatchError("unknown input 'bogus-input' given to --include"))
+		})
+
+		It("rejects inputs that aren't uploaded", func() {
+			_, err := AddIncludes(inputs(), []flaghelpers.IncludeFlag{
+				{Input: "remote-input", Path: extraDir, Dest: "extra"},
+			})
+			Expect(err).To(MatchError("input 'remote-input' is not uploaded, so nothing can be included in it"))
+		})
+	})
+
+	It("archives included files under their destination, with their modes", func() {
+		stream, err := NativeTarGZStreamFrom(inputDir, []string{"."}, []Include{
+			{Path: extraDir, Dest: "vendor/extra"},
+			{Path: filepath.Join(extraDir, "README"), Dest: "docs"},
+		}, false, gzip.DefaultCompression)
+		Expect(err).NotTo(HaveOccurred())
+
+		defer stream.Close()
+
+		gr, err := gzip.NewReader(stream)
+		Expect(err).NotTo(HaveOccurred())
+
+		tr := tar.NewReader(gr)
+
+		modes := map[string]os.FileMode{}
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+
+			Expect(err).NotTo(HaveOccurred())
+
+			modes[hdr.Name] = hdr.FileInfo().Mode().Perm()
+		}
+
+		Expect(modes).To(HaveKey("task.yml"))
+		Expect(modes).To(HaveKey("vendor/extra/"))
+		Expect(modes).To(HaveKey("docs/README"))
+
+		if runtime.GOOS != "windows" {
+			Expect(modes["vendor/extra/bin/"]).To(Equal(os.FileMode(0700)))
+			Expect(modes["vendor/extra/bin/tool"]).To(Equal(os.FileMode(0755)))
+			Expect(modes["vendor/extra/README"]).To(Equal(os.FileMode(0600)))
+		}
+	})
+})
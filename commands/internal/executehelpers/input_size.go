@@ -0,0 +1,64 @@
+package executehelpers
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// EntrySize is the total size of a top-level file or directory of an input.
+type EntrySize struct {
+	Name string
+	Size int64
+}
+
+// InputSize is how much of an input would be uploaded: the same files are
+// excluded as when uploading it.
+type InputSize struct {
+	Total   int64
+	Entries []EntrySize
+}
+
+// SizeOf totals the sizes of the files that would be uploaded for the input,
+// broken down by its top-level entries, largest first.
+func SizeOf(input Input, excludeIgnored bool, respectGitignore bool, excludes []string) (InputSize, error) {
+	files, err := uploadedFiles(input.Path, excludeIgnored, respectGitignore, excludes)
+	if err != nil {
+		return InputSize{}, err
+	}
+
+	sizes, err := manifestOf(input.Path, files, input.Dereference)
+	if err != nil {
+		return InputSize{}, err
+	}
+
+	var total int64
+	byEntry := map[string]int64{}
+	for path, entry := range sizes {
+		total += entry.size
+
+		topLevel := strings.SplitN(filepath.ToSlash(path), "/", 2)[0]
+		byEntry[topLevel] += entry.size
+	}
+
+	entries := make([]EntrySize, 0, len(byEntry))
+	for name, size := range byEntry {
+		entries = append(entries, EntrySize{Name: name, Size: size})
+	}
+
+	sort.Sort(bySizeDescending(entries))
+
+	return InputSize{Total: total, Entries: entries}, nil
+}
+
+type bySizeDescending []EntrySize
+
+func (entries bySizeDescending) Len() int      { return len(entries) }
+func (entries bySizeDescending) Swap(i, j int) { entries[i], entries[j] = entries[j], entries[i] }
+func (entries bySizeDescending) Less(i, j int) bool {
+	if entries[i].Size == entries[j].Size {
+		return entries[i].Name < entries[j].Name
+	}
+
+	return entries[i].Size > entries[j].Size
+}
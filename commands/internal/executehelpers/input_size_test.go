@@ -0,0 +1,57 @@
+package executehelpers_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/concourse/fly/commands/internal/executehelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SizeOf", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "input-size")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(dir, "vendor", "deep"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(dir, "tmp"), 0755)).To(Succeed())
+
+		Expect(ioutil.WriteFile(filepath.Join(dir, "vendor", "a"), make([]byte, 100), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "vendor", "deep", "b"), make([]byte, 200), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "tmp", "scratch"), make([]byte, 1000), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "main.go"), make([]byte, 10), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("totals the input, broken down by its top-level entries, largest first", func() {
+		size, err := SizeOf(Input{Path: dir}, false, false, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(size.Total).To(Equal(int64(1310)))
+		Expect(size.Entries).To(Equal([]EntrySize{
+			{Name: "tmp", Size: 1000},
+			{Name: "vendor", Size: 300},
+			{Name: "main.go", Size: 10},
+		}))
+	})
+
+	It("does not count excluded paths", func() {
+		size, err := SizeOf(Input{Path: dir}, false, false, []string{"tmp"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(size.Total).To(Equal(int64(310)))
+		Expect(size.Entries).To(Equal([]EntrySize{
+			{Name: "vendor", Size: 300},
+			{Name: "main.go", Size: 10},
+		}))
+	})
+})
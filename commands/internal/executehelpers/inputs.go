@@ -5,18 +5,27 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 
 	"github.com/concourse/atc"
 	"github.com/concourse/fly/commands/internal/flaghelpers"
 	"github.com/concourse/go-concourse/concourse"
 )
 
+var commitSHA = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
 type Input struct {
 	Name string
 
 	Path string
 	Pipe atc.Pipe
 
+	// uploaded along with what's at Path
+	Includes []Include
+
+	// archive what symlinks point to rather than the links
+	Dereference bool
+
 	BuildInput atc.BuildInput
 }
 
@@ -24,21 +33,47 @@ func DetermineInputs(
 	client concourse.Client,
 	taskInputs []atc.TaskInputConfig,
 	inputMappings []flaghelpers.InputPairFlag,
+	nameMappings []flaghelpers.VariablePairFlag,
 	inputsFrom flaghelpers.JobFlag,
 ) ([]Input, error) {
-	err := CheckForUnknownInputMappings(inputMappings, taskInputs)
+	localNames, err := LocalInputNames(nameMappings)
+	if err != nil {
+		return nil, err
+	}
+
+	for taskName := range localNames {
+		if !TaskInputsContainsName(taskInputs, taskName) {
+			return nil, fmt.Errorf("unknown input `%s`", taskName)
+		}
+	}
+
+	err = CheckForUnknownInputMappings(inputMappings, taskInputs, localNames)
 	if err != nil {
 		return nil, err
 	}
 
+	if len(taskInputs) == 0 {
+		fmt.Fprintln(os.Stderr, "no inputs declared; nothing to upload")
+		return []Input{}, nil
+	}
+
 	if len(inputMappings) == 0 && inputsFrom.PipelineName == "" && inputsFrom.JobName == "" {
 		wd, err := os.Getwd()
 		if err != nil {
 			return nil, err
 		}
 
+		name := filepath.Base(wd)
+
+		// with only one input there's no ambiguity, so the directory's name
+		// doesn't need to match
+		if len(taskInputs) == 1 {
+			name = localInputName(localNames, taskInputs[0].Name)
+			fmt.Fprintf(os.Stderr, "using current directory as input '%s'\n", taskInputs[0].Name)
+		}
+
 		inputMappings = append(inputMappings, flaghelpers.InputPairFlag{
-			Name: filepath.Base(wd),
+			Name: name,
 			Path: wd,
 		})
 	}
@@ -55,29 +90,70 @@ func DetermineInputs(
 
 	inputs := []Input{}
 	for _, taskInput := range taskInputs {
-		input, found := inputsFromLocal[taskInput.Name]
+		name := localInputName(localNames, taskInput.Name)
+
+		input, found := inputsFromLocal[name]
 		if !found {
-			input, found = inputsFromJob[taskInput.Name]
+			input, found = inputsFromJob[name]
 			if !found {
 				return nil, fmt.Errorf("missing required input `%s`", taskInput.Name)
 			}
 		}
 
+		input.Name = taskInput.Name
+		if input.BuildInput.Name != "" {
+			input.BuildInput.Name = taskInput.Name
+		}
+
 		inputs = append(inputs, input)
 	}
 
 	return inputs, nil
 }
 
-func CheckForUnknownInputMappings(inputMappings []flaghelpers.InputPairFlag, validInputs []atc.TaskInputConfig) error {
+// LocalInputNames maps task input names to the names they're provided under
+// locally, as given by --input-mapping.
+func LocalInputNames(nameMappings []flaghelpers.VariablePairFlag) (map[string]string, error) {
+	localNames := map[string]string{}
+
+	for _, mapping := range nameMappings {
+		existing, found := localNames[mapping.Name]
+		if found && existing != mapping.Value {
+			return nil, fmt.Errorf("conflicting mappings for input `%s`: `%s` and `%s`", mapping.Name, existing, mapping.Value)
+		}
+
+		localNames[mapping.Name] = mapping.Value
+	}
+
+	return localNames, nil
+}
+
+func localInputName(localNames map[string]string, taskInputName string) string {
+	if name, found := localNames[taskInputName]; found {
+		return name
+	}
+
+	return taskInputName
+}
+
+func CheckForUnknownInputMappings(inputMappings []flaghelpers.InputPairFlag, validInputs []atc.TaskInputConfig, localNames map[string]string) error {
 	for _, inputMapping := range inputMappings {
-		if !TaskInputsContainsName(validInputs, inputMapping.Name) {
+		if !TaskInputsContainsName(validInputs, inputMapping.Name) && !mapsToTaskInput(localNames, validInputs, inputMapping.Name) {
 			return fmt.Errorf("unknown input `%s`", inputMapping.Name)
 		}
 	}
 	return nil
 }
 
+func mapsToTaskInput(localNames map[string]string, validInputs []atc.TaskInputConfig, localName string) bool {
+	for taskName, name := range localNames {
+		if name == localName && TaskInputsContainsName(validInputs, taskName) {
+			return true
+		}
+	}
+	return false
+}
+
 func TaskInputsContainsName(inputs []atc.TaskInputConfig, name string) bool {
 	for _, input := range inputs {
 		if input.Name == name {
@@ -94,21 +170,49 @@ func GenerateLocalInputs(client concourse.Client, inputMappings []flaghelpers.In
 		inputName := i.Name
 		absPath := i.Path
 
-		pipe, err := client.CreatePipe()
+		if i.URI != "" {
+			kvMap[inputName] = Input{
+				Name:       inputName,
+				BuildInput: gitBuildInput(i),
+			}
+
+			continue
+		}
+
+		pipe, err := createPipe(client, "input '"+inputName+"'")
 		if err != nil {
 			return nil, err
 		}
 
 		kvMap[inputName] = Input{
-			Name: inputName,
-			Path: absPath,
-			Pipe: pipe,
+			Name:        inputName,
+			Path:        absPath,
+			Pipe:        pipe,
+			Dereference: i.Dereference,
 		}
 	}
 
 	return kvMap, nil
 }
 
+// a ref that looks like a commit SHA pins the version; anything else is
+// treated as a branch
+func gitBuildInput(mapping flaghelpers.InputPairFlag) atc.BuildInput {
+	input := atc.BuildInput{
+		Name:   mapping.Name,
+		Type:   "git",
+		Source: atc.Source{"uri": mapping.URI},
+	}
+
+	if commitSHA.MatchString(mapping.Ref) {
+		input.Version = atc.Version{"ref": mapping.Ref}
+	} else if mapping.Ref != "" {
+		input.Source["branch"] = mapping.Ref
+	}
+
+	return input
+}
+
 func FetchInputsFromJob(client concourse.Client, inputsFrom flaghelpers.JobFlag) (map[string]Input, error) {
 	kvMap := map[string]Input{}
 	if inputsFrom.PipelineName == "" && inputsFrom.JobName == "" {
@@ -0,0 +1,158 @@
+package executehelpers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/fly/config"
+	"github.com/concourse/fly/template"
+	"github.com/concourse/go-concourse/concourse"
+)
+
+func TaskConfigFromJob(
+	client concourse.Client,
+	job flaghelpers.JobFlag,
+	stepName string,
+	inputMappings []flaghelpers.InputPairFlag,
+	fileVariables template.Variables,
+	flagVariables template.Variables,
+) (atc.TaskConfig, error) {
+	step, err := FindJobTaskStep(client, job, stepName)
+	if err != nil {
+		return atc.TaskConfig{}, err
+	}
+
+	var taskConfig atc.TaskConfig
+	if step.TaskConfig != nil {
+		var problems config.Problems
+		taskConfig, problems = config.CheckInlineTaskConfig("task step '"+step.Task+"'", *step.TaskConfig, fileVariables, flagVariables)
+		if len(problems.Errors) > 0 {
+			return atc.TaskConfig{}, fmt.Errorf("invalid config in task step '%s':\n%s", step.Task, strings.Join(problems.Errors, "\n"))
+		}
+
+		problems.Print(os.Stderr)
+	} else {
+		configPath, err := localTaskConfigPath(step, inputMappings)
+		if err != nil {
+			return atc.TaskConfig{}, err
+		}
+
+		taskConfig = config.ReadTaskConfig(configPath, fileVariables, flagVariables)
+	}
+
+	if len(step.Params) > 0 && taskConfig.Params == nil {
+		taskConfig.Params = map[string]string{}
+	}
+
+	for name, value := range step.Params {
+		taskConfig.Params[name] = fmt.Sprintf("%v", value)
+	}
+
+	return taskConfig, nil
+}
+
+func FindJobTaskStep(client concourse.Client, job flaghelpers.JobFlag, stepName string) (atc.PlanConfig, error) {
+	pipelineConfig, _, found, err := client.PipelineConfig(job.PipelineName)
+	if err != nil {
+		return atc.PlanConfig{}, err
+	}
+
+	if !found {
+		return atc.PlanConfig{}, fmt.Errorf("pipeline '%s' not found", job.PipelineName)
+	}
+
+	jobConfig, found := atc.JobConfigs(pipelineConfig.Jobs).Lookup(job.JobName)
+	if !found {
+		return atc.PlanConfig{}, fmt.Errorf("job '%s' not found in pipeline '%s'", job.JobName, job.PipelineName)
+	}
+
+	steps := taskSteps(jobConfig.Plan)
+
+	if stepName != "" {
+		for _, step := range steps {
+			if step.Task == stepName {
+				return step, nil
+			}
+		}
+
+		return atc.PlanConfig{}, fmt.Errorf("job '%s' has no task step named '%s'", job.JobName, stepName)
+	}
+
+	switch len(steps) {
+	case 0:
+		return atc.PlanConfig{}, fmt.Errorf("job '%s' has no task steps", job.JobName)
+	case 1:
+		return steps[0], nil
+	default:
+		names := make([]string, len(steps))
+		for i, step := range steps {
+			names[i] = step.Task
+		}
+
+		return atc.PlanConfig{}, fmt.Errorf("job '%s' has multiple task steps; specify one with --step (%s)", job.JobName, strings.Join(names, ", "))
+	}
+}
+
+func taskSteps(plan atc.PlanSequence) []atc.PlanConfig {
+	steps := []atc.PlanConfig{}
+
+	for _, step := range plan {
+		steps = append(steps, taskStepsIn(step)...)
+	}
+
+	return steps
+}
+
+func taskStepsIn(step atc.PlanConfig) []atc.PlanConfig {
+	steps := []atc.PlanConfig{}
+
+	if step.Task != "" {
+		steps = append(steps, step)
+	}
+
+	if step.Do != nil {
+		steps = append(steps, taskSteps(*step.Do)...)
+	}
+
+	if step.Aggregate != nil {
+		steps = append(steps, taskSteps(*step.Aggregate)...)
+	}
+
+	for _, hook := range []*atc.PlanConfig{step.Try, step.Success, step.Failure, step.Ensure} {
+		if hook != nil {
+			steps = append(steps, taskStepsIn(*hook)...)
+		}
+	}
+
+	return steps
+}
+
+// a task's config file lives within one of its inputs, e.g. repo/ci/task.yml,
+// so it can only be found if that input is provided locally
+func localTaskConfigPath(step atc.PlanConfig, inputMappings []flaghelpers.InputPairFlag) (string, error) {
+	segments := strings.SplitN(step.TaskConfigPath, "/", 2)
+	if len(segments) != 2 {
+		return "", fmt.Errorf("task step '%s' has no config", step.Task)
+	}
+
+	for _, mapping := range inputMappings {
+		if mapping.Name == segments[0] {
+			if mapping.URI != "" {
+				return "", fmt.Errorf("task step '%s' loads its config from '%s', which must be a local input", step.Task, step.TaskConfigPath)
+			}
+
+			return filepath.Join(mapping.Path, filepath.FromSlash(segments[1])), nil
+		}
+	}
+
+	return "", fmt.Errorf(
+		"task step '%s' loads its config from '%s'; provide the input with -i %s=PATH",
+		step.Task,
+		step.TaskConfigPath,
+		segments[0],
+	)
+}
@@ -0,0 +1,151 @@
+package executehelpers_test
+
+import (
+	"github.com/concourse/atc"
+	. "github.com/concourse/fly/commands/internal/executehelpers"
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/go-concourse/concourse/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TaskConfigFromJob", func() {
+	var fakeClient *fakes.FakeClient
+	var job flaghelpers.JobFlag
+	var unitConfig atc.TaskConfig
+
+	BeforeEach(func() {
+		fakeClient = new(fakes.FakeClient)
+		job = flaghelpers.JobFlag{PipelineName: "some-pipeline", JobName: "some-job"}
+
+		unitConfig = atc.TaskConfig{
+			Platform: "linux",
+			Params:   map[string]string{"FOO": "from-config"},
+			Run:      atc.TaskRunConfig{Path: "./unit"},
+		}
+	})
+
+	Context("when the job has a single task step", func() {
+		BeforeEach(func() {
+			fakeClient.PipelineConfigReturns(atc.Config{
+				Jobs: atc.JobConfigs{
+					{
+						Name: "some-job",
+						Plan: atc.PlanSequence{
+							{Get: "repo"},
+							{
+								Task:       "unit",
+								TaskConfig: &unitConfig,
+								Params:     atc.Params{"FOO": "from-step"},
+							},
+						},
+					},
+				},
+			}, "1", true, nil)
+		})
+
+		It("returns the step's config with the step's params applied", func() {
+			config, err := TaskConfigFromJob(fakeClient, job, "", nil, nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Run.Path).To(Equal("./unit"))
+			Expect(config.Params).To(Equal(map[string]string{"FOO": "from-step"}))
+			Expect(fakeClient.PipelineConfigArgsForCall(0)).To(Equal("some-pipeline"))
+		})
+	})
+
+	Context("when the task step's inline config is invalid", func() {
+		BeforeEach(func() {
+			unitConfig.Run.Path = ""
+
+			fakeClient.PipelineConfigReturns(atc.Config{
+				Jobs: atc.JobConfigs{
+					{
+						Name: "some-job",
+						Plan: atc.PlanSequence{
+							{Task: "unit", TaskConfig: &unitConfig},
+						},
+					},
+				},
+			}, "1", true, nil)
+		})
+
+		It("validates it as it would a config file", func() {
+			_, err := TaskConfigFromJob(fakeClient, job, "", nil, nil, nil)
+			Expect(err).To(MatchError(ContainSubstring("invalid config in task step 'unit'")))
+			Expect(err).To(MatchError(ContainSubstring("missing required field 'run.path'")))
+		})
+	})
+
+	Context("when the job has multiple task steps", func() {
+		BeforeEach(func() {
+			integrationConfig := atc.TaskConfig{
+				Platform: "linux",
+				Run:      atc.TaskRunConfig{Path: "./integration"},
+			}
+
+			fakeClient.PipelineConfigReturns(atc.Config{
+				Jobs: atc.JobConfigs{
+					{
+						Name: "some-job",
+						Plan: atc.PlanSequence{
+							{Task: "unit", TaskConfig: &unitConfig},
+							{
+								Aggregate: &atc.PlanSequence{
+									{Task: "integration", TaskConfig: &integrationConfig},
+								},
+							},
+						},
+					},
+				},
+			}, "1", true, nil)
+		})
+
+		It("requires a step to be specified", func() {
+			_, err := TaskConfigFromJob(fakeClient, job, "", nil, nil, nil)
+			Expect(err).To(MatchError("job 'some-job' has multiple task steps; specify one with --step (unit, integration)"))
+		})
+
+		It("returns the specified step's config", func() {
+			config, err := TaskConfigFromJob(fakeClient, job, "integration", nil, nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Run.Path).To(Equal("./integration"))
+		})
+
+		It("errors when the specified step does not exist", func() {
+			_, err := TaskConfigFromJob(fakeClient, job, "bogus", nil, nil, nil)
+			Expect(err).To(MatchError("job 'some-job' has no task step named 'bogus'"))
+		})
+	})
+
+	Context("when the task step loads its config from a file", func() {
+		BeforeEach(func() {
+			fakeClient.PipelineConfigReturns(atc.Config{
+				Jobs: atc.JobConfigs{
+					{
+						Name: "some-job",
+						Plan: atc.PlanSequence{
+							{Task: "unit", TaskConfigPath: "repo/ci/unit.yml"},
+						},
+					},
+				},
+			}, "1", true, nil)
+		})
+
+		It("errors when the input containing the file is not provided", func() {
+			_, err := TaskConfigFromJob(fakeClient, job, "", nil, nil, nil)
+			Expect(err).To(MatchError("task step 'unit' loads its config from 'repo/ci/unit.yml'; provide the input with -i repo=PATH"))
+		})
+	})
+
+	Context("when the job does not exist", func() {
+		BeforeEach(func() {
+			fakeClient.PipelineConfigReturns(atc.Config{}, "1", true, nil)
+		})
+
+		It("returns an error", func() {
+			_, err := TaskConfigFromJob(fakeClient, job, "", nil, nil, nil)
+			Expect(err).To(MatchError("job 'some-job' not found in pipeline 'some-pipeline'"))
+		})
+	})
+})
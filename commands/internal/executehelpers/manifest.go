@@ -0,0 +1,84 @@
+package executehelpers
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+type manifestEntry struct {
+	size    int64
+	modTime int64
+}
+
+// manifest records the size and modification time of every file that would
+// be uploaded, to tell whether an input changed between attempts
+type manifest map[string]manifestEntry
+
+// manifestOf records the files at the paths; when following symlinks, it
+// records what they point to
+func manifestOf(workDir string, paths []string, follow bool) (manifest, error) {
+	// the input itself may be a link to the directory to upload
+	workDir, err := filepath.EvalSymlinks(workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := manifest{}
+
+	for _, p := range paths {
+		err := walk(filepath.Join(workDir, p), follow, func(path string, info os.FileInfo, err error) error {
+			// broken links are all reported together when archiving
+			if follow && os.IsNotExist(err) {
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() {
+				return nil
+			}
+
+			relative, err := filepath.Rel(workDir, path)
+			if err != nil {
+				return err
+			}
+
+			files[relative] = manifestEntry{
+				size:    info.Size(),
+				modTime: info.ModTime().UnixNano(),
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// changedSince returns the paths that were added, removed, or modified,
+// sorted
+func (files manifest) changedSince(previous manifest) []string {
+	changed := []string{}
+
+	for path, entry := range files {
+		if previousEntry, found := previous[path]; !found || previousEntry != entry {
+			changed = append(changed, path)
+		}
+	}
+
+	for path := range previous {
+		if _, found := files[path]; !found {
+			changed = append(changed, path)
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed
+}
@@ -6,9 +6,14 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
+	"unicode/utf8"
 )
 
-func nativeTarGZStreamFrom(workDir string, paths []string) (io.ReadCloser, error) {
+// nativeTarGZStreamFrom archives the paths within workDir along with the
+// includes. When dereferencing, symlinks are archived as what they point to;
+// a broken link or a cycle fails before anything is archived.
+func nativeTarGZStreamFrom(workDir string, paths []string, includes []Include, dereference bool, compressionLevel int) (io.ReadCloser, error) {
 	r, w := io.Pipe()
 
 	absWorkDir, err := filepath.Abs(workDir)
@@ -16,20 +21,59 @@ func nativeTarGZStreamFrom(workDir string, paths []string) (io.ReadCloser, error
 		return nil, err
 	}
 
-	gzWriter := gzip.NewWriter(w)
+	// symlinks within the input are archived as links, but the input itself
+	// may be a link to the directory to upload
+	absWorkDir, err = filepath.EvalSymlinks(absWorkDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if dereference {
+		err = checkFollowable(absWorkDir, paths, includes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	gzWriter, err := gzip.NewWriterLevel(w, compressionLevel)
+	if err != nil {
+		return nil, err
+	}
 
 	tarWriter := tar.NewWriter(gzWriter)
 
+	// the name each hard-linked file was first archived under
+	links := map[fileID]string{}
+
 	go func() {
 		defer w.Close()
 		defer gzWriter.Close()
 		defer tarWriter.Close()
 
 		for _, p := range paths {
-			err = writePathToTar(tarWriter, absWorkDir, filepath.Join(absWorkDir, p))
+			err = writePathToTar(tarWriter, absWorkDir, filepath.Join(absWorkDir, p), dereference, links)
 			if err != nil {
 				w.CloseWithError(err)
-				break
+				return
+			}
+		}
+
+		for _, include := range includes {
+			err = walkInclude(include, dereference, func(path string, name string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+
+				// the input's own root is already archived
+				if name == "." {
+					return nil
+				}
+
+				return addTarFile(path, name, tarWriter, dereference, links)
+			})
+			if err != nil {
+				w.CloseWithError(err)
+				return
 			}
 		}
 	}()
@@ -37,8 +81,14 @@ func nativeTarGZStreamFrom(workDir string, paths []string) (io.ReadCloser, error
 	return r, nil
 }
 
-func writePathToTar(tw *tar.Writer, workDir string, srcPath string) error {
-	return filepath.Walk(srcPath, func(path string, info os.FileInfo, err error) error {
+// fileID identifies a file however many links there are to it
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+func writePathToTar(tw *tar.Writer, workDir string, srcPath string, dereference bool, links map[fileID]string) error {
+	return walk(srcPath, dereference, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -48,12 +98,17 @@ func writePathToTar(tw *tar.Writer, workDir string, srcPath string) error {
 			return err
 		}
 
-		return addTarFile(path, relative, tw)
+		return addTarFile(path, relative, tw, dereference, links)
 	})
 }
 
-func addTarFile(path, name string, tw *tar.Writer) error {
-	fi, err := os.Lstat(path)
+func addTarFile(path, name string, tw *tar.Writer, dereference bool, links map[fileID]string) error {
+	lstat := os.Lstat
+	if dereference {
+		lstat = os.Stat
+	}
+
+	fi, err := lstat(path)
 	if err != nil {
 		return err
 	}
@@ -81,6 +136,31 @@ func addTarFile(path, name string, tw *tar.Writer) error {
 		hdr.Name = filepath.ToSlash(name)
 	}
 
+	// archive later links to a file as links to the first, not as copies
+	if hdr.Typeflag == tar.TypeReg {
+		if id, ok := fileIDOf(fi); ok {
+			if first, found := links[id]; found {
+				hdr.Typeflag = tar.TypeLink
+				hdr.Linkname = first
+				hdr.Size = 0
+			} else {
+				links[id] = hdr.Name
+			}
+		}
+	}
+
+	// PAX records hold names of any length, but only UTF-8 ones; GNU's long
+	// names hold any bytes, e.g. Latin-1 names from older filesystems
+	hdr.Format = tar.FormatPAX
+	if !utf8.ValidString(hdr.Name) || !utf8.ValidString(hdr.Linkname) {
+		hdr.Format = tar.FormatGNU
+	}
+
+	// choosing the format keeps these, which would only bloat the archive
+	// with records for every file
+	hdr.AccessTime = time.Time{}
+	hdr.ChangeTime = time.Time{}
+
 	if err := tw.WriteHeader(hdr); err != nil {
 		return err
 	}
@@ -0,0 +1,341 @@
+package executehelpers_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	. "github.com/concourse/fly/commands/internal/executehelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NativeTarGZStreamFrom", func() {
+	var srcDir string
+	var names []string
+
+	longPath := filepath.Join(
+		strings.Repeat("a", 100),
+		strings.Repeat("b", 100),
+		strings.Repeat("c", 100),
+		"file",
+	)
+
+	BeforeEach(func() {
+		var err error
+		srcDir, err = ioutil.TempDir("", "fly-native-tar")
+		Expect(err).NotTo(HaveOccurred())
+
+		names = []string{longPath, "ünïcødé.txt"}
+
+		if runtime.GOOS != "windows" {
+			names = append(names, "with\nnewline")
+		}
+
+		// only some filesystems take names that aren't UTF-8
+		if runtime.GOOS == "linux" {
+			names = append(names, "caf\xe9")
+		}
+
+		for _, name := range names {
+			path := filepath.Join(srcDir, name)
+
+			err := os.MkdirAll(filepath.Dir(path), 0755)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(path, []byte("contents of "+name), 0644)
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(srcDir)
+	})
+
+	archive := func() io.ReadCloser {
+		stream, err := NativeTarGZStreamFrom(srcDir, []string{"."}, nil, false, gzip.DefaultCompression)
+		Expect(err).NotTo(HaveOccurred())
+
+		return stream
+	}
+
+	It("keeps long and unusual names intact", func() {
+		Expect(len(longPath)).To(BeNumerically(">", 255))
+
+		stream := archive()
+		defer stream.Close()
+
+		gr, err := gzip.NewReader(stream)
+		Expect(err).NotTo(HaveOccurred())
+
+		tr := tar.NewReader(gr)
+
+		archived := []string{}
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+
+			Expect(err).NotTo(HaveOccurred())
+
+			if hdr.Typeflag == tar.TypeReg {
+				archived = append(archived, hdr.Name)
+			}
+		}
+
+		expected := []string{}
+		for _, name := range names {
+			expected = append(expected, filepath.ToSlash(name))
+		}
+
+		Expect(archived).To(ConsistOf(expected))
+	})
+
+	Context("with hard links", func() {
+		BeforeEach(func() {
+			err := os.Link(filepath.Join(srcDir, "ünïcødé.txt"), filepath.Join(srcDir, "link-1"))
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Link(filepath.Join(srcDir, "ünïcødé.txt"), filepath.Join(srcDir, "link-2"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		headers := func() map[string]*tar.Header {
+			stream := archive()
+			defer stream.Close()
+
+			gr, err := gzip.NewReader(stream)
+			Expect(err).NotTo(HaveOccurred())
+
+			tr := tar.NewReader(gr)
+
+			headers := map[string]*tar.Header{}
+			for {
+				hdr, err := tr.Next()
+				if err == io.EOF {
+					break
+				}
+
+				Expect(err).NotTo(HaveOccurred())
+
+				headers[hdr.Name] = hdr
+			}
+
+			return headers
+		}
+
+		It("archives the file once, and the other links as links to it", func() {
+			if runtime.GOOS == "windows" {
+				Skip("links are archived as copies on Windows")
+			}
+
+			linked := []string{"ünïcødé.txt", "link-1", "link-2"}
+
+			regular := []string{}
+			links := []*tar.Header{}
+			for _, name := range linked {
+				hdr := headers()[name]
+				Expect(hdr).NotTo(BeNil())
+
+				if hdr.Typeflag == tar.TypeLink {
+					links = append(links, hdr)
+				} else {
+					Expect(hdr.Typeflag).To(Equal(byte(tar.TypeReg)))
+					regular = append(regular, hdr.Name)
+				}
+			}
+
+			Expect(regular).To(HaveLen(1))
+			Expect(links).To(HaveLen(2))
+
+			for _, link := range links {
+				Expect(link.Linkname).To(Equal(regular[0]))
+				Expect(link.Size).To(BeZero())
+			}
+		})
+
+		It("archives each link as a copy on Windows", func() {
+			if runtime.GOOS != "windows" {
+				Skip("only Windows can't tell links apart")
+			}
+
+			for _, name := range []string{"ünïcødé.txt", "link-1", "link-2"} {
+				Expect(headers()[name].Typeflag).To(Equal(byte(tar.TypeReg)))
+			}
+		})
+
+		It("extracts them as links", func() {
+			if runtime.GOOS == "windows" {
+				Skip("links are archived as copies on Windows")
+			}
+
+			dstDir, err := ioutil.TempDir("", "fly-native-tar-extracted")
+			Expect(err).NotTo(HaveOccurred())
+
+			defer os.RemoveAll(dstDir)
+
+			stream := archive()
+			defer stream.Close()
+
+			err = Extract(dstDir, stream, false, ioutil.Discard)
+			Expect(err).NotTo(HaveOccurred())
+
+			original, err := os.Stat(filepath.Join(dstDir, "ünïcødé.txt"))
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, name := range []string{"link-1", "link-2"} {
+				link, err := os.Stat(filepath.Join(dstDir, name))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(os.SameFile(original, link)).To(BeTrue())
+			}
+		})
+	})
+
+	Context("when following symlinks", func() {
+		var storeDir string
+
+		BeforeEach(func() {
+			if runtime.GOOS == "windows" {
+				Skip("creating symlinks requires privileges on Windows")
+			}
+
+			var err error
+			storeDir, err = ioutil.TempDir("", "fly-native-tar-store")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(filepath.Join(storeDir, "blob"), []byte("stored contents"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.MkdirAll(filepath.Join(storeDir, "dir"), 0755)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(filepath.Join(storeDir, "dir", "nested"), []byte("nested contents"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Symlink(filepath.Join(storeDir, "blob"), filepath.Join(srcDir, "linked-file"))
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Symlink(filepath.Join(storeDir, "dir"), filepath.Join(srcDir, "linked-dir"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(storeDir)
+		})
+
+		follow := func() (io.ReadCloser, error) {
+			return NativeTarGZStreamFrom(srcDir, []string{"."}, nil, true, gzip.DefaultCompression)
+		}
+
+		It("archives what they point to in their place", func() {
+			stream, err := follow()
+			Expect(err).NotTo(HaveOccurred())
+
+			defer stream.Close()
+
+			gr, err := gzip.NewReader(stream)
+			Expect(err).NotTo(HaveOccurred())
+
+			tr := tar.NewReader(gr)
+
+			contents := map[string]string{}
+			types := map[string]byte{}
+			for {
+				hdr, err := tr.Next()
+				if err == io.EOF {
+					break
+				}
+
+				Expect(err).NotTo(HaveOccurred())
+
+				types[hdr.Name] = hdr.Typeflag
+
+				body, err := ioutil.ReadAll(tr)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents[hdr.Name] = string(body)
+			}
+
+			Expect(types["linked-file"]).To(Equal(byte(tar.TypeReg)))
+			Expect(contents["linked-file"]).To(Equal("stored contents"))
+
+			Expect(types["linked-dir/"]).To(Equal(byte(tar.TypeDir)))
+			Expect(types["linked-dir/nested"]).To(Equal(byte(tar.TypeReg)))
+			Expect(contents["linked-dir/nested"]).To(Equal("nested contents"))
+		})
+
+		It("archives them as links when not following them", func() {
+			stream := archive()
+			defer stream.Close()
+
+			gr, err := gzip.NewReader(stream)
+			Expect(err).NotTo(HaveOccurred())
+
+			tr := tar.NewReader(gr)
+
+			types := map[string]byte{}
+			for {
+				hdr, err := tr.Next()
+				if err == io.EOF {
+					break
+				}
+
+				Expect(err).NotTo(HaveOccurred())
+
+				types[hdr.Name] = hdr.Typeflag
+			}
+
+			Expect(types["linked-file"]).To(Equal(byte(tar.TypeSymlink)))
+			Expect(types["linked-dir"]).To(Equal(byte(tar.TypeSymlink)))
+			Expect(types).NotTo(HaveKey("linked-dir/nested"))
+		})
+
+		It("fails on a cycle rather than following it forever", func() {
+			err := os.Symlink("..", filepath.Join(storeDir, "dir", "parent"))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = follow()
+			Expect(err).To(MatchError(ContainSubstring("leads back to")))
+			Expect(err.Error()).To(ContainSubstring(filepath.Join("linked-dir", "parent")))
+		})
+
+		It("lists every broken link before archiving anything", func() {
+			err := os.Symlink(filepath.Join(storeDir, "missing"), filepath.Join(srcDir, "broken-1"))
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Symlink("gone", filepath.Join(storeDir, "dir", "broken-2"))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = follow()
+			Expect(err).To(MatchError(ContainSubstring("cannot follow broken symlinks:")))
+			Expect(err.Error()).To(ContainSubstring("broken-1 -> " + filepath.Join(storeDir, "missing")))
+			Expect(err.Error()).To(ContainSubstring("linked-dir/broken-2 -> gone"))
+		})
+	})
+
+	It("round-trips through Extract", func() {
+		dstDir, err := ioutil.TempDir("", "fly-native-tar-extracted")
+		Expect(err).NotTo(HaveOccurred())
+
+		defer os.RemoveAll(dstDir)
+
+		stream := archive()
+		defer stream.Close()
+
+		err = Extract(dstDir, stream, false, ioutil.Discard)
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, name := range names {
+			contents, err := ioutil.ReadFile(filepath.Join(dstDir, name))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("contents of " + name))
+		}
+	})
+})
@@ -1,8 +1,11 @@
 package executehelpers
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/concourse/atc"
 	"github.com/concourse/fly/commands/internal/flaghelpers"
@@ -13,45 +16,162 @@ type Output struct {
 	Name string
 	Path string
 	Pipe atc.Pipe
+
+	// write the gzipped tarball to Path rather than extracting it
+	Archive bool
 }
 
 func DetermineOutputs(
 	client concourse.Client,
 	taskOutputs []atc.TaskOutputConfig,
 	outputMappings []flaghelpers.OutputPairFlag,
+	nameMappings []flaghelpers.VariablePairFlag,
+	archive bool,
 ) ([]Output, error) {
+	for _, mapping := range nameMappings {
+		if !taskOutputsContainsName(taskOutputs, mapping.Name) {
+			return nil, fmt.Errorf("unknown output '%s'", mapping.Name)
+		}
+	}
 
 	outputs := []Output{}
+	writesToStdout := false
+
+	outputMappings, err := mapOutputs(outputMappings, nameMappings)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, i := range outputMappings {
 		outputName := i.Name
 
-		notInConfig := true
-		for _, configOutput := range taskOutputs {
-			if configOutput.Name == outputName {
-				notInConfig = false
-			}
-		}
-		if notInConfig {
+		if !taskOutputsContainsName(taskOutputs, outputName) {
 			return nil, fmt.Errorf("unknown output '%s'", outputName)
 		}
 
+		if i.Path == "-" {
+			if writesToStdout {
+				return nil, errors.New("only one output may be written to stdout")
+			}
+
+			writesToStdout = true
+
+			pipe, err := createPipe(client, "output '"+outputName+"'")
+			if err != nil {
+				return nil, err
+			}
+
+			outputs = append(outputs, Output{
+				Name:    outputName,
+				Path:    i.Path,
+				Pipe:    pipe,
+				Archive: true,
+			})
+
+			continue
+		}
+
 		absPath, err := filepath.Abs(i.Path)
 		if err != nil {
 			return nil, err
 		}
 
-		pipe, err := client.CreatePipe()
+		archiveOutput := archive || isArchivePath(absPath)
+
+		// the destination is created on download, but finding out that it can't
+		// be should happen before the build runs
+		info, err := os.Stat(absPath)
+		if err == nil {
+			if archiveOutput && info.IsDir() {
+				return nil, fmt.Errorf("output path '%s' is a directory", i.Path)
+			}
+
+			if !archiveOutput && !info.IsDir() {
+				return nil, fmt.Errorf("output path '%s' exists and is not a directory", i.Path)
+			}
+		}
+
+		pipe, err := createPipe(client, "output '"+outputName+"'")
 		if err != nil {
 			return nil, err
 		}
 
 		outputs = append(outputs, Output{
-			Name: outputName,
-			Path: absPath,
-			Pipe: pipe,
+			Name:    outputName,
+			Path:    absPath,
+			Pipe:    pipe,
+			Archive: archiveOutput,
 		})
 	}
 
+	err = checkDistinctPaths(outputs)
+	if err != nil {
+		return nil, err
+	}
+
 	return outputs, nil
 }
+
+// outputs are downloaded concurrently, so they mustn't write to the same
+// place, or one within another
+func checkDistinctPaths(outputs []Output) error {
+	for i, a := range outputs {
+		for _, b := range outputs[i+1:] {
+			if a.Path == "-" || b.Path == "-" {
+				continue
+			}
+
+			if within(a.Path, b.Path) || within(b.Path, a.Path) {
+				return fmt.Errorf("outputs '%s' and '%s' would both be written to '%s'", a.Name, b.Name, a.Path)
+			}
+		}
+	}
+
+	return nil
+}
+
+// mapOutputs adds a download into the mapped local directory for each
+// --output-mapping, unless the output's path was given explicitly with -o
+func mapOutputs(outputMappings []flaghelpers.OutputPairFlag, nameMappings []flaghelpers.VariablePairFlag) ([]flaghelpers.OutputPairFlag, error) {
+	localNames := map[string]string{}
+
+	for _, mapping := range nameMappings {
+		existing, found := localNames[mapping.Name]
+		if found && existing != mapping.Value {
+			return nil, fmt.Errorf("conflicting mappings for output '%s': '%s' and '%s'", mapping.Name, existing, mapping.Value)
+		}
+
+		if !found {
+			localNames[mapping.Name] = mapping.Value
+			outputMappings = appendUnlessMapped(outputMappings, flaghelpers.OutputPairFlag{
+				Name: mapping.Name,
+				Path: mapping.Value,
+			})
+		}
+	}
+
+	return outputMappings, nil
+}
+
+func appendUnlessMapped(outputMappings []flaghelpers.OutputPairFlag, pair flaghelpers.OutputPairFlag) []flaghelpers.OutputPairFlag {
+	for _, existing := range outputMappings {
+		if existing.Name == pair.Name {
+			return outputMappings
+		}
+	}
+
+	return append(outputMappings, pair)
+}
+
+func taskOutputsContainsName(outputs []atc.TaskOutputConfig, name string) bool {
+	for _, output := range outputs {
+		if output.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isArchivePath(path string) bool {
+	return strings.HasSuffix(path, ".tgz") || strings.HasSuffix(path, ".tar.gz")
+}
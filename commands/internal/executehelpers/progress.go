@@ -0,0 +1,127 @@
+package executehelpers
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/concourse/fly/ui"
+)
+
+const progressInterval = 250 * time.Millisecond
+
+var spinner = []string{"|", "/", "-", "\\"}
+
+// Progress reports the bytes read through it via a Console until Finish or
+// Stop is called. Streamed pipes have no known length, so only the running
+// total and rate are shown.
+type Progress struct {
+	active  string
+	done    string
+	console *Console
+
+	start   time.Time
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	stopped bool
+
+	lock  sync.Mutex
+	total int64
+}
+
+// NewProgress reports on a line of its own, redrawn in place on dst.
+func NewProgress(active string, done string, dst io.Writer) *Progress {
+	return NewConsole(dst, true).NewProgress(active, done)
+}
+
+func (progress *Progress) Reader(src io.Reader) io.Reader {
+	return progressReader{src: src, progress: progress}
+}
+
+// Finish stops reporting and prints a summary of the transfer.
+func (progress *Progress) Finish() {
+	if !progress.halt() {
+		return
+	}
+
+	progress.console.finish(progress, fmt.Sprintf(
+		"%s (%s in %.1fs)",
+		progress.done,
+		ui.FormatBytes(float64(progress.read())),
+		time.Since(progress.start).Seconds(),
+	))
+}
+
+// Stop stops reporting, clearing the progress. It is safe to call more than
+// once, and after Finish.
+func (progress *Progress) Stop() {
+	if !progress.halt() {
+		return
+	}
+
+	progress.console.stop(progress)
+}
+
+func (progress *Progress) halt() bool {
+	if progress.stopped {
+		return false
+	}
+
+	progress.stopped = true
+
+	close(progress.stop)
+	progress.wg.Wait()
+
+	return true
+}
+
+func (progress *Progress) report() {
+	defer progress.wg.Done()
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	for tick := 0; ; tick++ {
+		select {
+		case <-ticker.C:
+			total := float64(progress.read())
+			rate := total / time.Since(progress.start).Seconds()
+
+			progress.console.update(progress, tick, fmt.Sprintf(
+				"%s: %s (%s/s)",
+				progress.active,
+				ui.FormatBytes(total),
+				ui.FormatBytes(rate),
+			))
+		case <-progress.stop:
+			return
+		}
+	}
+}
+
+func (progress *Progress) add(n int) {
+	progress.lock.Lock()
+	progress.total += int64(n)
+	progress.lock.Unlock()
+}
+
+func (progress *Progress) read() int64 {
+	progress.lock.Lock()
+	defer progress.lock.Unlock()
+
+	return progress.total
+}
+
+// progressReader passes reads, and their errors, through untouched so that
+// readers further down the stream (e.g. gzip) still see truncation
+type progressReader struct {
+	src      io.Reader
+	progress *Progress
+}
+
+func (reader progressReader) Read(p []byte) (int, error) {
+	n, err := reader.src.Read(p)
+	reader.progress.add(n)
+	return n, err
+}
@@ -0,0 +1,36 @@
+package executehelpers_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing/iotest"
+
+	. "github.com/concourse/fly/commands/internal/executehelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Progress", func() {
+	It("reports the total read through it when finished", func() {
+		out := new(bytes.Buffer)
+
+		progress := NewProgress("uploading fixture", "uploaded fixture", out)
+
+		_, err := ioutil.ReadAll(progress.Reader(strings.NewReader(strings.Repeat("x", 3*1024))))
+		Expect(err).NotTo(HaveOccurred())
+
+		progress.Finish()
+
+		Expect(out.String()).To(MatchRegexp(`uploaded fixture \(3\.0 KiB in \d+\.\ds\)\n$`))
+	})
+
+	It("passes read errors through", func() {
+		progress := NewProgress("downloading some-dir", "downloaded some-dir", ioutil.Discard)
+		defer progress.Stop()
+
+		_, err := ioutil.ReadAll(progress.Reader(iotest.TimeoutReader(strings.NewReader("truncated"))))
+		Expect(err).To(Equal(iotest.ErrTimeout))
+	})
+})
@@ -0,0 +1,145 @@
+package executehelpers
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// the most a limited reader reads at once, so that it sleeps often and
+// briefly rather than bursting
+const maxLimitedRead = 32 * 1024
+
+// Clock is the passage of time as seen by a RateLimiter.
+type Clock interface {
+	Now() time.Time
+	Sleep(time.Duration)
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time        { return time.Now() }
+func (realClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// RateLimiter is a token bucket shared by every reader it limits, so that
+// their combined rate stays under the limit however many are running.
+type RateLimiter struct {
+	bytesPerSecond float64
+	clock          Clock
+
+	lock   sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func NewRateLimiter(bytesPerSecond int64) *RateLimiter {
+	return NewRateLimiterWithClock(bytesPerSecond, realClock{})
+}
+
+func NewRateLimiterWithClock(bytesPerSecond int64, clock Clock) *RateLimiter {
+	return &RateLimiter{
+		bytesPerSecond: float64(bytesPerSecond),
+		clock:          clock,
+		last:           clock.Now(),
+	}
+}
+
+func (limiter *RateLimiter) Reader(src io.Reader) io.Reader {
+	return limitedReader{src: src, limiter: limiter}
+}
+
+// Transport limits both the bodies of requests made through it and those of
+// their responses.
+func (limiter *RateLimiter) Transport(transport http.RoundTripper) http.RoundTripper {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return limitedTransport{transport: transport, limiter: limiter}
+}
+
+// take spends n bytes' worth of tokens, sleeping off any debt. Tokens that
+// build up while idle are capped at a second's worth.
+func (limiter *RateLimiter) take(n int) {
+	limiter.lock.Lock()
+
+	now := limiter.clock.Now()
+
+	limiter.tokens += now.Sub(limiter.last).Seconds() * limiter.bytesPerSecond
+	if limiter.tokens > limiter.bytesPerSecond {
+		limiter.tokens = limiter.bytesPerSecond
+	}
+
+	limiter.last = now
+	limiter.tokens -= float64(n)
+
+	var delay time.Duration
+	if limiter.tokens < 0 {
+		delay = time.Duration(-limiter.tokens / limiter.bytesPerSecond * float64(time.Second))
+	}
+
+	limiter.lock.Unlock()
+
+	if delay > 0 {
+		limiter.clock.Sleep(delay)
+	}
+}
+
+func (limiter *RateLimiter) readSize() int {
+	size := int(limiter.bytesPerSecond / 10)
+	if size > maxLimitedRead {
+		return maxLimitedRead
+	}
+
+	if size < 1 {
+		return 1
+	}
+
+	return size
+}
+
+type limitedReader struct {
+	src     io.Reader
+	limiter *RateLimiter
+}
+
+func (reader limitedReader) Read(p []byte) (int, error) {
+	if size := reader.limiter.readSize(); len(p) > size {
+		p = p[:size]
+	}
+
+	n, err := reader.src.Read(p)
+	if n > 0 {
+		reader.limiter.take(n)
+	}
+
+	return n, err
+}
+
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+type limitedTransport struct {
+	transport http.RoundTripper
+	limiter   *RateLimiter
+}
+
+func (transport limitedTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Body != nil {
+		limited := *request
+		limited.Body = limitedReadCloser{transport.limiter.Reader(request.Body), request.Body}
+		request = &limited
+	}
+
+	response, err := transport.transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	response.Body = limitedReadCloser{transport.limiter.Reader(response.Body), response.Body}
+
+	return response, nil
+}
@@ -0,0 +1,97 @@
+package executehelpers_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	. "github.com/concourse/fly/commands/internal/executehelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeClock only moves when slept or advanced
+type fakeClock struct {
+	lock  sync.Mutex
+	now   time.Time
+	slept time.Duration
+}
+
+func (clock *fakeClock) Now() time.Time {
+	clock.lock.Lock()
+	defer clock.lock.Unlock()
+	return clock.now
+}
+
+func (clock *fakeClock) Sleep(d time.Duration) {
+	clock.lock.Lock()
+	defer clock.lock.Unlock()
+	clock.now = clock.now.Add(d)
+	clock.slept += d
+}
+
+func (clock *fakeClock) Advance(d time.Duration) {
+	clock.lock.Lock()
+	defer clock.lock.Unlock()
+	clock.now = clock.now.Add(d)
+}
+
+var _ = Describe("RateLimiter", func() {
+	var (
+		clock   *fakeClock
+		limiter *RateLimiter
+	)
+
+	BeforeEach(func() {
+		clock = &fakeClock{now: time.Unix(123, 0)}
+		limiter = NewRateLimiterWithClock(1024, clock)
+	})
+
+	It("paces reads to the limit", func() {
+		data := bytes.Repeat([]byte("x"), 10*1024)
+
+		read, err := ioutil.ReadAll(limiter.Reader(bytes.NewReader(data)))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(read).To(Equal(data))
+
+		Expect(clock.slept).To(BeNumerically("~", 10*time.Second, time.Millisecond))
+	})
+
+	It("reads a tenth of a second's worth at a time", func() {
+		n, err := limiter.Reader(bytes.NewReader(make([]byte, 4096))).Read(make([]byte, 4096))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(102))
+	})
+
+	It("limits every reader sharing it in aggregate", func() {
+		a := limiter.Reader(bytes.NewReader(make([]byte, 5*1024)))
+		b := limiter.Reader(bytes.NewReader(make([]byte, 5*1024)))
+
+		buf := make([]byte, 512)
+		total := 0
+		for {
+			nA, errA := a.Read(buf)
+			nB, errB := b.Read(buf)
+			total += nA + nB
+
+			if errA == io.EOF && errB == io.EOF {
+				break
+			}
+		}
+
+		Expect(total).To(Equal(10 * 1024))
+		Expect(clock.slept).To(BeNumerically("~", 10*time.Second, time.Millisecond))
+	})
+
+	It("lets through up to a second's worth saved up while idle", func() {
+		clock.Advance(10 * time.Second)
+
+		_, err := ioutil.ReadAll(limiter.Reader(bytes.NewReader(make([]byte, 3*1024))))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(clock.slept).To(BeNumerically("~", 2*time.Second, time.Millisecond))
+	})
+})
@@ -0,0 +1,117 @@
+package executehelpers
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/apierror"
+	"github.com/concourse/go-concourse/concourse"
+)
+
+const DefaultRetryBackoff = 500 * time.Millisecond
+
+type retryingClient struct {
+	concourse.Client
+
+	attempts int
+	backoff  time.Duration
+	log      io.Writer
+}
+
+// RetryingClient wraps the client such that creating pipes and builds is
+// attempted up to the given number of times while the ATC is briefly
+// unavailable, e.g. behind a load balancer returning 502s. The waits start at
+// backoff and double, with jitter; each retry is logged as a line to log.
+func RetryingClient(client concourse.Client, attempts int, backoff time.Duration, log io.Writer) concourse.Client {
+	return retryingClient{
+		Client:   client,
+		attempts: attempts,
+		backoff:  backoff,
+		log:      log,
+	}
+}
+
+func (client retryingClient) CreatePipe() (atc.Pipe, error) {
+	var pipe atc.Pipe
+
+	err := retry(client.attempts, client.backoff, client.log, "creating pipe", func() error {
+		var err error
+		pipe, err = client.Client.CreatePipe()
+		return err
+	})
+
+	return pipe, err
+}
+
+func (client retryingClient) CreateBuild(plan atc.Plan) (atc.Build, error) {
+	var build atc.Build
+
+	err := retry(client.attempts, client.backoff, client.log, "creating build", func() error {
+		var err error
+		build, err = client.Client.CreateBuild(plan)
+		return err
+	})
+
+	return build, err
+}
+
+// retry makes up to the given number of attempts while they fail transiently
+func retry(attempts int, backoff time.Duration, log io.Writer, doing string, attempt func() error) error {
+	delay := backoff
+
+	for i := 1; ; i++ {
+		err := attempt()
+		if err == nil || i >= attempts || !isTransient(err) {
+			return err
+		}
+
+		fmt.Fprintf(log, "%s failed (%s); retrying (attempt %d of %d)\n", doing, transientReason(err), i+1, attempts)
+
+		time.Sleep(delay + time.Duration(rand.Int63n(int64(delay)/2+1)))
+		delay *= 2
+	}
+}
+
+// isTransient determines whether a failed request is worth retrying: the
+// connection failed or timed out, or a proxy in front of the ATC gave up
+func isTransient(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		_, isNetErr := urlErr.Err.(net.Error)
+		return isNetErr
+	}
+
+	if apiErr, ok := asAPIError(err); ok {
+		for _, status := range []string{"502", "503", "504"} {
+			if strings.HasPrefix(apiErr.Status, status+" ") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func transientReason(err error) string {
+	if apiErr, ok := asAPIError(err); ok {
+		return apiErr.Status
+	}
+
+	return err.Error()
+}
+
+// asAPIError handles both errors returned by the client and those made by
+// apierror.FromResponse
+func asAPIError(err error) (apierror.Error, bool) {
+	if apiErr, ok := err.(apierror.Error); ok {
+		return apiErr, true
+	}
+
+	apiErr, ok := apierror.Wrap("", err).(apierror.Error)
+	return apiErr, ok
+}
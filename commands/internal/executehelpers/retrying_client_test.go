@@ -0,0 +1,92 @@
+package executehelpers_test
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/apierror"
+	"github.com/concourse/go-concourse/concourse"
+
+	. "github.com/concourse/fly/commands/internal/executehelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// flakyClient fails to create pipes with each of its errors in turn
+type flakyClient struct {
+	concourse.Client
+
+	errs  []error
+	calls *int
+}
+
+func (client flakyClient) CreatePipe() (atc.Pipe, error) {
+	call := *client.calls
+	*client.calls++
+
+	if call < len(client.errs) {
+		return atc.Pipe{}, client.errs[call]
+	}
+
+	return atc.Pipe{ID: "some-pipe"}, nil
+}
+
+var _ = Describe("RetryingClient", func() {
+	var (
+		calls int
+		log   *bytes.Buffer
+	)
+
+	badGateway := errors.New("Unexpected Response\nStatus: 502 Bad Gateway\nBody:\n")
+	refused := &url.Error{Op: "Post", URL: "http://atc/api/v1/pipes", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+
+	BeforeEach(func() {
+		calls = 0
+		log = new(bytes.Buffer)
+	})
+
+	createPipe := func(attempts int, errs ...error) (atc.Pipe, error) {
+		client := RetryingClient(flakyClient{errs: errs, calls: &calls}, attempts, time.Millisecond, log)
+		return client.CreatePipe()
+	}
+
+	It("retries gateway errors and failed connections, logging each retry", func() {
+		pipe, err := createPipe(5, badGateway, refused)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pipe.ID).To(Equal("some-pipe"))
+
+		Expect(calls).To(Equal(3))
+		Expect(log.String()).To(Equal("" +
+			"creating pipe failed (502 Bad Gateway); retrying (attempt 2 of 5)\n" +
+			"creating pipe failed (" + refused.Error() + "); retrying (attempt 3 of 5)\n"))
+	})
+
+	It("retries errors describing the ATC's responses", func() {
+		unavailable := apierror.Error{Doing: "uploading bits", Status: "503 Service Unavailable"}
+
+		_, err := createPipe(5, unavailable)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(calls).To(Equal(2))
+		Expect(log.String()).To(Equal("creating pipe failed (503 Service Unavailable); retrying (attempt 2 of 5)\n"))
+	})
+
+	It("gives up after the given number of attempts", func() {
+		_, err := createPipe(2, badGateway, badGateway, badGateway)
+		Expect(err).To(Equal(badGateway))
+		Expect(calls).To(Equal(2))
+	})
+
+	It("does not retry other failures", func() {
+		notFound := errors.New("Unexpected Response\nStatus: 404 Not Found\nBody:\n")
+
+		_, err := createPipe(5, notFound)
+		Expect(err).To(Equal(notFound))
+		Expect(calls).To(Equal(1))
+		Expect(log.String()).To(BeEmpty())
+	})
+})
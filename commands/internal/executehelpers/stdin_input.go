@@ -0,0 +1,39 @@
+package executehelpers
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// SpoolInput writes src to a file named fileName in a new temporary
+// directory, to be uploaded like any other input. Stdin can only be read
+// once, so it's written to disk rather than streamed; this also gives the
+// archive the file's size up front and lets the upload be retried.
+func SpoolInput(src io.Reader, fileName string) (string, error) {
+	dir, err := ioutil.TempDir("", "fly-stdin-input")
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Create(filepath.Join(dir, fileName))
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	_, err = io.Copy(file, src)
+	if err == nil {
+		err = file.Close()
+	} else {
+		file.Close()
+	}
+
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	return dir, nil
+}
@@ -5,56 +5,95 @@ package executehelpers
 import (
 	"bytes"
 	"compress/gzip"
+	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/exec"
 	"strings"
-
-	"github.com/kr/tarutil"
+	"sync"
 )
 
-func tarStreamFrom(workDir string, paths []string) (io.ReadCloser, error) {
-	var archive io.ReadCloser
+func tarStreamFrom(workDir string, paths []string, compressionLevel int) (io.ReadCloser, error) {
+	tarPath, err := exec.LookPath("tar")
+	if err != nil {
+		return nativeTarGZStreamFrom(workDir, paths, nil, false, compressionLevel)
+	}
 
-	if tarPath, err := exec.LookPath("tar"); err == nil {
-		tarCmd := exec.Command(tarPath, "-czf", "-", "--null", "-T", "-")
-		tarCmd.Dir = workDir
-		tarCmd.Stderr = os.Stderr
+	// tar's own -z can't be told how hard to compress, so its output is
+	// gzipped here instead
+	tarCmd := exec.Command(tarPath, tarFlags(tarPath)...)
+	tarCmd.Dir = workDir
+	tarCmd.Stderr = os.Stderr
 
-		tarCmd.Stdin = bytes.NewBufferString(strings.Join(paths, "\x00"))
+	tarCmd.Stdin = bytes.NewBufferString(strings.Join(paths, "\x00"))
 
-		archive, err = tarCmd.StdoutPipe()
-		if err != nil {
-			log.Fatalln("could not create tar pipe:", err)
-		}
+	tarOut, err := tarCmd.StdoutPipe()
+	if err != nil {
+		log.Fatalln("could not create tar pipe:", err)
+	}
 
-		err = tarCmd.Start()
-		if err != nil {
-			log.Fatalln("could not run tar:", err)
-		}
-	} else {
-		return nativeTarGZStreamFrom(workDir, paths)
+	err = tarCmd.Start()
+	if err != nil {
+		log.Fatalln("could not run tar:", err)
 	}
 
-	return archive, nil
-}
+	r, w := io.Pipe()
+
+	go func() {
+		err := gzipTo(w, tarOut, compressionLevel)
+
+		// closing tar's output stops it early if the archive was abandoned
+		tarOut.Close()
+
+		waitErr := tarCmd.Wait()
+		if err == nil && waitErr != nil {
+			err = fmt.Errorf("tar failed: %s", waitErr)
+		}
 
-func tarStreamTo(workDir string, stream io.Reader) error {
-	if tarPath, err := exec.LookPath("tar"); err == nil {
-		tarCmd := exec.Command(tarPath, "-xzf", "-")
-		tarCmd.Dir = workDir
-		tarCmd.Stderr = os.Stderr
+		w.CloseWithError(err)
+	}()
 
-		tarCmd.Stdin = stream
+	return r, nil
+}
 
-		return tarCmd.Run()
+func gzipTo(dst io.Writer, src io.Reader, compressionLevel int) error {
+	gzWriter, err := gzip.NewWriterLevel(dst, compressionLevel)
+	if err != nil {
+		return err
 	}
 
-	gr, err := gzip.NewReader(stream)
+	_, err = io.Copy(gzWriter, src)
 	if err != nil {
 		return err
 	}
 
-	return tarutil.ExtractAll(gr, workDir, tarutil.Chmod|tarutil.Chtimes|tarutil.Symlink)
+	return gzWriter.Close()
+}
+
+var gnuTar struct {
+	once sync.Once
+	is   bool
+}
+
+// tarFlags archives the paths given on stdin. Hard links are archived as
+// links by any tar, but GNU tar only skips the holes in sparse files when
+// told to; bsdtar does so anyway.
+func tarFlags(tarPath string) []string {
+	flags := []string{"-cf", "-", "--null", "-T", "-"}
+
+	gnuTar.once.Do(func() {
+		version, err := exec.Command(tarPath, "--version").Output()
+		gnuTar.is = err == nil && bytes.Contains(version, []byte("GNU tar"))
+	})
+
+	if gnuTar.is {
+		flags = append(flags, "--sparse")
+	}
+
+	return flags
+}
+
+func tarStreamTo(workDir string, stream io.Reader, allowExternalSymlinks bool, log io.Writer) error {
+	return Extract(workDir, stream, allowExternalSymlinks, log)
 }
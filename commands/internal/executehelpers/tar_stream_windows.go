@@ -2,22 +2,12 @@
 
 package executehelpers
 
-import (
-	"compress/gzip"
-	"io"
+import "io"
 
-	"github.com/kr/tarutil"
-)
-
-func tarStreamFrom(workDir string, paths []string) (io.ReadCloser, error) {
-	return nativeTarGZStreamFrom(workDir, paths)
+func tarStreamFrom(workDir string, paths []string, compressionLevel int) (io.ReadCloser, error) {
+	return nativeTarGZStreamFrom(workDir, paths, nil, false, compressionLevel)
 }
 
-func tarStreamTo(workDir string, stream io.Reader) error {
-	gr, err := gzip.NewReader(stream)
-	if err != nil {
-		return err
-	}
-
-	return tarutil.ExtractAll(gr, workDir, tarutil.Chmod|tarutil.Chtimes|tarutil.Symlink)
+func tarStreamTo(workDir string, stream io.Reader, allowExternalSymlinks bool, log io.Writer) error {
+	return Extract(workDir, stream, allowExternalSymlinks, log)
 }
@@ -3,60 +3,216 @@ package executehelpers
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
 	"fmt"
+	"io"
 	"net/http"
-	"os"
+	"net/url"
 	"os/exec"
+	"time"
 
 	"github.com/concourse/atc"
+	"github.com/concourse/fly/apierror"
 	"github.com/concourse/fly/commands/internal/deprecated"
+	"github.com/hashicorp/go-multierror"
 	"github.com/tedsuo/rata"
 )
 
-func Upload(input Input, excludeIgnored bool, atcRequester *deprecated.AtcRequester) {
-	path := input.Path
-	pipe := input.Pipe
+// Upload streams the input to its pipe, making up to attempts attempts while
+// the PUT fails transiently. Each attempt walks the input and archives it
+// afresh, so nothing is buffered no matter how large the input is. Progress
+// is shown through the console, if given.
+func Upload(
+	input Input,
+	excludeIgnored bool,
+	respectGitignore bool,
+	excludes []string,
+	compressionLevel int,
+	console *Console,
+	cancel <-chan struct{},
+	atcRequester *deprecated.AtcRequester,
+	attempts int,
+	backoff time.Duration,
+	log io.Writer,
+) error {
+	var lastManifest manifest
 
-	var files []string
-	var err error
+	err := retry(attempts, backoff, log, "uploading "+input.Name, func() error {
+		files, err := uploadedFiles(input.Path, excludeIgnored, respectGitignore, excludes)
+		if err != nil {
+			return err
+		}
+
+		if attempts > 1 {
+			current, err := manifestOf(input.Path, files, input.Dereference)
+			if err != nil {
+				return fmt.Errorf("could not walk input: %s", err)
+			}
+
+			if lastManifest != nil {
+				if changed := current.changedSince(lastManifest); len(changed) > 0 {
+					fmt.Fprintf(log, "warning: %d file(s) in input '%s' changed since the last attempt, e.g. %s\n", len(changed), input.Name, changed[0])
+				}
+			}
+
+			lastManifest = current
+		}
+
+		return uploadOnce(input, files, compressionLevel, console, cancel, atcRequester)
+	})
 
+	if _, ok := err.(*url.Error); ok {
+		return fmt.Errorf("upload request failed: %s", err)
+	}
+
+	return err
+}
+
+func uploadedFiles(path string, excludeIgnored bool, respectGitignore bool, excludes []string) ([]string, error) {
 	if excludeIgnored {
-		files, err = getGitFiles(path)
+		files, err := getGitFiles(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not determine ignored files: %s", err)
+		}
+
+		files, err = filterExcluded(files, excludes)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, "could not determine ignored files:", err)
-			return
+			return nil, fmt.Errorf("could not determine excluded files: %s", err)
 		}
-	} else {
-		files = []string{"."}
+
+		return files, nil
 	}
 
-	archive, err := tarStreamFrom(path, files)
+	if len(excludes) > 0 || respectGitignore {
+		files, err := WalkFiles(path, excludes, respectGitignore)
+		if err != nil {
+			return nil, fmt.Errorf("could not determine excluded files: %s", err)
+		}
+
+		return files, nil
+	}
+
+	return []string{"."}, nil
+}
+
+func uploadOnce(
+	input Input,
+	files []string,
+	compressionLevel int,
+	console *Console,
+	cancel <-chan struct{},
+	atcRequester *deprecated.AtcRequester,
+) error {
+	archive, err := inputStream(input, files, compressionLevel)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "could create tar stream:", err)
-		return
+		return fmt.Errorf("could not create tar stream: %s", err)
 	}
 
 	defer archive.Close()
 
+	var body io.Reader = archive
+
+	var progress *Progress
+	if console != nil {
+		progress = console.NewProgress("uploading "+input.Name, "uploaded "+input.Name)
+		defer progress.Stop()
+
+		body = progress.Reader(archive)
+	}
+
 	uploadBits, err := atcRequester.CreateRequest(
 		atc.WritePipe,
-		rata.Params{"pipe_id": pipe.ID},
-		archive,
+		rata.Params{"pipe_id": input.Pipe.ID},
+		body,
 	)
 	if err != nil {
-		panic(err)
+		return err
+	}
+
+	uploadBits.Cancel = cancel
+
+	// lets the client follow a 307 or 308 by streaming the input again
+	uploadBits.GetBody = func() (io.ReadCloser, error) {
+		return inputStream(input, files, compressionLevel)
 	}
 
 	response, err := atcRequester.HttpClient.Do(uploadBits)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "upload request failed:", err)
+		select {
+		case <-cancel:
+			// don't retry a request that was canceled on purpose
+			return fmt.Errorf("upload request failed: %s", err)
+		default:
+			return err
+		}
 	}
 
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		fmt.Fprintln(os.Stderr, badResponseError("uploading bits", response))
+		return apierror.FromResponse("uploading bits", response)
+	}
+
+	if progress != nil {
+		progress.Finish()
+	}
+
+	return nil
+}
+
+// inputStream archives the input's files along with anything included in it;
+// only the native writer can add includes or follow symlinks
+func inputStream(input Input, files []string, compressionLevel int) (io.ReadCloser, error) {
+	if len(input.Includes) == 0 && !input.Dereference {
+		return tarStreamFrom(input.Path, files, compressionLevel)
+	}
+
+	return nativeTarGZStreamFrom(input.Path, files, input.Includes, input.Dereference, compressionLevel)
+}
+
+// CompressionLevels maps the names accepted by --compression to gzip levels.
+// The ATC always expects a gzipped archive, so "none" still frames it as gzip.
+var CompressionLevels = map[string]int{
+	"none":    gzip.NoCompression,
+	"fast":    gzip.BestSpeed,
+	"default": gzip.DefaultCompression,
+	"best":    gzip.BestCompression,
+}
+
+// UploadAll runs upload for each of the inputs, at most parallelism at a
+// time, returning all of their errors.
+func UploadAll(inputs []Input, parallelism int, upload func(Input) error) error {
+	if parallelism < 1 {
+		parallelism = 1
 	}
+
+	slots := make(chan struct{}, parallelism)
+
+	errs := make(chan error, len(inputs))
+	for _, input := range inputs {
+		slots <- struct{}{}
+
+		go func(input Input) {
+			defer func() { <-slots }()
+
+			err := upload(input)
+			if err != nil {
+				err = fmt.Errorf("%s: %s", input.Name, err)
+			}
+
+			errs <- err
+		}(input)
+	}
+
+	var uploadErrors error
+	for range inputs {
+		err := <-errs
+		if err != nil {
+			uploadErrors = multierror.Append(uploadErrors, err)
+		}
+	}
+
+	return uploadErrors
 }
 
 func getGitFiles(dir string) ([]string, error) {
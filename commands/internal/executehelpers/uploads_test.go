@@ -0,0 +1,84 @@
+package executehelpers_test
+
+import (
+	"errors"
+	"sync"
+
+	. "github.com/concourse/fly/commands/internal/executehelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UploadAll", func() {
+	var inputs []Input
+
+	BeforeEach(func() {
+		inputs = []Input{
+			{Name: "a", Path: "a"},
+			{Name: "b", Path: "b"},
+			{Name: "c", Path: "c"},
+			{Name: "d", Path: "d"},
+		}
+	})
+
+	It("uploads every input, at most parallelism at a time", func() {
+		var lock sync.Mutex
+		running := 0
+		maxRunning := 0
+		uploaded := []string{}
+
+		release := make(chan struct{})
+
+		done := make(chan error)
+		go func() {
+			done <- UploadAll(inputs, 2, func(input Input) error {
+				lock.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				lock.Unlock()
+
+				<-release
+
+				lock.Lock()
+				running--
+				uploaded = append(uploaded, input.Name)
+				lock.Unlock()
+
+				return nil
+			})
+		}()
+
+		currentlyRunning := func() int {
+			lock.Lock()
+			defer lock.Unlock()
+			return running
+		}
+
+		Eventually(currentlyRunning).Should(Equal(2))
+		Consistently(currentlyRunning).Should(Equal(2))
+
+		for range inputs {
+			release <- struct{}{}
+		}
+
+		Expect(<-done).NotTo(HaveOccurred())
+		Expect(maxRunning).To(Equal(2))
+		Expect(uploaded).To(ConsistOf("a", "b", "c", "d"))
+	})
+
+	It("returns the errors of every failed upload", func() {
+		err := UploadAll(inputs, 4, func(input Input) error {
+			if input.Name == "b" || input.Name == "d" {
+				return errors.New("disaster")
+			}
+
+			return nil
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("b: disaster"))
+		Expect(err.Error()).To(ContainSubstring("d: disaster"))
+	})
+})
@@ -0,0 +1,21 @@
+package flaghelpers
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// matches the ATC's own rules for step names
+var validBuildName = regexp.MustCompile(`^[a-z][a-z0-9\-_.]*$`)
+
+type BuildNameFlag string
+
+func (name *BuildNameFlag) UnmarshalFlag(value string) error {
+	if !validBuildName.MatchString(value) {
+		return fmt.Errorf("invalid build name '%s': must start with a lowercase letter and contain only lowercase letters, digits, '-', '_', and '.'", value)
+	}
+
+	*name = BuildNameFlag(value)
+
+	return nil
+}
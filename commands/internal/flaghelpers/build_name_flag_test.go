@@ -0,0 +1,32 @@
+package flaghelpers_test
+
+import (
+	. "github.com/concourse/fly/commands/internal/flaghelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BuildNameFlag", func() {
+	It("accepts valid names", func() {
+		var name BuildNameFlag
+
+		err := name.UnmarshalFlag("my-experiment_2.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(name).To(Equal(BuildNameFlag("my-experiment_2.0")))
+	})
+
+	It("rejects names that do not start with a lowercase letter", func() {
+		var name BuildNameFlag
+
+		err := name.UnmarshalFlag("2-experiment")
+		Expect(err).To(MatchError(ContainSubstring("invalid build name '2-experiment'")))
+	})
+
+	It("rejects names with other characters", func() {
+		var name BuildNameFlag
+
+		err := name.UnmarshalFlag("My Experiment")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,42 @@
+package flaghelpers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var byteSizeSuffixes = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+}
+
+// ByteSizeFlag is a number of bytes, optionally given in KiB, MiB or GiB,
+// e.g. 2MiB.
+type ByteSizeFlag int64
+
+func (size *ByteSizeFlag) UnmarshalFlag(value string) error {
+	number := value
+	multiplier := int64(1)
+
+	for _, s := range byteSizeSuffixes {
+		if strings.HasSuffix(value, s.suffix) {
+			number = strings.TrimSuffix(value, s.suffix)
+			multiplier = s.multiplier
+			break
+		}
+	}
+
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil || parsed < 0 {
+		return fmt.Errorf("invalid size '%s': must be a number of bytes, optionally followed by KiB, MiB or GiB", value)
+	}
+
+	*size = ByteSizeFlag(parsed * float64(multiplier))
+
+	return nil
+}
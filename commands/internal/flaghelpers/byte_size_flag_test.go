@@ -0,0 +1,43 @@
+package flaghelpers_test
+
+import (
+	. "github.com/concourse/fly/commands/internal/flaghelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ByteSizeFlag", func() {
+	var size ByteSizeFlag
+
+	BeforeEach(func() {
+		size = 0
+	})
+
+	It("accepts a plain number of bytes", func() {
+		err := size.UnmarshalFlag("1500")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(size).To(Equal(ByteSizeFlag(1500)))
+	})
+
+	It("accepts KiB, MiB and GiB", func() {
+		Expect(size.UnmarshalFlag("512KiB")).To(Succeed())
+		Expect(size).To(Equal(ByteSizeFlag(512 * 1024)))
+
+		Expect(size.UnmarshalFlag("2MiB")).To(Succeed())
+		Expect(size).To(Equal(ByteSizeFlag(2 * 1024 * 1024)))
+
+		Expect(size.UnmarshalFlag("1.5GiB")).To(Succeed())
+		Expect(size).To(Equal(ByteSizeFlag(1536 * 1024 * 1024)))
+	})
+
+	It("rejects other suffixes", func() {
+		err := size.UnmarshalFlag("2MB")
+		Expect(err).To(MatchError(ContainSubstring("invalid size '2MB'")))
+	})
+
+	It("rejects negative sizes", func() {
+		err := size.UnmarshalFlag("-1KiB")
+		Expect(err).To(HaveOccurred())
+	})
+})
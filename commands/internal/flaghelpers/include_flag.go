@@ -0,0 +1,49 @@
+package flaghelpers
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IncludeFlag adds a file or directory from outside of an input to it when
+// it's uploaded, under Dest within the input.
+type IncludeFlag struct {
+	Input string
+	Path  string
+	Dest  string
+}
+
+func (include *IncludeFlag) UnmarshalFlag(value string) error {
+	vs := strings.SplitN(value, "=", 2)
+
+	// split on the last colon, so that Windows paths work
+	separator := -1
+	if len(vs) == 2 {
+		separator = strings.LastIndex(vs[1], ":")
+	}
+
+	if separator == -1 || vs[0] == "" || separator == 0 || separator == len(vs[1])-1 {
+		return fmt.Errorf("invalid include '%s' (must be name=path:dest)", value)
+	}
+
+	src, dest := vs[1][:separator], vs[1][separator+1:]
+
+	dest = path.Clean(filepath.ToSlash(dest))
+	if filepath.IsAbs(dest) || strings.HasPrefix(dest, "/") || dest == ".." || strings.HasPrefix(dest, "../") {
+		return fmt.Errorf("invalid include '%s': dest must be a path within the input", value)
+	}
+
+	_, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("invalid include '%s': %s", value, err)
+	}
+
+	include.Input = vs[0]
+	include.Path = src
+	include.Dest = dest
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package flaghelpers_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/concourse/fly/commands/internal/flaghelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IncludeFlag", func() {
+	var flag IncludeFlag
+	var dir string
+
+	BeforeEach(func() {
+		flag = IncludeFlag{}
+
+		var err error
+		dir, err = ioutil.TempDir("", "fly-include")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("parses the input's name, the path to include, and where to put it", func() {
+		err := flag.UnmarshalFlag("some-input=" + dir + ":vendor/extra/")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(flag).To(Equal(IncludeFlag{Input: "some-input", Path: dir, Dest: "vendor/extra"}))
+	})
+
+	It("splits on the last colon, so that Windows paths work", func() {
+		err := flag.UnmarshalFlag("some-input=" + dir + ":.")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(flag.Path).To(Equal(dir))
+		Expect(flag.Dest).To(Equal("."))
+	})
+
+	It("requires all three parts", func() {
+		Expect(flag.UnmarshalFlag("some-input")).To(MatchError("invalid include 'some-input' (must be name=path:dest)"))
+		Expect(flag.UnmarshalFlag("some-input=" + dir)).To(HaveOccurred())
+		Expect(flag.UnmarshalFlag("some-input=" + dir + ":")).To(HaveOccurred())
+		Expect(flag.UnmarshalFlag("=" + dir + ":dest")).To(HaveOccurred())
+	})
+
+	It("rejects destinations outside of the input", func() {
+		for _, dest := range []string{"/abs", "..", "../sibling", "a/../../b"} {
+			err := flag.UnmarshalFlag("some-input=" + dir + ":" + dest)
+			Expect(err).To(MatchError(ContainSubstring("dest must be a path within the input")))
+		}
+	})
+
+	It("rejects paths that don't exist", func() {
+		err := flag.UnmarshalFlag("some-input=" + filepath.Join(dir, "bogus") + ":dest")
+		Expect(err).To(MatchError(ContainSubstring("invalid include")))
+	})
+})
@@ -9,29 +9,72 @@ import (
 type InputPairFlag struct {
 	Name string
 	Path string
+
+	// set instead of Path when the input is a remote git repository
+	URI string
+	Ref string
+
+	// archive what symlinks point to rather than the links, given as
+	// name=path!follow
+	Dereference bool
 }
 
+const followSuffix = "!follow"
+
 func (pair *InputPairFlag) UnmarshalFlag(value string) error {
 	vs := strings.SplitN(value, "=", 2)
 	if len(vs) != 2 {
 		return fmt.Errorf("invalid input pair '%s' (must be name=path)", value)
 	}
 
-	matches, err := filepath.Glob(vs[1])
+	if isRemoteURI(vs[1]) {
+		uri := vs[1]
+		ref := ""
+
+		if i := strings.LastIndex(uri, "#"); i != -1 {
+			uri, ref = uri[:i], uri[i+1:]
+		}
+
+		pair.Name = vs[0]
+		pair.URI = uri
+		pair.Ref = ref
+
+		return nil
+	}
+
+	path := vs[1]
+
+	dereference := strings.HasSuffix(path, followSuffix)
+	if dereference {
+		path = strings.TrimSuffix(path, followSuffix)
+	}
+
+	matches, err := filepath.Glob(path)
 	if err != nil {
-		return fmt.Errorf("failed to expand path '%s': %s", vs[1], err)
+		return fmt.Errorf("failed to expand path '%s': %s", path, err)
 	}
 
 	if len(matches) == 0 {
-		return fmt.Errorf("path '%s' does not exist", vs[1])
+		return fmt.Errorf("path '%s' does not exist", path)
 	}
 
 	if len(matches) > 1 {
-		return fmt.Errorf("path '%s' resolves to multiple entries: %s", vs[1], strings.Join(matches, ", "))
+		return fmt.Errorf("path '%s' resolves to multiple entries: %s", path, strings.Join(matches, ", "))
 	}
 
 	pair.Name = vs[0]
 	pair.Path = matches[0]
+	pair.Dereference = dereference
 
 	return nil
 }
+
+func isRemoteURI(value string) bool {
+	for _, prefix := range []string{"http://", "https://", "ssh://", "git://", "git@"} {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
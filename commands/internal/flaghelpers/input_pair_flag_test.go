@@ -0,0 +1,56 @@
+package flaghelpers_test
+
+import (
+	. "github.com/concourse/fly/commands/internal/flaghelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("InputPairFlag", func() {
+	var flag *InputPairFlag
+
+	BeforeEach(func() {
+		flag = &InputPairFlag{}
+	})
+
+	It("resolves local paths", func() {
+		err := flag.UnmarshalFlag("fixture=.")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(flag.Name).To(Equal("fixture"))
+		Expect(flag.Path).To(Equal("."))
+		Expect(flag.URI).To(BeEmpty())
+		Expect(flag.Dereference).To(BeFalse())
+	})
+
+	It("follows symlinks in paths ending in !follow", func() {
+		err := flag.UnmarshalFlag("fixture=.!follow")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(flag.Name).To(Equal("fixture"))
+		Expect(flag.Path).To(Equal("."))
+		Expect(flag.Dereference).To(BeTrue())
+	})
+
+	It("errors when the local path does not exist", func() {
+		err := flag.UnmarshalFlag("fixture=bogus-path")
+		Expect(err).To(MatchError("path 'bogus-path' does not exist"))
+	})
+
+	Context("when the value is a git URL", func() {
+		It("parses https URLs", func() {
+			err := flag.UnmarshalFlag("repo=https://github.com/foo/bar.git")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(flag.Name).To(Equal("repo"))
+			Expect(flag.URI).To(Equal("https://github.com/foo/bar.git"))
+			Expect(flag.Ref).To(BeEmpty())
+			Expect(flag.Path).To(BeEmpty())
+		})
+
+		It("parses ssh URLs with a ref", func() {
+			err := flag.UnmarshalFlag("repo=git@github.com:foo/bar.git#develop")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(flag.URI).To(Equal("git@github.com:foo/bar.git"))
+			Expect(flag.Ref).To(Equal("develop"))
+		})
+	})
+})
@@ -1,27 +1,18 @@
 package flaghelpers
 
-import (
-	"strings"
-
-	"github.com/concourse/go-concourse/concourse"
-)
-
 type JobFlag struct {
 	PipelineName string
 	JobName      string
 }
 
 func (job *JobFlag) UnmarshalFlag(value string) error {
-	vs := strings.SplitN(value, "/", 2)
-	if vs[0] == "" {
-		return concourse.NameRequiredError("pipeline")
-	}
-	if vs[1] == "" {
-		return concourse.NameRequiredError("job")
+	pipelineName, jobName, err := splitPipelinePath(value, "job")
+	if err != nil {
+		return err
 	}
 
-	job.PipelineName = vs[0]
-	job.JobName = vs[1]
+	job.PipelineName = pipelineName
+	job.JobName = jobName
 
 	return nil
 }
@@ -0,0 +1,52 @@
+package flaghelpers_test
+
+import (
+	. "github.com/concourse/fly/commands/internal/flaghelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JobFlag", func() {
+	var jobFlag *JobFlag
+
+	BeforeEach(func() {
+		jobFlag = &JobFlag{}
+	})
+
+	It("splits the pipeline and job names", func() {
+		err := jobFlag.UnmarshalFlag("some-pipeline/some-job")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(jobFlag.PipelineName).To(Equal("some-pipeline"))
+		Expect(jobFlag.JobName).To(Equal("some-job"))
+	})
+
+	Context("when there is only a pipeline specified", func() {
+		It("displays an error message", func() {
+			err := jobFlag.UnmarshalFlag("pipeline")
+			Expect(err).To(MatchError("argument format should be <pipeline>/<job>"))
+		})
+	})
+
+	Context("when there is more than one slash", func() {
+		It("displays an error message", func() {
+			err := jobFlag.UnmarshalFlag("pipeline/job/extra")
+			Expect(err).To(MatchError("argument format should be <pipeline>/<job>"))
+		})
+	})
+
+	Context("when the pipeline name is empty", func() {
+		It("says the pipeline name is required", func() {
+			err := jobFlag.UnmarshalFlag("/job")
+			Expect(err).To(MatchError("pipeline name required"))
+		})
+	})
+
+	Context("when the job name is empty", func() {
+		It("says the job name is required", func() {
+			err := jobFlag.UnmarshalFlag("pipeline/")
+			Expect(err).To(MatchError("job name required"))
+		})
+	})
+})
@@ -2,8 +2,11 @@ package flaghelpers
 
 import (
 	"fmt"
+	"io/ioutil"
 	"path/filepath"
 	"strings"
+
+	"github.com/concourse/fly/internal/similarity"
 )
 
 type PathFlag string
@@ -19,6 +22,11 @@ func (path *PathFlag) UnmarshalFlag(value string) error {
 	}
 
 	if len(matches) == 0 {
+		suggestions := similarPaths(value)
+		if len(suggestions) > 0 {
+			return fmt.Errorf("path '%s' does not exist; did you mean: %s", value, strings.Join(suggestions, ", "))
+		}
+
 		return fmt.Errorf("path '%s' does not exist", value)
 	}
 
@@ -29,3 +37,29 @@ func (path *PathFlag) UnmarshalFlag(value string) error {
 	*path = PathFlag(matches[0])
 	return nil
 }
+
+// similarPaths suggests entries alongside a mistyped path, i.e. those it is a
+// prefix of or which are only a couple of edits away
+func similarPaths(value string) []string {
+	dir, base := filepath.Split(value)
+
+	lookIn := dir
+	if lookIn == "" {
+		lookIn = "."
+	}
+
+	entries, err := ioutil.ReadDir(lookIn)
+	if err != nil {
+		return nil
+	}
+
+	suggestions := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, base) || similarity.EditDistance(name, base) <= 2 {
+			suggestions = append(suggestions, dir+name)
+		}
+	}
+
+	return suggestions
+}
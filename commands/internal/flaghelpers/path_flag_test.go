@@ -0,0 +1,49 @@
+package flaghelpers_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/concourse/fly/commands/internal/flaghelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PathFlag", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "fly-path-flag")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(dir, "unit.yml"), []byte{}, 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(dir, "integration.yml"), []byte{}, 0644)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("resolves existing paths", func() {
+		var path PathFlag
+
+		err := path.UnmarshalFlag(filepath.Join(dir, "unit.yml"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(path)).To(Equal(filepath.Join(dir, "unit.yml")))
+	})
+
+	It("suggests similar paths when the path does not exist", func() {
+		var path PathFlag
+
+		missing := filepath.Join(dir, "unit")
+
+		err := path.UnmarshalFlag(missing)
+		Expect(err).To(MatchError("path '" + missing + "' does not exist; did you mean: " + filepath.Join(dir, "unit.yml")))
+	})
+})
@@ -0,0 +1,28 @@
+package flaghelpers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/concourse/go-concourse/concourse"
+)
+
+// splitPipelinePath splits a <pipeline>/<thing> argument, e.g. a job or a
+// resource, requiring exactly one slash and a name either side of it
+func splitPipelinePath(value string, thing string) (string, string, error) {
+	vs := strings.Split(value, "/")
+
+	if len(vs) != 2 {
+		return "", "", fmt.Errorf("argument format should be <pipeline>/<%s>", thing)
+	}
+
+	if vs[0] == "" {
+		return "", "", concourse.NameRequiredError("pipeline")
+	}
+
+	if vs[1] == "" {
+		return "", "", concourse.NameRequiredError(thing)
+	}
+
+	return vs[0], vs[1], nil
+}
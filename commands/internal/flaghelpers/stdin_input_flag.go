@@ -0,0 +1,28 @@
+package flaghelpers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StdinInputFlag names an input made of a single file read from stdin.
+type StdinInputFlag struct {
+	Name     string
+	FileName string
+}
+
+func (pair *StdinInputFlag) UnmarshalFlag(value string) error {
+	vs := strings.SplitN(value, "=", 2)
+	if len(vs) != 2 || vs[0] == "" || vs[1] == "" {
+		return fmt.Errorf("invalid stdin input '%s' (must be name=file)", value)
+	}
+
+	if strings.ContainsAny(vs[1], `/\`) || vs[1] == "." || vs[1] == ".." {
+		return fmt.Errorf("invalid stdin input '%s': the file must be named without a directory", value)
+	}
+
+	pair.Name = vs[0]
+	pair.FileName = vs[1]
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package flaghelpers_test
+
+import (
+	. "github.com/concourse/fly/commands/internal/flaghelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StdinInputFlag", func() {
+	var flag StdinInputFlag
+
+	BeforeEach(func() {
+		flag = StdinInputFlag{}
+	})
+
+	It("parses the input's name and its file's name", func() {
+		err := flag.UnmarshalFlag("data=input.csv")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(flag).To(Equal(StdinInputFlag{Name: "data", FileName: "input.csv"}))
+	})
+
+	It("requires both names", func() {
+		Expect(flag.UnmarshalFlag("data")).To(MatchError("invalid stdin input 'data' (must be name=file)"))
+		Expect(flag.UnmarshalFlag("data=")).To(HaveOccurred())
+		Expect(flag.UnmarshalFlag("=input.csv")).To(HaveOccurred())
+	})
+
+	It("rejects file names with directories", func() {
+		err := flag.UnmarshalFlag("data=some/input.csv")
+		Expect(err).To(MatchError(ContainSubstring("the file must be named without a directory")))
+	})
+})
@@ -0,0 +1,20 @@
+package flaghelpers
+
+// TaskConfigFlag is a path to a task config, or - to read it from stdin
+type TaskConfigFlag string
+
+func (config *TaskConfigFlag) UnmarshalFlag(value string) error {
+	if value == "-" {
+		*config = TaskConfigFlag(value)
+		return nil
+	}
+
+	var path PathFlag
+	err := path.UnmarshalFlag(value)
+	if err != nil {
+		return err
+	}
+
+	*config = TaskConfigFlag(path)
+	return nil
+}
@@ -0,0 +1,54 @@
+package flaghelpers_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/concourse/fly/commands/internal/flaghelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TaskConfigFlag", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "fly-task-config-flag")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(dir, "task.yml"), []byte{}, 0644)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("resolves existing paths", func() {
+		var config TaskConfigFlag
+
+		err := config.UnmarshalFlag(filepath.Join(dir, "task.yml"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(config)).To(Equal(filepath.Join(dir, "task.yml")))
+	})
+
+	It("passes - through to stand for stdin", func() {
+		var config TaskConfigFlag
+
+		err := config.UnmarshalFlag("-")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(config)).To(Equal("-"))
+	})
+
+	It("rejects paths that do not exist", func() {
+		var config TaskConfigFlag
+
+		missing := filepath.Join(dir, "bogus.yml")
+
+		err := config.UnmarshalFlag(missing)
+		Expect(err).To(MatchError("path '" + missing + "' does not exist"))
+	})
+})
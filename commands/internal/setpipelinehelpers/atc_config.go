@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/concourse/atc"
 	"github.com/concourse/atc/web"
@@ -58,11 +59,30 @@ func (atcConfig ATCConfig) Set(configPath flaghelpers.PathFlag, templateVariable
 		newConfig,
 	)
 	if err != nil {
+		if atcConfig.changedSince(existingConfigVersion) {
+			displayhelpers.Failf("failed to update configuration: pipeline '%s' was changed by someone else since its config was fetched; run set-pipeline again to review the new diff", atcConfig.PipelineName)
+		}
+
+		if strings.Contains(err.Error(), "400 Bad Request") {
+			displayhelpers.FailWithErrorf("configuration is invalid", err)
+		}
+
 		displayhelpers.FailWithErrorf("failed to update configuration", err)
 	}
 	atcConfig.showHelpfulMessage(created, updated)
 }
 
+// changedSince reports whether the pipeline's config has moved on from the
+// given version, i.e. whether a failed update was a conflict.
+func (atcConfig ATCConfig) changedSince(version string) bool {
+	_, currentVersion, _, err := atcConfig.Client.PipelineConfig(atcConfig.PipelineName)
+	if err != nil {
+		return false
+	}
+
+	return currentVersion != version
+}
+
 func (atcConfig ATCConfig) newConfig(configPath flaghelpers.PathFlag, templateVariablesFiles []flaghelpers.PathFlag, templateVariables template.Variables) atc.Config {
 	configFile, err := ioutil.ReadFile(string(configPath))
 	if err != nil {
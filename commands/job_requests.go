@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/apierror"
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/go-concourse/concourse"
+	"github.com/tedsuo/rata"
+)
+
+// sendJobRequest makes a request to one of the job's API routes, returning
+// the response only if it succeeded
+func sendJobRequest(connection concourse.Connection, route string, job flaghelpers.JobFlag) (*http.Response, error) {
+	requestGenerator := rata.NewRequestGenerator(connection.URL(), atc.Routes)
+
+	request, err := requestGenerator.CreateRequest(route, rata.Params{
+		"pipeline_name": job.PipelineName,
+		"job_name":      job.JobName,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := connection.HTTPClient().Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return response, nil
+	}
+
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusUnauthorized:
+		return nil, concourse.ErrUnauthorized
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("job '%s/%s' not found", job.PipelineName, job.JobName)
+	}
+
+	return nil, apierror.FromResponse(fmt.Sprintf("requesting job '%s/%s'", job.PipelineName, job.JobName), response)
+}
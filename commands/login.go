@@ -1,31 +1,52 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/concourse/atc"
+	"github.com/concourse/fly/commands/internal/flaghelpers"
 	"github.com/concourse/fly/rc"
 	"github.com/concourse/go-concourse/concourse"
 	"github.com/vito/go-interact/interact"
 )
 
 type LoginCommand struct {
-	ATCURL   string `short:"c" long:"concourse-url" description:"Concourse URL to authenticate with"`
-	Insecure bool   `short:"k" long:"insecure" description:"Skip verification of the endpoint's SSL certificate"`
+	ATCURL     string               `short:"c" long:"concourse-url" description:"Concourse URL to authenticate with"`
+	Insecure   bool                 `short:"k" long:"insecure" description:"Skip verification of the endpoint's SSL certificate"`
+	CACert     flaghelpers.PathFlag `long:"ca-cert" description:"Path to the CA certificate to verify the endpoint's SSL certificate with"`
+	ClientCert flaghelpers.PathFlag `long:"client-cert" description:"Path to the certificate to present to an endpoint that requires one"`
+	ClientKey  flaghelpers.PathFlag `long:"client-key" description:"Path to the private key of the client certificate"`
+	Username   string               `short:"u" long:"username" description:"Username for basic auth, instead of being prompted for it"`
+	Password   string               `short:"p" long:"password" description:"Password for basic auth, instead of being prompted for it"`
+
+	NoBrowser    bool          `long:"no-browser" description:"For OAuth, print the login URL and read the token from stdin instead of receiving it from the browser"`
+	OAuthTimeout time.Duration `long:"oauth-timeout" description:"How long to wait for the browser to complete an OAuth login (default: 5m)"`
 }
 
 func (command *LoginCommand) Execute(args []string) error {
 	var connection concourse.Connection
 	var err error
 
-	if command.ATCURL != "" {
-		connection, err = rc.NewConnection(command.ATCURL, command.Insecure)
-	} else {
-		connection, err = rc.CommandTargetConnection(Fly.Target, &command.Insecure)
+	if Fly.Target == "" {
+		return rc.NoTargetError()
 	}
 
+	target, err := command.target()
+	if err != nil {
+		return err
+	}
+
+	if command.Insecure {
+		fmt.Fprintf(os.Stderr, "warning: the SSL certificate of target '%s' will not be verified by this or later commands\n", Fly.Target)
+	}
+
+	connection, err = rc.NewConnection(target, Fly.Proxy)
 	if err != nil {
 		return err
 	}
@@ -38,16 +59,22 @@ func (command *LoginCommand) Execute(args []string) error {
 	}
 
 	var chosenMethod atc.AuthMethod
+
+	// credentials on the command line can only be for basic auth
+	if command.Username != "" || command.Password != "" {
+		for _, method := range authMethods {
+			if method.Type == atc.AuthTypeBasic {
+				return command.loginWith(method, connection, target)
+			}
+		}
+
+		return errors.New("basic auth is not configured for this target")
+	}
+
 	switch len(authMethods) {
 	case 0:
 		fmt.Println("no auth methods configured; updating target data")
-		err := rc.SaveTarget(
-			Fly.Target,
-			connection.URL(),
-			command.Insecure,
-			&rc.TargetToken{},
-		)
-
+		err := command.saveTarget(connection, target, &rc.TargetToken{})
 		if err != nil {
 			return err
 		}
@@ -69,53 +96,48 @@ func (command *LoginCommand) Execute(args []string) error {
 		}
 	}
 
-	return command.loginWith(chosenMethod, connection)
+	return command.loginWith(chosenMethod, connection, target)
 }
 
-func (command *LoginCommand) loginWith(method atc.AuthMethod, connection concourse.Connection) error {
-	var token atc.AuthToken
+func (command *LoginCommand) loginWith(method atc.AuthMethod, connection concourse.Connection, target rc.TargetProps) error {
+	var token rc.TargetToken
 
 	switch method.Type {
 	case atc.AuthTypeOAuth:
-		fmt.Println("navigate to the following URL in your browser:")
-		fmt.Println("")
-		fmt.Printf("    %s\n", method.AuthURL)
-		fmt.Println("")
-
-		for {
-			var tokenStr string
-
-			err := interact.NewInteraction("enter token").Resolve(interact.Required(&tokenStr))
-			if err != nil {
-				return err
+		var err error
+		if command.NoBrowser {
+			token, err = enterOAuthToken(method.AuthURL)
+		} else {
+			timeout := command.OAuthTimeout
+			if timeout == 0 {
+				timeout = defaultOAuthTimeout
 			}
 
-			segments := strings.SplitN(tokenStr, " ", 2)
-			if len(segments) != 2 {
-				fmt.Println("token must be of the format 'TYPE VALUE', e.g. 'Bearer ...'")
-				continue
-			}
-
-			token.Type = segments[0]
-			token.Value = segments[1]
-
-			break
+			token, err = receiveOAuthToken(method.AuthURL, timeout)
 		}
 
-	case atc.AuthTypeBasic:
-		var username string
-		err := interact.NewInteraction("username").Resolve(interact.Required(&username))
 		if err != nil {
 			return err
 		}
 
-		var password interact.Password
-		err = interact.NewInteraction("password").Resolve(interact.Required(&password))
-		if err != nil {
-			return err
+	case atc.AuthTypeBasic:
+		username := command.Username
+		if username == "" {
+			err := interact.NewInteraction("username").Resolve(interact.Required(&username))
+			if err != nil {
+				return err
+			}
 		}
 
-		newUnauthedClient, err := rc.NewConnection(connection.URL(), command.Insecure)
+		password := interact.Password(command.Password)
+		if password == "" {
+			err := interact.NewInteraction("password").Resolve(interact.Required(&password))
+			if err != nil {
+				return err
+			}
+		}
+
+		newUnauthedClient, err := rc.NewConnection(target, Fly.Proxy)
 		if err != nil {
 			return err
 		}
@@ -136,28 +158,108 @@ func (command *LoginCommand) loginWith(method atc.AuthMethod, connection concour
 
 		client := concourse.NewClient(basicAuthClient)
 
-		token, err = client.AuthToken()
+		authToken, err := client.AuthToken()
 		if err != nil {
 			return err
 		}
+
+		token = rc.TargetToken{
+			Type:  authToken.Type,
+			Value: authToken.Value,
+		}
+	}
+
+	err := command.saveTarget(connection, target, &token)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("token saved")
+
+	return nil
+}
+
+// target is the target to log in to as it is saved, with the URL and any
+// TLS settings given to the command taking precedence
+func (command *LoginCommand) target() (rc.TargetProps, error) {
+	var target rc.TargetProps
+
+	if command.ATCURL != "" {
+		targets, err := rc.LoadTargets()
+		if err != nil {
+			return rc.TargetProps{}, err
+		}
+
+		if !strings.Contains(command.ATCURL, "://") {
+			fmt.Fprintf(os.Stderr, "warning: no scheme given for %s; assuming https\n", command.ATCURL)
+		}
+
+		api, err := rc.NormalizeURL(command.ATCURL)
+		if err != nil {
+			return rc.TargetProps{}, err
+		}
+
+		target = targets[Fly.Target]
+		target.API = api
+	} else {
+		var err error
+		target, err = rc.SelectTarget(Fly.Target)
+		if err != nil {
+			return rc.TargetProps{}, err
+		}
+	}
+
+	target.Insecure = command.Insecure
+	target.Token = nil
+
+	if command.CACert != "" {
+		caCert, err := ioutil.ReadFile(string(command.CACert))
+		if err != nil {
+			return rc.TargetProps{}, fmt.Errorf("failed to read CA certificate: %s", err)
+		}
+
+		target.CACert = string(caCert)
 	}
 
+	if command.ClientCert != "" || command.ClientKey != "" {
+		if command.ClientCert == "" || command.ClientKey == "" {
+			return rc.TargetProps{}, errors.New("--client-cert and --client-key must be given together")
+		}
+
+		clientCert, err := ioutil.ReadFile(string(command.ClientCert))
+		if err != nil {
+			return rc.TargetProps{}, fmt.Errorf("failed to read client certificate: %s", err)
+		}
+
+		clientKey, err := ioutil.ReadFile(string(command.ClientKey))
+		if err != nil {
+			return rc.TargetProps{}, fmt.Errorf("failed to read client key: %s", err)
+		}
+
+		target.ClientCert = string(clientCert)
+		target.ClientKey = string(clientKey)
+	}
+
+	return target, nil
+}
+
+func (command *LoginCommand) saveTarget(connection concourse.Connection, target rc.TargetProps, token *rc.TargetToken) error {
 	err := rc.SaveTarget(
 		Fly.Target,
 		connection.URL(),
 		command.Insecure,
-		&rc.TargetToken{
-			Type:  token.Type,
-			Value: token.Value,
-		},
+		token,
+		target.CACert,
 	)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("token saved")
+	if command.ClientCert == "" {
+		return nil
+	}
 
-	return nil
+	return rc.SaveClientCertificate(Fly.Target, target.ClientCert, target.ClientKey)
 }
 
 type basicAuthTransport struct {
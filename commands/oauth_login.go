@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/concourse/fly/rc"
+	"github.com/vito/go-interact/interact"
+)
+
+const (
+	oauthCallbackPath   = "/oauth/callback"
+	defaultOAuthTimeout = 5 * time.Minute
+)
+
+var errBadTokenFormat = errors.New("token must be of the format 'TYPE VALUE', e.g. 'Bearer ...'")
+
+// enterOAuthToken has the user log in with their browser and paste the
+// resulting token, for machines where fly can't receive it itself
+func enterOAuthToken(authURL string) (rc.TargetToken, error) {
+	fmt.Println("navigate to the following URL in your browser:")
+	fmt.Println("")
+	fmt.Printf("    %s\n", authURL)
+	fmt.Println("")
+
+	for {
+		var tokenStr string
+
+		err := interact.NewInteraction("enter token").Resolve(interact.Required(&tokenStr))
+		if err != nil {
+			return rc.TargetToken{}, err
+		}
+
+		token, err := parseToken(tokenStr)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+
+		return token, nil
+	}
+}
+
+// receiveOAuthToken listens on an ephemeral local port for the ATC to
+// redirect the browser back to once the user has logged in
+func receiveOAuthToken(authURL string, timeout time.Duration) (rc.TargetToken, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return rc.TargetToken{}, fmt.Errorf("failed to listen for the OAuth callback: %s", err)
+	}
+
+	// closing the listener stops the server below
+	defer listener.Close()
+
+	state, err := oauthState()
+	if err != nil {
+		return rc.TargetToken{}, err
+	}
+
+	loginURL, err := oauthLoginURL(authURL, listener.Addr().(*net.TCPAddr).Port, state)
+	if err != nil {
+		return rc.TargetToken{}, err
+	}
+
+	// only the first valid callback is taken; the channel is buffered so the
+	// handler never blocks once nothing is waiting on it
+	tokens := make(chan rc.TargetToken, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(oauthCallbackPath, func(w http.ResponseWriter, r *http.Request) {
+		// anything on this machine could call back, so only the ATC that was
+		// given the state is believed
+		callbackState := r.URL.Query().Get("fly_state")
+		if subtle.ConstantTimeCompare([]byte(callbackState), []byte(state)) != 1 {
+			http.Error(w, "invalid state", http.StatusForbidden)
+			return
+		}
+
+		token, err := parseToken(r.URL.Query().Get("token"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// ATCs that issue refresh tokens pass them along too
+		token.RefreshToken = r.URL.Query().Get("refresh_token")
+
+		if expiresIn, err := strconv.ParseInt(r.URL.Query().Get("expires_in"), 10, 64); err == nil {
+			token.Expiry = time.Now().Add(time.Duration(expiresIn) * time.Second).Unix()
+		}
+
+		select {
+		case tokens <- token:
+			fmt.Fprintln(w, "you have been logged in; you may now close this window")
+		default:
+			http.Error(w, "fly has already received a token", http.StatusConflict)
+		}
+	})
+
+	go http.Serve(listener, mux)
+
+	fmt.Println("navigate to the following URL in your browser:")
+	fmt.Println("")
+	fmt.Printf("    %s\n", loginURL)
+	fmt.Println("")
+
+	openBrowser(loginURL)
+
+	fmt.Println("waiting for the login to complete...")
+
+	select {
+	case token := <-tokens:
+		return token, nil
+	case <-time.After(timeout):
+		return rc.TargetToken{}, fmt.Errorf("timed out after %s waiting for the OAuth login; try again, or use --no-browser", timeout)
+	}
+}
+
+// oauthState is a nonce for the ATC to pass back with the token
+func oauthState() (string, error) {
+	nonce := make([]byte, 16)
+
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate OAuth state: %s", err)
+	}
+
+	return hex.EncodeToString(nonce), nil
+}
+
+// oauthLoginURL tells the ATC where to send the browser once logged in, and
+// the state to send back with it
+func oauthLoginURL(authURL string, port int, state string) (string, error) {
+	loginURL, err := url.Parse(authURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid auth URL '%s': %s", authURL, err)
+	}
+
+	query := loginURL.Query()
+	query.Set("fly_local_port", strconv.Itoa(port))
+	query.Set("fly_state", state)
+	loginURL.RawQuery = query.Encode()
+
+	return loginURL.String(), nil
+}
+
+func parseToken(tokenStr string) (rc.TargetToken, error) {
+	segments := strings.SplitN(tokenStr, " ", 2)
+	if len(segments) != 2 {
+		return rc.TargetToken{}, errBadTokenFormat
+	}
+
+	return rc.TargetToken{Type: segments[0], Value: segments[1]}, nil
+}
+
+// the URL has been printed, so failing to open a browser is not an error
+func openBrowser(url string) {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+
+	if cmd.Start() == nil {
+		go cmd.Wait()
+	}
+}
@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+)
+
+type PauseJobCommand struct {
+	Job flaghelpers.JobFlag `short:"j" long:"job" required:"true" value-name:"PIPELINE/JOB" description:"Job to pause"`
+}
+
+func (command *PauseJobCommand) Execute(args []string) error {
+	connection, err := targetConnection()
+	if err != nil {
+		log.Fatalln(err)
+		return nil
+	}
+
+	response, err := sendJobRequest(connection, atc.PauseJob, command.Job)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	response.Body.Close()
+
+	fmt.Printf("paused '%s/%s'\n", command.Job.PipelineName, command.Job.JobName)
+
+	return nil
+}
@@ -1,19 +1,21 @@
 package commands
 
 import (
+	"encoding/json"
 	"log"
 	"os"
 
-	"github.com/concourse/fly/rc"
+	"github.com/concourse/atc"
 	"github.com/concourse/fly/ui"
 	"github.com/concourse/go-concourse/concourse"
-	"github.com/fatih/color"
 )
 
-type PipelinesCommand struct{}
+type PipelinesCommand struct {
+	JSON bool `long:"json" description:"Print the pipelines as returned by the API, as JSON"`
+}
 
 func (command *PipelinesCommand) Execute([]string) error {
-	connection, err := rc.TargetConnection(Fly.Target)
+	connection, err := targetConnection()
 	if err != nil {
 		log.Fatalln(err)
 		return nil
@@ -26,27 +28,36 @@ func (command *PipelinesCommand) Execute([]string) error {
 		log.Fatalln(err)
 	}
 
+	if command.JSON {
+		return json.NewEncoder(os.Stdout).Encode(pipelines)
+	}
+
 	table := ui.Table{
-		Headers: ui.TableRow{
-			{Contents: "name", Color: color.New(color.Bold)},
-			{Contents: "paused", Color: color.New(color.Bold)},
-		},
+		Headers: ui.Headers("name", "paused", "public"),
 	}
 
 	for _, p := range pipelines {
-		var pausedColumn ui.TableCell
-		if p.Paused {
-			pausedColumn.Contents = "yes"
-			pausedColumn.Color = color.New(color.FgCyan)
-		} else {
-			pausedColumn.Contents = "no"
-		}
-
-		table.Data = append(table.Data, []ui.TableCell{
+		table.Data = append(table.Data, ui.TableRow{
 			{Contents: p.Name},
-			pausedColumn,
+			ui.YesNo(p.Paused),
+			ui.YesNo(p.Public),
 		})
 	}
 
 	return table.Render(os.Stdout)
 }
+
+func findPipeline(client concourse.Client, name string) (atc.Pipeline, bool, error) {
+	pipelines, err := client.ListPipelines()
+	if err != nil {
+		return atc.Pipeline{}, false, err
+	}
+
+	for _, pipeline := range pipelines {
+		if pipeline.Name == name {
+			return pipeline, true, nil
+		}
+	}
+
+	return atc.Pipeline{}, false, nil
+}
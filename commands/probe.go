@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/concourse/fly/rc"
+	"github.com/concourse/go-concourse/concourse"
+)
+
+const infoPath = "/api/v1/info"
+
+// atcInfo is what the ATC reports about itself
+type atcInfo struct {
+	Version string `json:"version"`
+}
+
+// fetchInfo asks the ATC about itself; an error means it could not be
+// reached. ATCs without the info endpoint report no version.
+func fetchInfo(connection concourse.Connection) (atcInfo, error) {
+	var info atcInfo
+
+	resp, err := connection.HTTPClient().Get(connection.URL() + infoPath)
+	if err != nil {
+		return info, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return info, fmt.Errorf("unexpected response from %s: %s", connection.URL(), resp.Status)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return info, nil
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	if err != nil {
+		return info, fmt.Errorf("invalid response from %s: %s", connection.URL(), err)
+	}
+
+	return info, nil
+}
+
+var errTokenRejected = errors.New("token rejected")
+
+// probeToken checks that the target accepts the token, returning
+// errTokenRejected if not; an expired token that can't be refreshed is
+// rejected without asking the ATC
+func probeToken(client concourse.Client, token *rc.TargetToken) error {
+	if token != nil {
+		if expiry, ok := token.ExpiresAt(); ok && expiry.Before(time.Now()) && token.RefreshToken == "" {
+			return errTokenRejected
+		}
+	}
+
+	_, err := client.ListWorkers()
+	if err == concourse.ErrUnauthorized {
+		return errTokenRejected
+	}
+
+	return err
+}
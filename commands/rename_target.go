@@ -0,0 +1,24 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/concourse/fly/rc"
+)
+
+type RenameTargetCommand struct {
+	Old   string `short:"o" long:"old-name" required:"true" description:"Current name of the target"`
+	New   string `short:"n" long:"new-name" required:"true" description:"New name for the target"`
+	Force bool   `          long:"force"                    description:"Replace any existing target with the new name"`
+}
+
+func (command *RenameTargetCommand) Execute(args []string) error {
+	err := rc.RenameTarget(command.Old, command.New, command.Force)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("renamed target `%s` to `%s`\n", command.Old, command.New)
+
+	return nil
+}
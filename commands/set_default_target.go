@@ -0,0 +1,25 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/concourse/fly/rc"
+)
+
+type SetDefaultTargetCommand struct{}
+
+func (command *SetDefaultTargetCommand) Execute(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: fly set-default-target <target>")
+	}
+
+	err := rc.SetDefaultTarget(args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("default target set to `%s`\n", args[0])
+
+	return nil
+}
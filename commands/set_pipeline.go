@@ -6,7 +6,6 @@ import (
 	"github.com/concourse/atc/web"
 	"github.com/concourse/fly/commands/internal/flaghelpers"
 	"github.com/concourse/fly/commands/internal/setpipelinehelpers"
-	"github.com/concourse/fly/rc"
 	"github.com/concourse/fly/template"
 	"github.com/concourse/go-concourse/concourse"
 	"github.com/tedsuo/rata"
@@ -30,7 +29,7 @@ func (command *SetPipelineCommand) Execute(args []string) error {
 		templateVariables[v.Name] = v.Value
 	}
 
-	connection, err := rc.TargetConnection(Fly.Target)
+	connection, err := targetConnection()
 	if err != nil {
 		log.Fatalln(err)
 		return nil
@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/concourse/fly/rc"
+	"github.com/concourse/go-concourse/concourse"
+)
+
+const (
+	statusOK                = 0
+	statusAuthError         = 1
+	statusConnectivityError = 2
+)
+
+type StatusCommand struct {
+	JSON bool `long:"json" description:"Print the status as JSON"`
+}
+
+type statusJSON struct {
+	Target        string     `json:"target"`
+	API           string     `json:"api,omitempty"`
+	Reachable     bool       `json:"reachable"`
+	Version       string     `json:"version,omitempty"`
+	Authenticated bool       `json:"authenticated"`
+	Expiry        *time.Time `json:"expiry,omitempty"`
+	Error         string     `json:"error,omitempty"`
+}
+
+func (command *StatusCommand) Execute([]string) error {
+	status := statusJSON{Target: Fly.Target}
+
+	exitCode, lines := command.check(&status)
+
+	if command.JSON {
+		err := json.NewEncoder(os.Stdout).Encode(status)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, line := range lines {
+			fmt.Println(line)
+		}
+	}
+
+	os.Exit(exitCode)
+
+	return nil
+}
+
+// check fills in the status as far as it can get, returning the exit code
+// and the lines to show
+func (command *StatusCommand) check(status *statusJSON) (int, []string) {
+	target, err := rc.SelectTarget(Fly.Target)
+	if err != nil {
+		status.Error = err.Error()
+		return statusConnectivityError, []string{"target: " + err.Error()}
+	}
+
+	status.API = target.API
+	lines := []string{fmt.Sprintf("target: %s (%s)", Fly.Target, target.API)}
+
+	connection, err := rc.TargetConnection(Fly.Target, Fly.Proxy)
+	if err != nil {
+		status.Error = err.Error()
+		return statusConnectivityError, append(lines, "atc: "+err.Error())
+	}
+
+	info, err := targetInfo(connection)
+	if err != nil {
+		status.Error = err.Error()
+		return statusConnectivityError, append(lines, "atc: unreachable: "+err.Error())
+	}
+
+	status.Reachable = true
+	status.Version = info.Version
+
+	version := info.Version
+	if version == "" {
+		version = "unknown"
+	}
+
+	lines = append(lines, "atc: reachable, version "+version)
+
+	loggedIn := target.Token != nil && target.Token.Value != ""
+
+	err = probeToken(concourse.NewClient(connection), target.Token)
+	if err == errTokenRejected {
+		status.Error = "not authorized"
+
+		login := fmt.Sprintf("run 'fly -t %s login'", Fly.Target)
+		if loggedIn {
+			return statusAuthError, append(lines, "auth: token rejected; "+login)
+		}
+
+		return statusAuthError, append(lines, "auth: not logged in; "+login)
+	}
+
+	if err != nil {
+		status.Error = err.Error()
+		return statusConnectivityError, append(lines, "auth: failed to check: "+err.Error())
+	}
+
+	status.Authenticated = true
+
+	if !loggedIn {
+		return statusOK, append(lines, "auth: not required")
+	}
+
+	expiry, ok := target.Token.ExpiresAt()
+	if !ok {
+		return statusOK, append(lines, "auth: token accepted")
+	}
+
+	status.Expiry = &expiry
+
+	return statusOK, append(lines, "auth: token accepted, expires "+expiry.Format(buildTimeFormat))
+}
@@ -1,26 +1,41 @@
 package commands
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"os"
 	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/inconshreveable/go-update"
 
 	"github.com/concourse/fly/commands/internal/displayhelpers"
 	"github.com/concourse/fly/rc"
+	"github.com/concourse/fly/version"
 	"github.com/concourse/go-concourse/concourse"
 )
 
-type SyncCommand struct{}
+type SyncCommand struct {
+	Force bool `short:"f" long:"force" description:"Sync even if the target's fly is older than this one"`
+}
 
 func (command *SyncCommand) Execute(args []string) error {
-	connection, err := rc.TargetConnection(Fly.Target)
+	connection, err := rc.TargetConnection(Fly.Target, Fly.Proxy)
 	if err != nil {
 		log.Fatalln(err)
 		return nil
 	}
 
+	// an ATC that doesn't report its version can still be synced with
+	info, _ := targetInfo(connection)
+
+	if !command.Force && isDowngrade(version.Version, info.Version) {
+		displayhelpers.Failf("the target's fly (%s) is older than this one (%s); use --force to downgrade", info.Version, version.Version)
+	}
+
 	client := concourse.NewClient(connection)
 	body, err := client.GetCLIReader(runtime.GOARCH, runtime.GOOS)
 	if err != nil {
@@ -29,11 +44,142 @@ func (command *SyncCommand) Execute(args []string) error {
 
 	fmt.Printf("downloading fly from %s... ", connection.URL())
 
-	err = update.Apply(body, update.Options{})
+	binary, err := ioutil.ReadAll(body)
+	body.Close()
+	if err != nil {
+		displayhelpers.FailWithErrorf("download failed", err)
+	}
+
+	if !isExecutable(binary, runtime.GOOS) {
+		displayhelpers.Failf("download failed: not a %s executable", runtime.GOOS)
+	}
+
+	options := update.Options{}
+
+	err = options.CheckPermissions()
+	if err != nil {
+		path, saveErr := saveDownload(binary)
+		if saveErr != nil {
+			displayhelpers.FailWithErrorf("cannot replace fly, and failed to save the new one", saveErr)
+		}
+
+		displayhelpers.Failf("cannot replace fly (%s); the new one has been saved to %s", err, path)
+	}
+
+	// go-update writes the new binary alongside the old one and renames it
+	// into place, moving the running one aside first on Windows
+	err = update.Apply(bytes.NewReader(binary), options)
 	if err != nil {
 		displayhelpers.Failf("update failed: %s", err)
 	}
 
 	fmt.Println("update successful!")
+	fmt.Printf("%s -> %s\n", displayVersion(version.Version), displayVersion(info.Version))
+
 	return nil
 }
+
+// executableMagic are the ways executables start, by platform
+var executableMagic = map[string][][]byte{
+	"linux":   {[]byte("\x7fELF")},
+	"freebsd": {[]byte("\x7fELF")},
+	"windows": {[]byte("MZ")},
+	"darwin": {
+		{0xfe, 0xed, 0xfa, 0xce},
+		{0xfe, 0xed, 0xfa, 0xcf},
+		{0xce, 0xfa, 0xed, 0xfe},
+		{0xcf, 0xfa, 0xed, 0xfe},
+		{0xca, 0xfe, 0xba, 0xbe},
+	},
+}
+
+func isExecutable(binary []byte, platform string) bool {
+	magics, found := executableMagic[platform]
+	if !found {
+		// nothing to check against
+		return len(binary) > 0
+	}
+
+	for _, magic := range magics {
+		if bytes.HasPrefix(binary, magic) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func saveDownload(binary []byte) (string, error) {
+	file, err := ioutil.TempFile("", "fly")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = file.Write(binary)
+	file.Close()
+	if err != nil {
+		return "", err
+	}
+
+	err = os.Chmod(file.Name(), 0755)
+	if err != nil {
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+func displayVersion(version string) string {
+	if version == "" {
+		return "unknown"
+	}
+
+	return version
+}
+
+// isDowngrade only compares release versions; development builds and
+// unknown versions can always be replaced
+func isDowngrade(current string, target string) bool {
+	currentSegments, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+
+	targetSegments, ok := parseVersion(target)
+	if !ok {
+		return false
+	}
+
+	for i := range currentSegments {
+		if targetSegments[i] != currentSegments[i] {
+			return targetSegments[i] < currentSegments[i]
+		}
+	}
+
+	return false
+}
+
+func parseVersion(version string) ([3]int, bool) {
+	var segments [3]int
+
+	if version == "" || version == devVersion {
+		return segments, false
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	for i, part := range parts {
+		// ignore any pre-release or build suffix, e.g. 1.2.3-rc.1
+		if i == 2 {
+			part = strings.SplitN(strings.SplitN(part, "-", 2)[0], "+", 2)[0]
+		}
+
+		segment, err := strconv.Atoi(part)
+		if err != nil {
+			return segments, false
+		}
+
+		segments[i] = segment
+	}
+
+	return segments, true
+}
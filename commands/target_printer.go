@@ -11,6 +11,6 @@ type targetPrinter struct {
 }
 
 func (command *targetPrinter) Execute(args []string) error {
-	fmt.Println("currently targeting", Fly.Target)
+	fmt.Printf("currently targeting %s (from %s)\n", Fly.Target, Fly.targetSource())
 	return command.Commander.Execute(args)
 }
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/concourse/fly/rc"
+	"github.com/concourse/fly/ui"
+	"github.com/fatih/color"
+)
+
+const redactedToken = "REDACTED"
+
+type TargetsCommand struct {
+	JSON       bool `long:"json"        description:"Print the targets as JSON"`
+	ShowTokens bool `long:"show-tokens" description:"With --json, include the targets' token values"`
+}
+
+type targetJSON struct {
+	Name     string          `json:"name"`
+	API      string          `json:"api"`
+	Insecure bool            `json:"insecure"`
+	Token    *rc.TargetToken `json:"token"`
+	Expiry   *time.Time      `json:"expiry"`
+	Expired  bool            `json:"expired"`
+}
+
+func (command *TargetsCommand) Execute([]string) error {
+	targets, err := rc.LoadTargets()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	var names []string
+	for name := range targets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	if command.JSON {
+		printed := []targetJSON{}
+
+		for _, name := range names {
+			target := targets[name]
+
+			printable := targetJSON{
+				Name:     name,
+				API:      target.API,
+				Insecure: target.Insecure,
+			}
+
+			if target.Token != nil {
+				token := *target.Token
+				if !command.ShowTokens {
+					token.Value = redactedToken
+
+					if token.RefreshToken != "" {
+						token.RefreshToken = redactedToken
+					}
+				}
+
+				printable.Token = &token
+
+				if expiry, ok := target.Token.ExpiresAt(); ok {
+					printable.Expiry = &expiry
+					printable.Expired = expiry.Before(time.Now())
+				}
+			}
+
+			printed = append(printed, printable)
+		}
+
+		return json.NewEncoder(os.Stdout).Encode(printed)
+	}
+
+	table := ui.Table{
+		Headers: ui.Headers("name", "url", "insecure", "expiry"),
+	}
+
+	for _, name := range names {
+		target := targets[name]
+
+		row := ui.TableRow{
+			{Contents: name},
+			{Contents: target.API},
+			{Contents: strconv.FormatBool(target.Insecure)},
+			tokenExpiry(target.Token),
+		}
+
+		table.Data = append(table.Data, row)
+	}
+
+	return table.Render(os.Stdout)
+}
+
+func tokenExpiry(token *rc.TargetToken) ui.TableCell {
+	if token == nil {
+		return ui.TableCell{Contents: "n/a", Color: color.New(color.Faint)}
+	}
+
+	expiry, ok := token.ExpiresAt()
+	if !ok {
+		return ui.TableCell{Contents: "unknown", Color: color.New(color.Faint)}
+	}
+
+	if expiry.Before(time.Now()) {
+		return ui.TableCell{Contents: expiry.Format(buildTimeFormat) + " (expired)", Color: color.New(color.FgRed)}
+	}
+
+	return ui.TableCell{Contents: expiry.Format(buildTimeFormat)}
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/fly/eventstream"
+	"github.com/concourse/fly/rc"
+)
+
+type TriggerJobCommand struct {
+	Job   flaghelpers.JobFlag `short:"j" long:"job"   required:"true" value-name:"PIPELINE/JOB" description:"Job to trigger"`
+	Watch bool                `short:"w" long:"watch"                                           description:"Watch the build's output, exiting with its status"`
+}
+
+func (command *TriggerJobCommand) Execute(args []string) error {
+	connection, err := targetConnection()
+	if err != nil {
+		log.Fatalln(err)
+		return nil
+	}
+
+	response, err := sendJobRequest(connection, atc.CreateJobBuild, command.Job)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	var build atc.Build
+	err = json.NewDecoder(response.Body).Decode(&build)
+	response.Body.Close()
+	if err != nil {
+		log.Fatalln("invalid build in response:", err)
+	}
+
+	fmt.Printf("started %s/%s #%s\n", command.Job.PipelineName, command.Job.JobName, build.Name)
+	fmt.Println(buildURL(connection.URL(), build))
+
+	if !command.Watch {
+		return nil
+	}
+
+	fmt.Println()
+
+	// the build may run for as long as it likes; the global --timeout only
+	// bounded triggering it
+	rc.SetCommandTimeout(0)
+
+	eventSource, err := buildEvents(connection, build.ID, eventstream.DefaultMaxReconnects)
+	if err != nil {
+		log.Println("failed to attach to stream:", err)
+		os.Exit(1)
+	}
+
+	exitCode := eventstream.Render(os.Stdout, eventSource, eventstream.RenderOptions{
+		ShowTimestamp: showTimestamps(false),
+		Color:         useColor("auto", os.Stdout),
+	})
+
+	eventSource.Close()
+
+	os.Exit(exitCode)
+
+	return nil
+}
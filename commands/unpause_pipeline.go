@@ -5,7 +5,6 @@ import (
 	"log"
 
 	"github.com/concourse/fly/commands/internal/displayhelpers"
-	"github.com/concourse/fly/rc"
 	"github.com/concourse/go-concourse/concourse"
 )
 
@@ -16,21 +15,36 @@ type UnpausePipelineCommand struct {
 func (command *UnpausePipelineCommand) Execute(args []string) error {
 	pipelineName := command.Pipeline
 
-	connection, err := rc.TargetConnection(Fly.Target)
+	connection, err := targetConnection()
 	if err != nil {
 		log.Fatalln(err)
 		return nil
 	}
 	client := concourse.NewClient(connection)
-	found, err := client.UnpausePipeline(pipelineName)
+
+	pipeline, found, err := findPipeline(client, pipelineName)
 	if err != nil {
 		return err
 	}
 
-	if found {
-		fmt.Printf("unpaused '%s'\n", pipelineName)
-	} else {
-		displayhelpers.Failf("pipeline '%s' not found\n", pipelineName)
+	if !found {
+		displayhelpers.Failf("pipeline '%s' not found", pipelineName)
+	}
+
+	if pipeline.Paused == false {
+		fmt.Printf("'%s' is already unpaused\n", pipelineName)
+		return nil
 	}
+
+	found, err = client.UnpausePipeline(pipelineName)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		displayhelpers.Failf("pipeline '%s' not found", pipelineName)
+	}
+
+	fmt.Printf("unpaused '%s'\n", pipelineName)
 	return nil
 }
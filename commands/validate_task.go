@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/fly/config"
+)
+
+type ValidateTaskCommand struct {
+	TaskConfig flaghelpers.TaskConfigFlag     `short:"c" long:"config" required:"true" description:"The task config to validate, or - to read it from stdin"`
+	Var        []flaghelpers.VariablePairFlag `short:"v" long:"var"    value-name:"NAME=VALUE" description:"Variable flag that can be used for filling in template values in configuration"`
+	VarsFrom   []flaghelpers.PathFlag         `short:"l" long:"load-vars-from"                 description:"Variable flag that can be used for filling in template values in configuration from a YAML file"`
+	Strict     bool                           `          long:"strict"                         description:"Fail on warnings, such as unknown fields, as well as errors"`
+}
+
+func (command *ValidateTaskCommand) Execute(args []string) error {
+	fileVariables, flagVariables := loadVariables(command.VarsFrom, command.Var)
+
+	configPath, err := config.ResolveTaskConfigPath(string(command.TaskConfig))
+	if err != nil {
+		return err
+	}
+
+	_, problems := config.CheckTaskConfig(configPath, fileVariables, flagVariables)
+
+	problems.Print(os.Stderr)
+
+	if len(problems.Errors) > 0 || (command.Strict && len(problems.Warnings) > 0) {
+		os.Exit(1)
+	}
+
+	fmt.Println("looks good")
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+
+	"github.com/concourse/fly/version"
+)
+
+type VersionCommand struct {
+	JSON bool `long:"json" description:"Print the version as JSON"`
+}
+
+type versionJSON struct {
+	Version   string `json:"version"`
+	GitSHA    string `json:"git_sha"`
+	BuildDate string `json:"build_date"`
+	Platform  string `json:"platform"`
+}
+
+func init() {
+	Fly.Version = func() {
+		printVersion(os.Stdout, false)
+		os.Exit(0)
+	}
+}
+
+func (command *VersionCommand) Execute([]string) error {
+	return printVersion(os.Stdout, command.JSON)
+}
+
+func printVersion(dst io.Writer, asJSON bool) error {
+	if asJSON {
+		return json.NewEncoder(dst).Encode(versionJSON{
+			Version:   version.Version,
+			GitSHA:    version.GitSHA,
+			BuildDate: version.BuildDate,
+			Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		})
+	}
+
+	_, err := fmt.Fprintf(dst, "fly %s\ngit sha: %s\nbuild date: %s\nplatform: %s/%s\n",
+		version.Version,
+		orUnknown(version.GitSHA),
+		orUnknown(version.BuildDate),
+		runtime.GOOS,
+		runtime.GOARCH,
+	)
+
+	return err
+}
+
+func orUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+
+	return value
+}
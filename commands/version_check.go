@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/concourse/fly/rc"
+	"github.com/concourse/fly/version"
+	"github.com/concourse/go-concourse/concourse"
+)
+
+const devVersion = "0.0.0-dev"
+
+var infoCache struct {
+	once sync.Once
+	info atcInfo
+	err  error
+}
+
+// targetInfo fetches the ATC's info once per process
+func targetInfo(connection concourse.Connection) (atcInfo, error) {
+	infoCache.once.Do(func() {
+		infoCache.info, infoCache.err = fetchInfo(connection)
+	})
+
+	return infoCache.info, infoCache.err
+}
+
+// targetConnection connects to the target, warning if its version doesn't
+// match fly's
+func targetConnection() (concourse.Connection, error) {
+	connection, err := rc.TargetConnection(Fly.Target, Fly.Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	if !Fly.IgnoreVersionMismatch {
+		warnOnVersionMismatch(connection)
+	}
+
+	return connection, nil
+}
+
+// warnOnVersionMismatch never fails; development builds of fly, ATCs that
+// don't report a version, and ATCs that can't be reached are not checked
+func warnOnVersionMismatch(connection concourse.Connection) {
+	if version.Version == devVersion {
+		return
+	}
+
+	info, err := targetInfo(connection)
+	if err != nil || info.Version == "" {
+		return
+	}
+
+	if majorMinor(info.Version) == majorMinor(version.Version) {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "warning: fly version %s does not match the target's version %s; run 'fly -t %s sync' to update fly\n", version.Version, info.Version, Fly.Target)
+}
+
+func majorMinor(version string) string {
+	segments := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(segments) > 2 {
+		segments = segments[:2]
+	}
+
+	return strings.Join(segments, ".")
+}
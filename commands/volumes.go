@@ -1,47 +1,71 @@
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/concourse/atc"
-	"github.com/concourse/fly/rc"
+	"github.com/concourse/fly/apierror"
 	"github.com/concourse/fly/ui"
 	"github.com/concourse/go-concourse/concourse"
 	"github.com/fatih/color"
+	"github.com/tedsuo/rata"
 )
 
-type VolumesCommand struct{}
+type VolumesCommand struct {
+	Worker string `long:"worker" value-name:"NAME" description:"Only list the volumes on this worker"`
+	JSON   bool   `long:"json"                     description:"Print the volumes as returned by the API, as JSON"`
+}
+
+// volume is a volume as listed by the API, including the size and type that
+// newer ATCs report
+type volume struct {
+	atc.Volume
+
+	SizeInBytes int64  `json:"size_in_bytes,omitempty"`
+	Type        string `json:"type,omitempty"`
+}
 
 func (command *VolumesCommand) Execute([]string) error {
-	connection, err := rc.TargetConnection(Fly.Target)
+	connection, err := targetConnection()
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	client := concourse.NewClient(connection)
-
-	volumes, err := client.ListVolumes()
+	volumes, err := listVolumes(connection)
 	if err != nil {
-		log.Fatalln(err)
+		log.Fatalln(explainMissingEndpoint(err, "volumes"))
 	}
 
-	table := ui.Table{
-		Headers: ui.TableRow{
-			{Contents: "handle", Color: color.New(color.Bold)},
-			{Contents: "ttl", Color: color.New(color.Bold)},
-			{Contents: "validity", Color: color.New(color.Bold)},
-			{Contents: "worker", Color: color.New(color.Bold)},
-			{Contents: "version", Color: color.New(color.Bold)},
-		},
+	if command.Worker != "" {
+		onWorker := []volume{}
+		for _, v := range volumes {
+			if v.WorkerName == command.Worker {
+				onWorker = append(onWorker, v)
+			}
+		}
+
+		volumes = onWorker
 	}
 
 	sort.Sort(volumesByWorkerAndHandle(volumes))
 
+	if command.JSON {
+		return json.NewEncoder(os.Stdout).Encode(volumes)
+	}
+
+	table := ui.Table{
+		Headers: ui.Headers("handle", "ttl", "validity", "worker", "version", "type", "size"),
+	}
+
 	for _, c := range volumes {
 		row := ui.TableRow{
 			{Contents: c.ID},
@@ -49,6 +73,8 @@ func (command *VolumesCommand) Execute([]string) error {
 			{Contents: formatTTL(c.ValidityInSeconds)},
 			{Contents: c.WorkerName},
 			versionCell(c.ResourceVersion),
+			volumeType(c),
+			sizeCell(c.SizeInBytes),
 		}
 
 		table.Data = append(table.Data, row)
@@ -57,7 +83,96 @@ func (command *VolumesCommand) Execute([]string) error {
 	return table.Render(os.Stdout)
 }
 
-type volumesByWorkerAndHandle []atc.Volume
+// listVolumes fetches every page of volumes, following the Link header's
+// next page if the ATC pages them
+func listVolumes(connection concourse.Connection) ([]volume, error) {
+	requestGenerator := rata.NewRequestGenerator(connection.URL(), atc.Routes)
+
+	request, err := requestGenerator.CreateRequest(atc.ListVolumes, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes := []volume{}
+
+	for {
+		response, err := connection.HTTPClient().Do(request)
+		if err != nil {
+			return nil, err
+		}
+
+		if response.StatusCode == http.StatusUnauthorized {
+			response.Body.Close()
+			return nil, concourse.ErrUnauthorized
+		}
+
+		if response.StatusCode != http.StatusOK {
+			return nil, apierror.FromResponse("listing volumes", response)
+		}
+
+		var page []volume
+		err = json.NewDecoder(response.Body).Decode(&page)
+		response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		volumes = append(volumes, page...)
+
+		next := nextPage(response)
+		if next == nil {
+			return volumes, nil
+		}
+
+		request, err = http.NewRequest("GET", request.URL.ResolveReference(next).String(), nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+var nextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+func nextPage(response *http.Response) *url.URL {
+	for _, link := range response.Header["Link"] {
+		match := nextLink.FindStringSubmatch(link)
+		if match == nil {
+			continue
+		}
+
+		next, err := url.Parse(match[1])
+		if err != nil {
+			return nil
+		}
+
+		return next
+	}
+
+	return nil
+}
+
+// volumeType says what the volume is for, working it out from its version
+// if the ATC doesn't say
+func volumeType(v volume) ui.TableCell {
+	switch {
+	case v.Type != "":
+		return ui.TableCell{Contents: v.Type}
+	case v.ResourceVersion != nil:
+		return ui.TableCell{Contents: "resource"}
+	default:
+		return ui.TableCell{Contents: "unknown", Color: color.New(color.Faint)}
+	}
+}
+
+func sizeCell(size int64) ui.TableCell {
+	if size == 0 {
+		return ui.TableCell{Contents: "n/a", Color: color.New(color.Faint)}
+	}
+
+	return ui.TableCell{Contents: ui.FormatBytes(float64(size))}
+}
+
+type volumesByWorkerAndHandle []volume
 
 func (cs volumesByWorkerAndHandle) Len() int          { return len(cs) }
 func (cs volumesByWorkerAndHandle) Swap(i int, j int) { cs[i], cs[j] = cs[j], cs[i] }
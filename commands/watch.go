@@ -1,23 +1,28 @@
 package commands
 
 import (
-	"fmt"
 	"log"
 	"os"
 
+	"github.com/concourse/atc"
 	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/fly/eventstream"
 	"github.com/concourse/fly/rc"
 	"github.com/concourse/go-concourse/concourse"
-	"github.com/concourse/go-concourse/concourse/eventstream"
 )
 
 type WatchCommand struct {
-	Job   flaghelpers.JobFlag `short:"j" long:"job"   value-name:"PIPELINE/JOB"   description:"Watches builds of the given job"`
-	Build string              `short:"b" long:"build"                               description:"Watches a specific build"`
+	Job        flaghelpers.JobFlag `short:"j" long:"job"   value-name:"PIPELINE/JOB"   description:"Watches builds of the given job"`
+	Build      string              `short:"b" long:"build"                               description:"Watches a specific build"`
+	Timestamps bool                `          long:"timestamps"                          description:"Prefix each line of output with the time it was logged (or set FLY_TIMESTAMPS=1)"`
+	Color      string              `          long:"color" value-name:"WHEN" default:"auto" choice:"always" choice:"never" choice:"auto" description:"Color the build's output: always, never, or auto to color it only on a terminal"`
 }
 
 func (command *WatchCommand) Execute(args []string) error {
-	connection, err := rc.TargetConnection(Fly.Target)
+	// builds may run for as long as they like
+	rc.SetCommandTimeout(0)
+
+	connection, err := targetConnection()
 	if err != nil {
 		log.Fatalln(err)
 		return nil
@@ -25,19 +30,26 @@ func (command *WatchCommand) Execute(args []string) error {
 
 	client := concourse.NewClient(connection)
 
-	build, err := GetBuild(client, command.Job.JobName, command.Build, command.Job.PipelineName)
+	var build atc.Build
+	if command.Job.JobName == "" && command.Build == "" {
+		build, err = latestBuild(client)
+	} else {
+		build, err = GetBuild(client, command.Job.JobName, command.Build, command.Job.PipelineName)
+	}
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	eventSource, err := client.BuildEvents(fmt.Sprintf("%d", build.ID))
-
+	eventSource, err := buildEvents(connection, build.ID, eventstream.DefaultMaxReconnects)
 	if err != nil {
 		log.Println("failed to attach to stream:", err)
 		os.Exit(1)
 	}
 
-	exitCode := eventstream.Render(os.Stdout, eventSource)
+	exitCode := eventstream.Render(os.Stdout, eventSource, eventstream.RenderOptions{
+		ShowTimestamp: showTimestamps(command.Timestamps),
+		Color:         useColor(command.Color, os.Stdout),
+	})
 
 	eventSource.Close()
 
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"encoding/json"
 	"log"
 	"os"
 	"sort"
@@ -8,18 +9,19 @@ import (
 	"strings"
 
 	"github.com/concourse/atc"
-	"github.com/concourse/fly/rc"
 	"github.com/concourse/fly/ui"
 	"github.com/concourse/go-concourse/concourse"
 	"github.com/fatih/color"
 )
 
 type WorkersCommand struct {
-	Details bool `short:"d" long:"details" description:"Print additional information for each worker"`
+	Details bool   `short:"d" long:"details"                                                                  description:"Print additional information for each worker"`
+	Sort    string `          long:"sort" value-name:"COLUMN" default:"name" choice:"name" choice:"containers" choice:"platform" description:"Order the workers by name, containers (busiest first) or platform"`
+	JSON    bool   `          long:"json"                                                                     description:"Print the workers as returned by the API, as JSON"`
 }
 
 func (command *WorkersCommand) Execute([]string) error {
-	connection, err := rc.TargetConnection(Fly.Target)
+	connection, err := targetConnection()
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -28,28 +30,23 @@ func (command *WorkersCommand) Execute([]string) error {
 
 	workers, err := client.ListWorkers()
 	if err != nil {
-		log.Fatalln(err)
+		log.Fatalln(explainMissingEndpoint(err, "workers"))
 	}
 
-	headers := ui.TableRow{
-		{Contents: "name", Color: color.New(color.Bold)},
-		{Contents: "containers", Color: color.New(color.Bold)},
-		{Contents: "platform", Color: color.New(color.Bold)},
-		{Contents: "tags", Color: color.New(color.Bold)},
+	sort.Sort(workersBy{workers, workerOrderings[command.Sort]})
+
+	if command.JSON {
+		return json.NewEncoder(os.Stdout).Encode(workers)
 	}
 
+	headers := ui.Headers("name", "containers", "platform", "tags")
+
 	if command.Details {
-		headers = append(headers,
-			ui.TableCell{Contents: "garden address", Color: color.New(color.Bold)},
-			ui.TableCell{Contents: "baggageclaim url", Color: color.New(color.Bold)},
-			ui.TableCell{Contents: "resource types", Color: color.New(color.Bold)},
-		)
+		headers = append(headers, ui.Headers("garden address", "baggageclaim url", "resource types")...)
 	}
 
 	table := ui.Table{Headers: headers}
 
-	sort.Sort(byWorkerName(workers))
-
 	for _, w := range workers {
 		row := ui.TableRow{
 			{Contents: w.Name},
@@ -75,11 +72,32 @@ func (command *WorkersCommand) Execute([]string) error {
 	return table.Render(os.Stdout)
 }
 
-type byWorkerName []atc.Worker
+var workerOrderings = map[string]func(a, b atc.Worker) bool{
+	"name":       func(a, b atc.Worker) bool { return a.Name < b.Name },
+	"containers": func(a, b atc.Worker) bool { return a.ActiveContainers > b.ActiveContainers },
+	"platform":   func(a, b atc.Worker) bool { return a.Platform < b.Platform },
+}
 
-func (ws byWorkerName) Len() int               { return len(ws) }
-func (ws byWorkerName) Swap(i int, j int)      { ws[i], ws[j] = ws[j], ws[i] }
-func (ws byWorkerName) Less(i int, j int) bool { return ws[i].Name < ws[j].Name }
+// workersBy orders workers by one column, falling back on the name
+type workersBy struct {
+	workers []atc.Worker
+	less    func(a, b atc.Worker) bool
+}
+
+func (ws workersBy) Len() int { return len(ws.workers) }
+func (ws workersBy) Swap(i int, j int) {
+	ws.workers[i], ws.workers[j] = ws.workers[j], ws.workers[i]
+}
+func (ws workersBy) Less(i int, j int) bool {
+	a, b := ws.workers[i], ws.workers[j]
+	if ws.less(a, b) {
+		return true
+	}
+	if ws.less(b, a) {
+		return false
+	}
+	return a.Name < b.Name
+}
 
 func stringOrNone(str string) ui.TableCell {
 	var column ui.TableCell
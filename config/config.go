@@ -1,29 +1,361 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
-	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"syscall"
 
 	"github.com/concourse/atc"
+	"github.com/concourse/fly/template"
 	"gopkg.in/yaml.v2"
 )
 
-func LoadTaskConfig(configPath string, args []string) atc.TaskConfig {
-	configFile, err := ioutil.ReadFile(configPath)
+var taskConfigFileNames = []string{"task.yml", "task.yaml"}
+
+// ResolveTaskConfigPath finds the task config within configPath if it is a
+// directory.
+func ResolveTaskConfigPath(configPath string) (string, error) {
+	if configPath == "-" {
+		return configPath, nil
+	}
+
+	info, err := os.Stat(configPath)
 	if err != nil {
-		log.Fatalln("could not open config file:", err)
+		return "", err
+	}
+
+	if !info.IsDir() {
+		return configPath, nil
+	}
+
+	tried := []string{}
+	for _, name := range taskConfigFileNames {
+		candidate := filepath.Join(configPath, name)
+
+		_, err := os.Stat(candidate)
+		if err == nil {
+			return candidate, nil
+		}
+
+		tried = append(tried, candidate)
+	}
+
+	return "", fmt.Errorf("'%s' is a directory with no task config (tried %s)", configPath, strings.Join(tried, ", "))
+}
+
+func ReadTaskConfig(configPath string, fileVariables template.Variables, flagVariables template.Variables) atc.TaskConfig {
+	config, problems := CheckTaskConfig(configPath, fileVariables, flagVariables)
+
+	problems.Print(os.Stderr)
+
+	if len(problems.Errors) > 0 {
+		os.Exit(1)
+	}
+
+	return config
+}
+
+// Problems are everything found wrong with a task config. Errors prevent it
+// from being run; warnings do not.
+type Problems struct {
+	Errors   []string
+	Warnings []string
+}
+
+func (problems *Problems) errorf(format string, args ...interface{}) {
+	problems.Errors = append(problems.Errors, fmt.Sprintf(format, args...))
+}
+
+func (problems *Problems) warnf(format string, args ...interface{}) {
+	problems.Warnings = append(problems.Warnings, fmt.Sprintf(format, args...))
+}
+
+func (problems Problems) Print(dst io.Writer) {
+	for _, warning := range problems.Warnings {
+		fmt.Fprintln(dst, "warning:", warning)
+	}
+
+	for _, err := range problems.Errors {
+		fmt.Fprintln(dst, err)
+	}
+}
+
+// CheckTaskConfig reads, templates, and validates the task config at
+// configPath, collecting problems rather than failing on the first one.
+func CheckTaskConfig(configPath string, fileVariables template.Variables, flagVariables template.Variables) (atc.TaskConfig, Problems) {
+	var problems Problems
+
+	source := "config file"
+
+	var configFile []byte
+	var err error
+	if configPath == "-" {
+		source = "config from stdin"
+		configFile, err = ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			problems.errorf("could not read config from stdin: %s", err)
+			return atc.TaskConfig{}, problems
+		}
+	} else {
+		configFile, err = ioutil.ReadFile(configPath)
+		if err != nil {
+			problems.errorf("could not open config file: %s", err)
+			return atc.TaskConfig{}, problems
+		}
+	}
+
+	displayName := "stdin"
+	if configPath != "-" {
+		displayName = filepath.Base(configPath)
+	}
+
+	return checkTaskConfig(displayName, source, configFile, isJSON(configPath, configFile), fileVariables, flagVariables)
+}
+
+// CheckInlineTaskConfig validates a config given inline, e.g. by a pipeline
+// job's task step, just as CheckTaskConfig does one read from a file. Any line
+// numbers are those of the config printed as JSON.
+func CheckInlineTaskConfig(name string, config atc.TaskConfig, fileVariables template.Variables, flagVariables template.Variables) (atc.TaskConfig, Problems) {
+	configFile, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		var problems Problems
+		problems.errorf("could not read the config of %s: %s", name, err)
+		return atc.TaskConfig{}, problems
+	}
+
+	return checkTaskConfig(name, "config of "+name, configFile, true, fileVariables, flagVariables)
+}
+
+func checkTaskConfig(displayName string, source string, configFile []byte, configIsJSON bool, fileVariables template.Variables, flagVariables template.Variables) (atc.TaskConfig, Problems) {
+	var problems Problems
+
+	for _, name := range template.Unreferenced(configFile, flagVariables) {
+		problems.warnf("variable '%s' is not used by the task config", name)
+	}
+
+	configFile, err := template.Evaluate(configFile, fileVariables.Merge(flagVariables))
+	if err != nil {
+		problems.errorf("failed to evaluate variables into template: %s", err)
+		return atc.TaskConfig{}, problems
 	}
 
 	var config atc.TaskConfig
 
-	err = yaml.Unmarshal(configFile, &config)
+	if configIsJSON {
+		err = unmarshalJSON(configFile, &config)
+	} else {
+		err = yaml.Unmarshal(configFile, &config)
+	}
 	if err != nil {
-		log.Fatalln("could not parse config file:", err)
+		problems.errorf("could not parse %s: %s", source, err)
+		return atc.TaskConfig{}, problems
 	}
 
+	validateTaskConfig(displayName, configFile, config, &problems)
+
+	checkCaches(configFile, config, &problems)
+
+	checkRunOptions(displayName, configFile, &problems)
+
+	config = applyImageResource(configFile, config, &problems)
+
+	return config, problems
+}
+
+func isJSON(configPath string, configFile []byte) bool {
+	return filepath.Ext(configPath) == ".json" || bytes.HasPrefix(bytes.TrimSpace(configFile), []byte("{"))
+}
+
+// unmarshalJSON includes the byte offset of syntax errors, as line numbers
+// are rarely meaningful for generated JSON
+func unmarshalJSON(configFile []byte, config *atc.TaskConfig) error {
+	var jsonConfig jsonTaskConfig
+	err := json.Unmarshal(configFile, &jsonConfig)
+	if err == nil {
+		*config = jsonConfig.TaskConfig
+		config.Params = jsonConfig.Params
+	}
+
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		return fmt.Errorf("invalid JSON at byte offset %d: %s", e.Offset, e)
+	case *json.UnmarshalTypeError:
+		return fmt.Errorf("invalid JSON at byte offset %d: cannot use %s as %s", e.Offset, e.Value, e.Type)
+	default:
+		return err
+	}
+}
+
+// jsonTaskConfig decodes params leniently, as YAML does, so that e.g.
+// {"PORT": 8080} isn't rejected for not being a string
+type jsonTaskConfig struct {
+	atc.TaskConfig
+
+	Params jsonParams `json:"params,omitempty"`
+}
+
+type jsonParams map[string]string
+
+func (params *jsonParams) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var raw map[string]interface{}
+	err := decoder.Decode(&raw)
+	if err != nil {
+		return err
+	}
+
+	if raw == nil {
+		*params = nil
+		return nil
+	}
+
+	*params = jsonParams{}
+
+	for name, value := range raw {
+		switch v := value.(type) {
+		case string:
+			(*params)[name] = v
+		case json.Number:
+			(*params)[name] = v.String()
+		case bool:
+			(*params)[name] = strconv.FormatBool(v)
+		case nil:
+			(*params)[name] = ""
+		default:
+			return fmt.Errorf("param '%s' must be a string, number, or boolean", name)
+		}
+	}
+
+	return nil
+}
+
+// the ATC's task config only knows of rootfs URIs, so an image_resource is
+// translated into the equivalent docker:/// URI where possible
+func applyImageResource(configFile []byte, config atc.TaskConfig, problems *Problems) atc.TaskConfig {
+	var imageConfig struct {
+		ImageResource *struct {
+			Type   string                 `yaml:"type"`
+			Source map[string]interface{} `yaml:"source"`
+		} `yaml:"image_resource"`
+	}
+
+	err := yaml.Unmarshal(configFile, &imageConfig)
+	if err != nil || imageConfig.ImageResource == nil {
+		return config
+	}
+
+	if config.Image != "" {
+		problems.warnf("both image and image_resource are specified; using image")
+		return config
+	}
+
+	resource := imageConfig.ImageResource
+	if resource.Type != "docker-image" {
+		problems.errorf("invalid task config: image_resource of type '%s' is not supported; only docker-image is", resource.Type)
+		return config
+	}
+
+	repository, _ := resource.Source["repository"].(string)
+	if repository == "" {
+		problems.errorf("invalid task config: image_resource must specify a repository in its source")
+		return config
+	}
+
+	config.Image = "docker:///" + repository
+
+	if tag, found := resource.Source["tag"]; found {
+		config.Image += fmt.Sprintf("#%v", tag)
+	}
+
+	return config
+}
+
+// the ATC's task config has no notion of caches, so rather than silently
+// dropping them, validate them and warn that they will not take effect
+func checkCaches(configFile []byte, config atc.TaskConfig, problems *Problems) {
+	var cacheConfig struct {
+		Caches []struct {
+			Path string `yaml:"path"`
+		} `yaml:"caches"`
+	}
+
+	err := yaml.Unmarshal(configFile, &cacheConfig)
+	if err != nil || len(cacheConfig.Caches) == 0 {
+		return
+	}
+
+	for _, cache := range cacheConfig.Caches {
+		if cache.Path == "" {
+			problems.errorf("invalid task config: cache path must be specified")
+			continue
+		}
+
+		for _, output := range config.Outputs {
+			outputPath := output.Path
+			if outputPath == "" {
+				outputPath = output.Name
+			}
+
+			if path.Clean(cache.Path) == path.Clean(outputPath) {
+				problems.errorf("invalid task config: cache path '%s' collides with output '%s'", cache.Path, output.Name)
+			}
+		}
+	}
+
+	problems.warnf("task caches are not supported by the targeted ATC and will be ignored")
+}
+
+// likewise the ATC's task config can't say which directory to run the task
+// in or as whom, so validate those and warn that they will not take effect
+func checkRunOptions(name string, configFile []byte, problems *Problems) {
+	var runConfig struct {
+		Run struct {
+			Dir  string `yaml:"dir"`
+			User string `yaml:"user"`
+		} `yaml:"run"`
+	}
+
+	err := yaml.Unmarshal(configFile, &runConfig)
+	if err != nil {
+		return
+	}
+
+	run := runConfig.Run
+
+	if run.Dir != "" {
+		// the task runs in a directory relative to its inputs and outputs
+		if path.IsAbs(run.Dir) || filepath.IsAbs(run.Dir) {
+			problems.errorf("%s:%d: 'run.dir' must be a path relative to the task's working directory, not '%s'", name, newLineLocator(configFile).line("run", "dir"), run.Dir)
+		}
+
+		problems.warnf("'run.dir' is not supported by the targeted ATC and will be ignored")
+	}
+
+	if run.User != "" {
+		problems.warnf("'run.user' is not supported by the targeted ATC and will be ignored")
+	}
+}
+
+func OverrideTaskConfig(config atc.TaskConfig, args []string, envParams bool) atc.TaskConfig {
 	config.Run.Args = append(config.Run.Args, args...)
 
+	if !envParams {
+		return config
+	}
+
+	// only params declared by the config are overridden, so unrelated
+	// environment variables don't leak into the build
 	for k, _ := range config.Params {
 		env, found := syscall.Getenv(k)
 		if found {
@@ -33,3 +365,39 @@ func LoadTaskConfig(configPath string, args []string) atc.TaskConfig {
 
 	return config
 }
+
+func ForwardEnvironment(config atc.TaskConfig, names []string) (atc.TaskConfig, error) {
+	for _, name := range names {
+		env, found := syscall.Getenv(name)
+		if !found {
+			return atc.TaskConfig{}, fmt.Errorf("environment variable '%s' is not set", name)
+		}
+
+		if config.Params == nil {
+			config.Params = map[string]string{}
+		}
+
+		config.Params[name] = env
+	}
+
+	return config, nil
+}
+
+func OverrideTaskParams(config atc.TaskConfig, params map[string]string) (atc.TaskConfig, error) {
+	names := []string{}
+	for name := range params {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, found := config.Params[name]; !found {
+			return atc.TaskConfig{}, fmt.Errorf("unknown param '%s' (params must be declared in the task config)", name)
+		}
+
+		config.Params[name] = params[name]
+	}
+
+	return config, nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/concourse/atc"
+	"gopkg.in/yaml.v2"
+)
+
+// Overrides are the changes made to a loaded task config from the command
+// line.
+type Overrides struct {
+	// appended to the config's run args
+	Args []string
+
+	// override the params the config declares with the environment
+	EnvParams bool
+
+	// environment variables forwarded as params, declared or not
+	Env []string
+
+	// values for params the config declares
+	Params map[string]string
+
+	Image string
+}
+
+// ResolveTaskConfig applies the overrides to a config that has already been
+// loaded and templated, in the order they take precedence: args and params
+// from the environment, then forwarded variables, then params given as
+// flags, and finally the image. The result is what's sent to the ATC.
+func ResolveTaskConfig(config atc.TaskConfig, overrides Overrides) (atc.TaskConfig, error) {
+	config = OverrideTaskConfig(config, overrides.Args, overrides.EnvParams)
+
+	config, err := ForwardEnvironment(config, overrides.Env)
+	if err != nil {
+		return atc.TaskConfig{}, err
+	}
+
+	config, err = OverrideTaskParams(config, overrides.Params)
+	if err != nil {
+		return atc.TaskConfig{}, err
+	}
+
+	if overrides.Image != "" {
+		config.Image = overrides.Image
+	}
+
+	return config, nil
+}
+
+// RedactParams hides the values of the config's params, keeping their names.
+func RedactParams(config atc.TaskConfig) atc.TaskConfig {
+	if len(config.Params) == 0 {
+		return config
+	}
+
+	redacted := map[string]string{}
+	for name := range config.Params {
+		redacted[name] = "[redacted]"
+	}
+
+	config.Params = redacted
+
+	return config
+}
+
+// PrintTaskConfig writes the config as YAML, with the values of its params
+// hidden if redactParams is set.
+func PrintTaskConfig(dst io.Writer, config atc.TaskConfig, redactParams bool) error {
+	if redactParams {
+		config = RedactParams(config)
+	}
+
+	payload, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to print task config: %s", err)
+	}
+
+	_, err = dst.Write(payload)
+
+	return err
+}
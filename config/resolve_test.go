@@ -0,0 +1,130 @@
+package config_test
+
+import (
+	"os"
+
+	"github.com/concourse/atc"
+	. "github.com/concourse/fly/config"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("ResolveTaskConfig", func() {
+	var taskConfig atc.TaskConfig
+
+	BeforeEach(func() {
+		taskConfig = atc.TaskConfig{
+			Platform: "linux",
+			Image:    "ubuntu",
+			Params: map[string]string{
+				"FROM_ENV":  "declared",
+				"FROM_FLAG": "declared",
+			},
+			Run: atc.TaskRunConfig{
+				Path: "find",
+				Args: []string{"."},
+			},
+		}
+
+		os.Setenv("FROM_ENV", "from-env")
+		os.Setenv("FORWARDED", "forwarded")
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("FROM_ENV")
+		os.Unsetenv("FORWARDED")
+	})
+
+	It("applies every override", func() {
+		resolved, err := ResolveTaskConfig(taskConfig, Overrides{
+			Args:      []string{"-name", "*.go"},
+			EnvParams: true,
+			Env:       []string{"FORWARDED"},
+			Params:    map[string]string{"FROM_FLAG": "from-flag"},
+			Image:     "some-image",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(resolved.Image).To(Equal("some-image"))
+		Expect(resolved.Run.Args).To(Equal([]string{".", "-name", "*.go"}))
+		Expect(resolved.Params).To(Equal(map[string]string{
+			"FROM_ENV":  "from-env",
+			"FROM_FLAG": "from-flag",
+			"FORWARDED": "forwarded",
+		}))
+	})
+
+	It("prefers params given as flags to those from the environment", func() {
+		resolved, err := ResolveTaskConfig(taskConfig, Overrides{
+			EnvParams: true,
+			Params:    map[string]string{"FROM_ENV": "from-flag"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(resolved.Params["FROM_ENV"]).To(Equal("from-flag"))
+	})
+
+	It("leaves the config alone without overrides", func() {
+		resolved, err := ResolveTaskConfig(taskConfig, Overrides{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(resolved.Image).To(Equal("ubuntu"))
+		Expect(resolved.Params["FROM_ENV"]).To(Equal("declared"))
+	})
+
+	It("rejects params the config doesn't declare", func() {
+		_, err := ResolveTaskConfig(taskConfig, Overrides{
+			Params: map[string]string{"BOGUS": "value"},
+		})
+		Expect(err).To(MatchError("unknown param 'BOGUS' (params must be declared in the task config)"))
+	})
+
+	It("rejects forwarding variables that aren't set", func() {
+		_, err := ResolveTaskConfig(taskConfig, Overrides{
+			Env: []string{"SURELY_NOT_SET"},
+		})
+		Expect(err).To(MatchError("environment variable 'SURELY_NOT_SET' is not set"))
+	})
+})
+
+var _ = Describe("RedactParams", func() {
+	It("hides the values of params, keeping their names", func() {
+		taskConfig := atc.TaskConfig{Params: map[string]string{"SECRET": "hunter2"}}
+
+		redacted := RedactParams(taskConfig)
+		Expect(redacted.Params).To(Equal(map[string]string{"SECRET": "[redacted]"}))
+		Expect(taskConfig.Params["SECRET"]).To(Equal("hunter2"))
+	})
+})
+
+var _ = Describe("PrintTaskConfig", func() {
+	taskConfig := atc.TaskConfig{
+		Platform: "linux",
+		Params:   map[string]string{"SECRET": "hunter2"},
+		Run:      atc.TaskRunConfig{Path: "true"},
+	}
+
+	It("prints the config as YAML", func() {
+		out := gbytes.NewBuffer()
+
+		err := PrintTaskConfig(out, taskConfig, false)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(out).To(gbytes.Say(`platform: linux`))
+		Expect(out).To(gbytes.Say(`SECRET: hunter2`))
+		Expect(out).To(gbytes.Say(`path: "true"`))
+	})
+
+	It("hides the values of params when redacting them", func() {
+		out := gbytes.NewBuffer()
+
+		err := PrintTaskConfig(out, taskConfig, true)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(out).To(gbytes.Say(`SECRET: '\[redacted\]'`))
+		Expect(out.Contents()).NotTo(ContainSubstring("hunter2"))
+		Expect(taskConfig.Params["SECRET"]).To(Equal("hunter2"))
+	})
+})
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/internal/similarity"
+	"gopkg.in/yaml.v2"
+)
+
+var knownFields = map[string][]string{
+	"":               {"platform", "tags", "image", "image_resource", "caches", "params", "run", "inputs", "outputs"},
+	"image_resource": {"type", "source"},
+	"run":            {"path", "args", "dir", "user"},
+	"inputs":         {"name", "path"},
+	"outputs":        {"name", "path"},
+	"caches":         {"path"},
+}
+
+// variables that are set in every container, which a param of the same name
+// would clobber
+var containerEnvironment = []string{"HOME", "PATH", "PWD", "SHELL", "USER"}
+
+// validateTaskConfig checks for missing required fields, which are errors,
+// and unknown (most likely misspelled) fields, which are warnings, reporting
+// each along with the line it was found on.
+func validateTaskConfig(name string, configFile []byte, config atc.TaskConfig, problems *Problems) {
+	var raw yaml.MapSlice
+	err := yaml.Unmarshal(configFile, &raw)
+	if err != nil {
+		return
+	}
+
+	locator := newLineLocator(configFile)
+
+	for _, item := range raw {
+		key := fmt.Sprintf("%v", item.Key)
+
+		if !known("", key) {
+			problems.warnf("%s:%d: unknown field '%s'%s", name, locator.line(key), key, suggestion("", key))
+			continue
+		}
+
+		if _, nested := knownFields[key]; !nested {
+			continue
+		}
+
+		for _, nestedKey := range nestedKeys(item.Value) {
+			if !known(key, nestedKey) {
+				problems.warnf("%s:%d: unknown field '%s.%s'%s", name, locator.line(key, nestedKey), key, nestedKey, suggestion(key, nestedKey))
+			}
+		}
+	}
+
+	if config.Platform == "" {
+		problems.errorf("%s:%d: missing required field 'platform'", name, 1)
+	}
+
+	if config.Run.Path == "" {
+		problems.errorf("%s:%d: missing required field 'run.path'", name, locator.line("run"))
+	}
+
+	for i, input := range config.Inputs {
+		if input.Name == "" {
+			problems.errorf("%s:%d: missing required field 'inputs[%d].name'", name, locator.line("inputs"), i)
+		}
+	}
+
+	for i, output := range config.Outputs {
+		if output.Name == "" {
+			problems.errorf("%s:%d: missing required field 'outputs[%d].name'", name, locator.line("outputs"), i)
+		}
+	}
+
+	for _, env := range containerEnvironment {
+		if _, found := config.Params[env]; found {
+			problems.warnf("%s:%d: param '%s' shadows the environment variable of the same name", name, locator.line("params", env), env)
+		}
+	}
+}
+
+func known(section string, key string) bool {
+	for _, field := range knownFields[section] {
+		if field == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+func suggestion(section string, key string) string {
+	for _, field := range knownFields[section] {
+		if similarity.EditDistance(field, key) <= 2 {
+			if section != "" {
+				field = section + "." + field
+			}
+
+			return fmt.Sprintf(", did you mean '%s'?", field)
+		}
+	}
+
+	return ""
+}
+
+// nestedKeys lists the keys of a mapping, or of each mapping in a list of
+// them, as with inputs and outputs
+func nestedKeys(value interface{}) []string {
+	keys := []string{}
+
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		for _, item := range v {
+			keys = append(keys, fmt.Sprintf("%v", item.Key))
+		}
+	case map[interface{}]interface{}:
+		for key := range v {
+			keys = append(keys, fmt.Sprintf("%v", key))
+		}
+		sort.Strings(keys)
+	case []interface{}:
+		for _, element := range v {
+			keys = append(keys, nestedKeys(element)...)
+		}
+	}
+
+	return keys
+}
+
+type lineLocator struct {
+	lines []string
+}
+
+func newLineLocator(configFile []byte) lineLocator {
+	return lineLocator{lines: strings.Split(string(configFile), "\n")}
+}
+
+// line finds the line number of the field at the given path by looking for
+// each key in turn, indented beneath the one before it. Being line-based, it
+// handles block-style YAML and pretty-printed JSON; anything else falls back
+// to the line of the closest parent found.
+func (locator lineLocator) line(path ...string) int {
+	found := 0
+	start := 0
+	parentIndent := -1
+
+	for _, key := range path {
+		pattern := regexp.MustCompile(`^(\s*)(- )?"?` + regexp.QuoteMeta(key) + `"?\s*:`)
+
+		matched := false
+		for i := start; i < len(locator.lines); i++ {
+			line := locator.lines[i]
+
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+
+			indent := len(line) - len(strings.TrimLeft(line, " \t"))
+			if i > start && parentIndent >= 0 && indent <= parentIndent && !strings.HasPrefix(trimmed, "- ") {
+				break
+			}
+
+			match := pattern.FindStringSubmatch(line)
+			if match != nil && len(match[1]) > parentIndent {
+				found = i + 1
+				start = i + 1
+				parentIndent = len(match[1])
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			break
+		}
+	}
+
+	if found == 0 {
+		return 1
+	}
+
+	return found
+}
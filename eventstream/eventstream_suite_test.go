@@ -0,0 +1,13 @@
+package eventstream_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestEventstream(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Eventstream Suite")
+}
@@ -0,0 +1,62 @@
+package eventstream
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultIdleTimeout is how long a stream may go without sending anything
+// before it's assumed to have silently died and is reconnected
+const DefaultIdleTimeout = 5 * time.Minute
+
+// idleBody closes the body once nothing has been read from it for the
+// timeout, unblocking the read waiting on it
+type idleBody struct {
+	body     io.ReadCloser
+	timeout  time.Duration
+	timer    *time.Timer
+	timedOut int32
+}
+
+func newIdleBody(body io.ReadCloser, timeout time.Duration) *idleBody {
+	idle := &idleBody{
+		body:    body,
+		timeout: timeout,
+	}
+
+	idle.timer = time.AfterFunc(timeout, func() {
+		atomic.StoreInt32(&idle.timedOut, 1)
+		body.Close()
+	})
+
+	return idle
+}
+
+func (idle *idleBody) Read(p []byte) (int, error) {
+	n, err := idle.body.Read(p)
+	if idle.TimedOut() {
+		return n, errIdle{timeout: idle.timeout}
+	}
+
+	idle.timer.Reset(idle.timeout)
+
+	return n, err
+}
+
+func (idle *idleBody) Close() error {
+	idle.timer.Stop()
+	return idle.body.Close()
+}
+
+func (idle *idleBody) TimedOut() bool {
+	return atomic.LoadInt32(&idle.timedOut) == 1
+}
+
+type errIdle struct {
+	timeout time.Duration
+}
+
+func (err errIdle) Error() string {
+	return "nothing received for " + err.timeout.String()
+}
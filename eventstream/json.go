@@ -0,0 +1,106 @@
+package eventstream
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/atc/event"
+	"github.com/concourse/go-concourse/concourse"
+)
+
+type summary struct {
+	Event      string `json:"event"`
+	BuildID    int    `json:"build_id"`
+	Status     string `json:"status,omitempty"`
+	ExitCode   int    `json:"exit_code"`
+	ExitStatus *int   `json:"task_exit_status,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// RenderJSON writes each of the build's events to dst as a line of JSON,
+// followed by a fly-summary record once the build finishes. It returns the
+// same exit code as Render.
+func RenderJSON(dst io.Writer, src concourse.Events, buildID int) int {
+	encoder := json.NewEncoder(dst)
+
+	result := summary{
+		Event:   "fly-summary",
+		BuildID: buildID,
+	}
+
+	finish := func(exitCode int) int {
+		result.ExitCode = exitCode
+		encoder.Encode(result)
+		return exitCode
+	}
+
+	for {
+		ev, err := src.NextEvent()
+		if err != nil {
+			if err == io.EOF {
+				if result.Error != "" {
+					result.Status = string(atc.StatusErrored)
+					return finish(ExitErrored)
+				}
+
+				// as Render does, fall back on the task's exit status
+				if result.ExitStatus != nil {
+					return finish(*result.ExitStatus)
+				}
+
+				return finish(ExitSucceeded)
+			}
+
+			result.Error = err.Error()
+			return finish(255)
+		}
+
+		err = encoder.Encode(jsonRecord(ev, buildID))
+		if err != nil {
+			result.Error = err.Error()
+			return finish(255)
+		}
+
+		switch e := ev.(type) {
+		case event.FinishTask:
+			exitStatus := e.ExitStatus
+			result.ExitStatus = &exitStatus
+
+		case event.Error:
+			result.Error = e.Message
+
+		case event.Status:
+			if e.Status == atc.StatusStarted || e.Status == atc.StatusPending {
+				continue
+			}
+
+			exitStatus := ExitSucceeded
+			if result.ExitStatus != nil {
+				exitStatus = *result.ExitStatus
+			}
+
+			result.Status = string(e.Status)
+
+			exitCode, _ := exitCodeFor(e.Status, exitStatus)
+			return finish(exitCode)
+		}
+	}
+}
+
+// jsonRecord is the event's envelope, as it was sent by the ATC, with fly's
+// own fields added
+func jsonRecord(ev atc.Event, buildID int) map[string]interface{} {
+	record := map[string]interface{}{}
+
+	payload, err := json.Marshal(event.Message{Event: ev})
+	if err == nil {
+		json.Unmarshal(payload, &record)
+	}
+
+	record["build_id"] = buildID
+	record["received_at"] = time.Now().UTC().Format(time.RFC3339Nano)
+
+	return record
+}
@@ -0,0 +1,98 @@
+package eventstream_test
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/atc/event"
+	. "github.com/concourse/fly/eventstream"
+	"github.com/onsi/gomega/gbytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RenderJSON", func() {
+	var out *gbytes.Buffer
+
+	BeforeEach(func() {
+		out = gbytes.NewBuffer()
+	})
+
+	records := func() []map[string]interface{} {
+		lines := strings.Split(strings.TrimSpace(string(out.Contents())), "\n")
+
+		records := []map[string]interface{}{}
+		for _, line := range lines {
+			var record map[string]interface{}
+			err := json.Unmarshal([]byte(line), &record)
+			Expect(err).NotTo(HaveOccurred())
+
+			records = append(records, record)
+		}
+
+		return records
+	}
+
+	It("prints each event as a line of JSON, followed by a summary", func() {
+		exitCode := RenderJSON(out, &fakeEvents{events: []atc.Event{
+			event.Log{Payload: "sup"},
+			event.FinishTask{ExitStatus: 0},
+			event.Status{Status: atc.StatusSucceeded},
+		}}, 128)
+
+		Expect(exitCode).To(Equal(0))
+
+		lines := records()
+		Expect(lines).To(HaveLen(4))
+
+		Expect(lines[0]["event"]).To(Equal("log"))
+		Expect(lines[0]["build_id"]).To(Equal(float64(128)))
+		Expect(lines[0]).To(HaveKey("received_at"))
+
+		Expect(lines[3]).To(Equal(map[string]interface{}{
+			"event":            "fly-summary",
+			"build_id":         float64(128),
+			"status":           "succeeded",
+			"exit_code":        float64(0),
+			"task_exit_status": float64(0),
+		}))
+	})
+
+	It("exits with the same code as when rendering text", func() {
+		exitCode := RenderJSON(out, &fakeEvents{events: []atc.Event{
+			event.FinishTask{ExitStatus: 42},
+			event.Status{Status: atc.StatusFailed},
+		}}, 128)
+
+		Expect(exitCode).To(Equal(42))
+
+		lines := records()
+		Expect(lines[len(lines)-1]["exit_code"]).To(Equal(float64(42)))
+	})
+
+	It("includes the last error when the build's status never came", func() {
+		exitCode := RenderJSON(out, &fakeEvents{events: []atc.Event{
+			event.Error{Message: "no workers"},
+		}}, 128)
+
+		Expect(exitCode).To(Equal(2))
+
+		lines := records()
+		Expect(lines[len(lines)-1]["error"]).To(Equal("no workers"))
+		Expect(lines[len(lines)-1]["status"]).To(Equal("errored"))
+	})
+
+	It("exits with the task's exit status when the build's status never came", func() {
+		exitCode := RenderJSON(out, &fakeEvents{events: []atc.Event{
+			event.FinishTask{ExitStatus: 42},
+		}}, 128)
+
+		Expect(exitCode).To(Equal(42))
+
+		lines := records()
+		Expect(lines[len(lines)-1]["exit_code"]).To(Equal(float64(42)))
+		Expect(lines[len(lines)-1]["task_exit_status"]).To(Equal(float64(42)))
+	})
+})
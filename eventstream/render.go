@@ -0,0 +1,281 @@
+package eventstream
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/atc/event"
+	"github.com/concourse/go-concourse/concourse"
+	"github.com/fatih/color"
+)
+
+const (
+	ExitSucceeded = 0
+	ExitErrored   = 2
+	ExitAborted   = 3
+)
+
+type RenderOptions struct {
+	// where the task's stdout and stderr are written, if not with
+	// everything else
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// prefix each line of log output with the time it was logged
+	ShowTimestamp bool
+
+	// color stderr, errors, and the build's status
+	Color bool
+}
+
+// Render writes the build's events to dst until the build finishes,
+// returning the exit code fly should exit with: the task's exit status, 2 if
+// the build errored, or 3 if it was aborted.
+func Render(dst io.Writer, src concourse.Events, options RenderOptions) int {
+	exitStatus := ExitSucceeded
+
+	paint := func(attributes ...color.Attribute) func(string) string {
+		printColor := color.New(attributes...)
+		if options.Color {
+			printColor.EnableColor()
+		} else {
+			printColor.DisableColor()
+		}
+
+		sprint := printColor.SprintFunc()
+		return func(s string) string {
+			return sprint(s)
+		}
+	}
+
+	bold := paint(color.Bold)
+	red := paint(statusColors[atc.StatusFailed]...)
+
+	stdoutDst := options.Stdout
+	if stdoutDst == nil {
+		stdoutDst = dst
+	}
+
+	stderrDst := options.Stderr
+	if stderrDst == nil {
+		stderrDst = dst
+	}
+
+	// output interleaved into the same destination shares its lines
+	writers := map[io.Writer]*logWriter{}
+	writerFor := func(dst io.Writer) *logWriter {
+		writer, found := writers[dst]
+		if !found {
+			writer = &logWriter{dst: dst, showTimestamp: options.ShowTimestamp, atLineStart: true}
+			writers[dst] = writer
+		}
+
+		return writer
+	}
+
+	messages := writerFor(dst)
+	stdout := writerFor(stdoutDst)
+	stderr := writerFor(stderrDst)
+
+	header := func(text string, unixTime int64) {
+		messages.finishLine()
+		messages.write("--- "+text+" ---\n", unixTime, bold)
+	}
+
+	// the command being run is only known when initializing, but is printed
+	// once it starts
+	var argv string
+	var startTime int64
+
+	var lastError string
+
+	for {
+		ev, err := src.NextEvent()
+		if err != nil {
+			if err == io.EOF {
+				// the build's status never came, so whatever went wrong last
+				// is the best explanation there is
+				if lastError != "" {
+					messages.finishLine()
+					fmt.Fprintf(dst, "%s\n", paint(color.FgRed, color.Bold)("build errored: "+lastError))
+					return ExitErrored
+				}
+
+				return exitStatus
+			}
+
+			fmt.Fprintf(dst, "failed to parse next event: %s\n", err)
+			return 255
+		}
+
+		switch e := ev.(type) {
+		case event.Log:
+			if e.Origin.Source == event.OriginSourceStderr {
+				stderr.write(e.Payload, e.Time, red)
+			} else {
+				stdout.write(e.Payload, e.Time, nil)
+			}
+
+		case event.InitializeTask:
+			if e.TaskConfig.Image != "" {
+				header(fmt.Sprintf("initializing (pulling %s)", e.TaskConfig.Image), e.Time)
+			} else {
+				header("initializing", e.Time)
+			}
+
+			argv = strings.Join(append([]string{e.TaskConfig.Run.Path}, e.TaskConfig.Run.Args...), " ")
+
+		case event.StartTask:
+			startTime = e.Time
+			header("running "+argv, e.Time)
+
+		case event.FinishTask:
+			exitStatus = e.ExitStatus
+
+			finished := fmt.Sprintf("finished: exit %d", e.ExitStatus)
+			if startTime != 0 && e.Time >= startTime {
+				finished += fmt.Sprintf(" (%s)", time.Duration(e.Time-startTime)*time.Second)
+			}
+
+			header(finished, e.Time)
+
+		case event.Error:
+			lastError = e.Message
+			if e.Origin.Name != "" {
+				lastError = e.Origin.Name + ": " + e.Message
+			}
+
+			messages.finishLine()
+			fmt.Fprintf(dst, "%s\n", paint(color.FgRed, color.Bold)(lastError))
+
+		case event.Status:
+			if e.Status == atc.StatusStarted || e.Status == atc.StatusPending {
+				continue
+			}
+
+			exitCode, known := exitCodeFor(e.Status, exitStatus)
+			if !known {
+				fmt.Fprintf(dst, "unknown status: %s\n", e.Status)
+				return 255
+			}
+
+			messages.finishLine()
+			fmt.Fprintf(dst, "%s\n", paint(statusColors[e.Status]...)(string(e.Status)))
+
+			return exitCode
+		}
+	}
+}
+
+var statusColors = map[atc.BuildStatus][]color.Attribute{
+	atc.StatusSucceeded: {color.FgGreen},
+	atc.StatusFailed:    {color.FgRed},
+	atc.StatusErrored:   {color.FgYellow, color.Bold},
+	atc.StatusAborted:   {color.FgYellow},
+}
+
+// StatusColor returns the color a build status is printed in, or nil if it
+// has none
+func StatusColor(status atc.BuildStatus) *color.Color {
+	attributes, found := statusColors[status]
+	if !found {
+		return nil
+	}
+
+	return color.New(attributes...)
+}
+
+// PaintStatus colors text according to the build status it describes
+func PaintStatus(status atc.BuildStatus, text string, enabled bool) string {
+	printColor := color.New(statusColors[status]...)
+	if enabled {
+		printColor.EnableColor()
+	} else {
+		printColor.DisableColor()
+	}
+
+	return printColor.SprintFunc()(text)
+}
+
+// exitCodeFor determines the exit code for a build that finished with the
+// given status, given the exit status of its task, if any
+func exitCodeFor(status atc.BuildStatus, exitStatus int) (int, bool) {
+	switch status {
+	case atc.StatusSucceeded:
+		return exitStatus, true
+	case atc.StatusFailed:
+		// e.g. an output failed to upload after the task succeeded
+		if exitStatus == ExitSucceeded {
+			return 1, true
+		}
+
+		return exitStatus, true
+	case atc.StatusErrored:
+		return ExitErrored, true
+	case atc.StatusAborted:
+		return ExitAborted, true
+	default:
+		return 255, false
+	}
+}
+
+type logWriter struct {
+	dst           io.Writer
+	showTimestamp bool
+
+	// whether the next payload continues a line, as output is streamed in
+	// arbitrary chunks
+	atLineStart bool
+}
+
+// finishLine ends any line left unfinished by the last payload
+func (writer *logWriter) finishLine() {
+	if !writer.atLineStart {
+		fmt.Fprint(writer.dst, "\n")
+		writer.atLineStart = true
+	}
+}
+
+// write prints the payload, painting each line separately so that colors
+// don't bleed into timestamps or across lines
+func (writer *logWriter) write(payload string, unixTime int64, paint func(string) string) {
+	if !writer.showTimestamp && paint == nil {
+		fmt.Fprintf(writer.dst, "%s", payload)
+		return
+	}
+
+	prefix := ""
+	if writer.showTimestamp {
+		logTime := time.Now()
+		if unixTime != 0 {
+			logTime = time.Unix(unixTime, 0)
+		}
+
+		prefix = logTime.Local().Format("15:04:05") + " "
+	}
+
+	for _, line := range strings.SplitAfter(payload, "\n") {
+		if line == "" {
+			continue
+		}
+
+		if writer.atLineStart {
+			fmt.Fprint(writer.dst, prefix)
+		}
+
+		content := strings.TrimSuffix(line, "\n")
+		if paint != nil && content != "" {
+			content = paint(content)
+		}
+
+		fmt.Fprint(writer.dst, content)
+
+		writer.atLineStart = strings.HasSuffix(line, "\n")
+		if writer.atLineStart {
+			fmt.Fprint(writer.dst, "\n")
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package eventstream_test
+
+import (
+	"io"
+	"time"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/atc/event"
+	. "github.com/concourse/fly/eventstream"
+	"github.com/onsi/gomega/gbytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeEvents struct {
+	events []atc.Event
+}
+
+func (events *fakeEvents) NextEvent() (atc.Event, error) {
+	if len(events.events) == 0 {
+		return nil, io.EOF
+	}
+
+	ev := events.events[0]
+	events.events = events.events[1:]
+
+	return ev, nil
+}
+
+func (events *fakeEvents) Close() error {
+	return nil
+}
+
+var _ = Describe("Render", func() {
+	var out *gbytes.Buffer
+
+	BeforeEach(func() {
+		out = gbytes.NewBuffer()
+	})
+
+	render := func(events ...atc.Event) int {
+		return Render(out, &fakeEvents{events: events}, RenderOptions{})
+	}
+
+	It("prints logs and exits with the task's exit status", func() {
+		exitCode := render(
+			event.Log{Payload: "sup"},
+			event.FinishTask{ExitStatus: 1},
+			event.Status{Status: atc.StatusFailed},
+		)
+
+		Expect(out).To(gbytes.Say("sup"))
+		Expect(out).To(gbytes.Say("failed"))
+		Expect(exitCode).To(Equal(1))
+	})
+
+	It("exits 1 when the build failed without a failing task", func() {
+		exitCode := render(event.Status{Status: atc.StatusFailed})
+
+		Expect(out).To(gbytes.Say("failed"))
+		Expect(exitCode).To(Equal(1))
+	})
+
+	It("exits 2 when the build errored", func() {
+		exitCode := render(event.Status{Status: atc.StatusErrored})
+
+		Expect(out).To(gbytes.Say("errored"))
+		Expect(exitCode).To(Equal(2))
+	})
+
+	It("exits 3 when the build was aborted", func() {
+		exitCode := render(event.Status{Status: atc.StatusAborted})
+
+		Expect(out).To(gbytes.Say("aborted"))
+		Expect(exitCode).To(Equal(3))
+	})
+
+	It("keeps rendering past the started status", func() {
+		exitCode := render(
+			event.Status{Status: atc.StatusStarted},
+			event.Log{Payload: "still here"},
+			event.Status{Status: atc.StatusSucceeded},
+		)
+
+		Expect(out).To(gbytes.Say("still here"))
+		Expect(out).To(gbytes.Say("succeeded"))
+		Expect(exitCode).To(Equal(0))
+	})
+
+	Context("when showing timestamps", func() {
+		renderWithTimestamps := func(events ...atc.Event) int {
+			return Render(out, &fakeEvents{events: events}, RenderOptions{ShowTimestamp: true})
+		}
+
+		It("prefixes each line of logs with the time it was logged", func() {
+			logged := time.Date(2016, 3, 4, 15, 4, 5, 0, time.Local)
+
+			renderWithTimestamps(
+				event.Log{Payload: "one\ntw", Time: logged.Unix()},
+				event.Log{Payload: "o\nthree\n", Time: logged.Add(time.Second).Unix()},
+			)
+
+			Expect(string(out.Contents())).To(Equal("15:04:05 one\n15:04:05 two\n15:04:06 three\n"))
+		})
+
+		It("uses the time the log was received when it has none", func() {
+			renderWithTimestamps(event.Log{Payload: "sup\n"})
+
+			Expect(out).To(gbytes.Say(`^\d\d:\d\d:\d\d sup\n`))
+		})
+	})
+
+	Context("when coloring output", func() {
+		renderWithColor := func(events ...atc.Event) int {
+			return Render(out, &fakeEvents{events: events}, RenderOptions{Color: true})
+		}
+
+		It("colors each line logged to stderr red", func() {
+			renderWithColor(
+				event.Log{Payload: "fine\n"},
+				event.Log{Payload: "oh\nno\n", Origin: event.Origin{Source: event.OriginSourceStderr}},
+			)
+
+			Expect(string(out.Contents())).To(Equal("fine\n\x1b[31moh\x1b[0m\n\x1b[31mno\x1b[0m\n"))
+		})
+
+		It("colors the build's status", func() {
+			renderWithColor(event.Status{Status: atc.StatusSucceeded})
+
+			Expect(string(out.Contents())).To(Equal("\x1b[32msucceeded\x1b[0m\n"))
+		})
+	})
+
+	It("does not color output unless told to", func() {
+		render(
+			event.Log{Payload: "oh no\n", Origin: event.Origin{Source: event.OriginSourceStderr}},
+			event.Status{Status: atc.StatusFailed},
+		)
+
+		Expect(string(out.Contents())).To(Equal("oh no\nfailed\n"))
+	})
+
+	It("passes through colors already in the output", func() {
+		render(event.Log{Payload: "\x1b[32mgreen\x1b[0m\n"})
+
+		Expect(string(out.Contents())).To(Equal("\x1b[32mgreen\x1b[0m\n"))
+	})
+
+	Context("when the task's output is written separately", func() {
+		var stdout *gbytes.Buffer
+		var stderr *gbytes.Buffer
+
+		BeforeEach(func() {
+			stdout = gbytes.NewBuffer()
+			stderr = gbytes.NewBuffer()
+		})
+
+		It("writes each log to the stream it came from, and everything else to dst", func() {
+			Render(out, &fakeEvents{events: []atc.Event{
+				event.Log{Payload: "out\n", Origin: event.Origin{Source: event.OriginSourceStdout}},
+				event.Log{Payload: "err\n", Origin: event.Origin{Source: event.OriginSourceStderr}},
+				event.Status{Status: atc.StatusSucceeded},
+			}}, RenderOptions{Stdout: stdout, Stderr: stderr})
+
+			Expect(string(stdout.Contents())).To(Equal("out\n"))
+			Expect(string(stderr.Contents())).To(Equal("err\n"))
+			Expect(string(out.Contents())).To(Equal("succeeded\n"))
+		})
+	})
+
+	It("prints a header for each phase of the task, with how long it ran", func() {
+		exitCode := render(
+			event.InitializeTask{
+				Time: 100,
+				TaskConfig: atc.TaskConfig{
+					Image: "ubuntu",
+					Run:   atc.TaskRunConfig{Path: "find", Args: []string{"."}},
+				},
+			},
+			event.StartTask{Time: 110},
+			event.Log{Payload: "./foo"},
+			event.FinishTask{Time: 243, ExitStatus: 0},
+			event.Status{Status: atc.StatusSucceeded},
+		)
+
+		Expect(string(out.Contents())).To(Equal(
+			"--- initializing (pulling ubuntu) ---\n" +
+				"--- running find . ---\n" +
+				"./foo\n" +
+				"--- finished: exit 0 (2m13s) ---\n" +
+				"succeeded\n",
+		))
+		Expect(exitCode).To(Equal(0))
+	})
+
+	It("shows the task's exit status when it fails", func() {
+		render(
+			event.StartTask{Time: 110},
+			event.FinishTask{Time: 111, ExitStatus: 42},
+		)
+
+		Expect(out).To(gbytes.Say("--- finished: exit 42 \\(1s\\) ---"))
+	})
+
+	Context("when the ATC reports an error", func() {
+		It("prints it with its origin and keeps rendering", func() {
+			exitCode := render(
+				event.Error{Message: "no workers", Origin: event.Origin{Name: "one-off"}},
+				event.Log{Payload: "still here\n"},
+				event.Status{Status: atc.StatusErrored},
+			)
+
+			Expect(out).To(gbytes.Say("one-off: no workers"))
+			Expect(out).To(gbytes.Say("still here"))
+			Expect(out).To(gbytes.Say("errored"))
+			Expect(exitCode).To(Equal(2))
+		})
+
+		It("writes it along with fly's own messages, even when the task's output is written separately", func() {
+			stderr := gbytes.NewBuffer()
+
+			Render(out, &fakeEvents{events: []atc.Event{
+				event.Error{Message: "no workers"},
+				event.Status{Status: atc.StatusErrored},
+			}}, RenderOptions{Stdout: gbytes.NewBuffer(), Stderr: stderr})
+
+			Expect(out).To(gbytes.Say("no workers"))
+			Expect(stderr.Contents()).To(BeEmpty())
+		})
+
+		Context("when the stream ends without a status", func() {
+			It("exits 2, repeating the error as the reason", func() {
+				exitCode := render(
+					event.Error{Message: "failed to fetch image"},
+					event.Error{Message: "no workers"},
+				)
+
+				Expect(out).To(gbytes.Say("build errored: no workers"))
+				Expect(exitCode).To(Equal(2))
+			})
+		})
+	})
+})
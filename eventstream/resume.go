@@ -0,0 +1,232 @@
+package eventstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/atc/event"
+	"github.com/concourse/fly/apierror"
+	"github.com/concourse/go-concourse/concourse"
+	"github.com/vito/go-sse/sse"
+)
+
+const DefaultMaxReconnects = 5
+
+const initialReconnectDelay = 500 * time.Millisecond
+
+type resumingEvents struct {
+	client         *http.Client
+	newRequest     func() (*http.Request, error)
+	maxReconnects  int
+	idleTimeout    time.Duration
+	reauthenticate func() error
+	trace          io.Writer
+
+	// set once authorization can't be regained; not worth retrying
+	authErr error
+
+	stream *sse.ReadCloser
+	idle   *idleBody
+	lastID string
+}
+
+// Connect streams a build's events, reconnecting with backoff when the
+// stream drops and resuming after the last event received via Last-Event-ID.
+// Any events replayed by the reconnect are skipped. A stream that sends
+// nothing for idleTimeout (if not 0) is reconnected too, without counting
+// against maxReconnects, since a quiet build is not a failure. If a connection
+// is not authorized, reauthenticate is given the chance to fix that before
+// retrying. Connecting and reconnecting are logged to trace, if given.
+func Connect(client *http.Client, newRequest func() (*http.Request, error), maxReconnects int, idleTimeout time.Duration, reauthenticate func() error, trace io.Writer) (concourse.Events, error) {
+	events := &resumingEvents{
+		client:         client,
+		newRequest:     newRequest,
+		maxReconnects:  maxReconnects,
+		idleTimeout:    idleTimeout,
+		reauthenticate: reauthenticate,
+		trace:          trace,
+	}
+
+	err := events.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (events *resumingEvents) NextEvent() (atc.Event, error) {
+	reconnects := 0
+	delay := initialReconnectDelay
+
+	for {
+		if events.stream == nil {
+			err := events.connect()
+			if err != nil {
+				if events.authErr != nil || reconnects >= events.maxReconnects {
+					return nil, err
+				}
+
+				reconnects++
+				events.tracef("failed to connect to event stream (%s); reconnecting (attempt %d of %d)", err, reconnects, events.maxReconnects)
+				time.Sleep(delay)
+				delay *= 2
+				continue
+			}
+		}
+
+		ev, err := events.stream.Next()
+		if err != nil {
+			idled := events.idle != nil && events.idle.TimedOut()
+
+			events.stream.Close()
+			events.stream = nil
+			events.idle = nil
+
+			if idled {
+				events.tracef("event stream went quiet (%s) after event %q; reconnecting", errIdle{timeout: events.idleTimeout}, events.lastID)
+				continue
+			}
+
+			if reconnects >= events.maxReconnects {
+				return nil, err
+			}
+
+			reconnects++
+			events.tracef("event stream dropped (%s) after event %q; reconnecting (attempt %d of %d)", err, events.lastID, reconnects, events.maxReconnects)
+			time.Sleep(delay)
+			delay *= 2
+			continue
+		}
+
+		switch ev.Name {
+		case "event":
+			if events.seen(ev.ID) {
+				continue
+			}
+
+			if ev.ID != "" {
+				events.lastID = ev.ID
+			}
+
+			var message event.Message
+			err := json.Unmarshal(ev.Data, &message)
+			if err != nil {
+				return nil, err
+			}
+
+			return message.Event, nil
+
+		case "end":
+			return nil, io.EOF
+		}
+	}
+}
+
+func (events *resumingEvents) Close() error {
+	if events.stream == nil {
+		return nil
+	}
+
+	return events.stream.Close()
+}
+
+func (events *resumingEvents) connect() error {
+	response, err := events.request()
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode == http.StatusUnauthorized {
+		response.Body.Close()
+
+		if events.reauthenticate == nil {
+			events.authErr = concourse.ErrUnauthorized
+			return events.authErr
+		}
+
+		err := events.reauthenticate()
+		if err != nil {
+			events.authErr = err
+			return err
+		}
+
+		// only once; a token fresh from logging in being refused too is
+		// not going to be fixed by logging in again
+		response, err = events.request()
+		if err != nil {
+			return err
+		}
+
+		if response.StatusCode == http.StatusUnauthorized {
+			response.Body.Close()
+
+			events.authErr = concourse.ErrUnauthorized
+			return events.authErr
+		}
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return apierror.FromResponse("streaming events", response)
+	}
+
+	body := response.Body
+	if events.idleTimeout > 0 {
+		events.idle = newIdleBody(body, events.idleTimeout)
+		body = events.idle
+	}
+
+	events.stream = sse.NewReadCloser(body)
+
+	if events.lastID != "" {
+		events.tracef("connected to event stream, resuming after event %s", events.lastID)
+	} else {
+		events.tracef("connected to event stream")
+	}
+
+	return nil
+}
+
+func (events *resumingEvents) request() (*http.Response, error) {
+	request, err := events.newRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	if events.lastID != "" {
+		request.Header.Set("Last-Event-ID", events.lastID)
+	}
+
+	return events.client.Do(request)
+}
+
+func (events *resumingEvents) tracef(format string, args ...interface{}) {
+	if events.trace != nil {
+		fmt.Fprintf(events.trace, format+"\n", args...)
+	}
+}
+
+// seen determines whether an event has already been returned, which the
+// ATC's sequential event IDs make a simple comparison
+func (events *resumingEvents) seen(id string) bool {
+	if events.lastID == "" {
+		return false
+	}
+
+	last, err := strconv.Atoi(events.lastID)
+	if err != nil {
+		return false
+	}
+
+	current, err := strconv.Atoi(id)
+	if err != nil {
+		return false
+	}
+
+	return current <= last
+}
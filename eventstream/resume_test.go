@@ -0,0 +1,64 @@
+package eventstream_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/concourse/fly/eventstream"
+	"github.com/concourse/go-concourse/concourse"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Connect", func() {
+	var server *httptest.Server
+	var newRequest func() (*http.Request, error)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+
+		newRequest = func() (*http.Request, error) {
+			return http.NewRequest("GET", server.URL+"/api/v1/builds/128/events", nil)
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("when the ATC keeps refusing the token", func() {
+		It("reauthenticates only once before giving up", func() {
+			reauthentications := 0
+			reauthenticate := func() error {
+				reauthentications++
+				return nil
+			}
+
+			_, err := Connect(http.DefaultClient, newRequest, DefaultMaxReconnects, 0, reauthenticate, nil)
+			Expect(err).To(Equal(concourse.ErrUnauthorized))
+			Expect(reauthentications).To(Equal(1))
+		})
+	})
+
+	Context("when reauthenticating fails", func() {
+		It("returns its error", func() {
+			disaster := errors.New("login cancelled")
+
+			_, err := Connect(http.DefaultClient, newRequest, DefaultMaxReconnects, 0, func() error {
+				return disaster
+			}, nil)
+			Expect(err).To(Equal(disaster))
+		})
+	})
+
+	Context("when it can't reauthenticate", func() {
+		It("is unauthorized", func() {
+			_, err := Connect(http.DefaultClient, newRequest, DefaultMaxReconnects, 0, nil, nil)
+			Expect(err).To(Equal(concourse.ErrUnauthorized))
+		})
+	})
+})
@@ -0,0 +1,172 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/atc/event"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/vito/go-sse/sse"
+)
+
+var _ = Describe("Fly CLI", func() {
+	var (
+		atcServer *ghttp.Server
+	)
+
+	Describe("abort-build", func() {
+		BeforeEach(func() {
+			atcServer = ghttp.NewServer()
+		})
+
+		AfterEach(func() {
+			atcServer.Close()
+		})
+
+		Context("when the build is running", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/builds/128"),
+						ghttp.RespondWithJSONEncoded(200, atc.Build{ID: 128, Name: "128", Status: "started"}),
+					),
+				)
+			})
+
+			Context("and aborting succeeds", func() {
+				BeforeEach(func() {
+					atcServer.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("POST", "/api/v1/builds/128/abort"),
+							ghttp.RespondWith(204, ""),
+						),
+					)
+				})
+
+				It("aborts the build and exits 0", func() {
+					flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "abort-build", "-b", "128")
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(sess).Should(gexec.Exit(0))
+					Expect(sess.Out).To(gbytes.Say("aborting build 128"))
+
+					Expect(atcServer.ReceivedRequests()).To(HaveLen(2))
+				})
+
+				Context("with --watch", func() {
+					BeforeEach(func() {
+						atcServer.AppendHandlers(
+							ghttp.CombineHandlers(
+								ghttp.VerifyRequest("GET", "/api/v1/builds/128/events"),
+								func(w http.ResponseWriter, r *http.Request) {
+									w.Header().Add("Content-Type", "text/event-stream; charset=utf-8")
+									w.WriteHeader(http.StatusOK)
+
+									for i, e := range []atc.Event{
+										event.Log{Payload: "sup\n"},
+										event.Status{Status: atc.StatusAborted},
+									} {
+										payload, err := json.Marshal(event.Message{Event: e})
+										Expect(err).NotTo(HaveOccurred())
+
+										err = sse.Event{ID: fmt.Sprintf("%d", i), Name: "event", Data: payload}.Write(w)
+										Expect(err).NotTo(HaveOccurred())
+									}
+
+									err := sse.Event{Name: "end"}.Write(w)
+									Expect(err).NotTo(HaveOccurred())
+								},
+							),
+						)
+					})
+
+					It("streams the build's output until it has been aborted", func() {
+						flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "abort-build", "-b", "128", "--watch")
+
+						sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+						Expect(err).NotTo(HaveOccurred())
+
+						Eventually(sess).Should(gexec.Exit(0))
+						Expect(sess.Out).To(gbytes.Say("sup"))
+						Expect(sess.Out).To(gbytes.Say("aborted"))
+					})
+				})
+			})
+
+			Context("and aborting fails", func() {
+				BeforeEach(func() {
+					atcServer.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("POST", "/api/v1/builds/128/abort"),
+							ghttp.RespondWith(500, `{"errors":["the build is stuck"]}`),
+						),
+					)
+				})
+
+				It("reports the error and exits 1", func() {
+					flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "abort-build", "-b", "128")
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(sess).Should(gexec.Exit(1))
+					Expect(sess.Err).To(gbytes.Say(`bad response aborting build \(500 Internal Server Error\):`))
+					Expect(sess.Err).To(gbytes.Say("the build is stuck"))
+				})
+			})
+		})
+
+		Context("when the build has already finished", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/builds/128"),
+						ghttp.RespondWithJSONEncoded(200, atc.Build{ID: 128, Name: "128", Status: "succeeded"}),
+					),
+				)
+			})
+
+			It("refuses to abort it and exits 1", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "abort-build", "-b", "128")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(1))
+				Expect(sess.Err).To(gbytes.Say(`build 128 has already finished \(succeeded\)`))
+
+				Expect(atcServer.ReceivedRequests()).To(HaveLen(1))
+			})
+		})
+
+		Context("when the build does not exist", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/builds/128"),
+						ghttp.RespondWith(404, ""),
+					),
+				)
+			})
+
+			It("exits 1", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "abort-build", "-b", "128")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(1))
+				Expect(sess.Err).To(gbytes.Say("build not found"))
+			})
+		})
+	})
+})
@@ -0,0 +1,168 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"os/exec"
+	"time"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/ui"
+	"github.com/fatih/color"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Fly CLI", func() {
+	var (
+		atcServer *ghttp.Server
+	)
+
+	Describe("builds", func() {
+		var (
+			flyCmd *exec.Cmd
+			builds []atc.Build
+		)
+
+		startTime := time.Date(2016, time.March, 1, 12, 30, 0, 0, time.Local)
+
+		BeforeEach(func() {
+			atcServer = ghttp.NewServer()
+
+			builds = []atc.Build{
+				{
+					ID:        2,
+					Name:      "2",
+					Status:    "pending",
+					JobName:   "",
+					StartTime: 0,
+				},
+				{
+					ID:           3,
+					Name:         "7",
+					Status:       "failed",
+					PipelineName: "some-pipeline",
+					JobName:      "some-job",
+					StartTime:    startTime.Unix(),
+					EndTime:      startTime.Add(90 * time.Second).Unix(),
+				},
+				{
+					ID:        1,
+					Name:      "1",
+					Status:    "succeeded",
+					JobName:   "",
+					StartTime: startTime.Unix(),
+					EndTime:   startTime.Add(10 * time.Second).Unix(),
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			atcServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/builds"),
+					ghttp.RespondWithJSONEncoded(200, builds),
+				),
+			)
+		})
+
+		Context("when builds are returned from the API", func() {
+			BeforeEach(func() {
+				flyCmd = exec.Command(flyPath, "-t", atcServer.URL(), "builds")
+			})
+
+			It("lists them to the user, newest first", func() {
+				Expect(flyCmd).To(PrintTable(ui.Table{
+					Headers: ui.TableRow{
+						{Contents: "id", Color: color.New(color.Bold)},
+						{Contents: "pipeline/job", Color: color.New(color.Bold)},
+						{Contents: "build", Color: color.New(color.Bold)},
+						{Contents: "status", Color: color.New(color.Bold)},
+						{Contents: "start", Color: color.New(color.Bold)},
+						{Contents: "duration", Color: color.New(color.Bold)},
+					},
+					Data: []ui.TableRow{
+						{{Contents: "3"}, {Contents: "some-pipeline/some-job"}, {Contents: "7"}, {Contents: "failed", Color: color.New(color.FgRed)}, {Contents: startTime.Format("2006-01-02 15:04:05")}, {Contents: "1m30s"}},
+						{{Contents: "2"}, {Contents: "one-off", Color: color.New(color.Faint)}, {Contents: "2"}, {Contents: "pending"}, {Contents: "n/a", Color: color.New(color.Faint)}, {Contents: "n/a", Color: color.New(color.Faint)}},
+						{{Contents: "1"}, {Contents: "one-off", Color: color.New(color.Faint)}, {Contents: "1"}, {Contents: "succeeded", Color: color.New(color.FgGreen)}, {Contents: startTime.Format("2006-01-02 15:04:05")}, {Contents: "10s"}},
+					},
+				}))
+			})
+		})
+
+		Context("with -n", func() {
+			BeforeEach(func() {
+				flyCmd = exec.Command(flyPath, "-t", atcServer.URL(), "builds", "-n", "1")
+			})
+
+			It("lists only that many of the newest builds", func() {
+				sess, err := gexec.Start(flyCmd, nil, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(0))
+
+				Expect(sess.Out).To(gbytes.Say("some-pipeline/some-job"))
+				Expect(sess.Out).NotTo(gbytes.Say("one-off"))
+			})
+
+			Context("and --all", func() {
+				BeforeEach(func() {
+					flyCmd = exec.Command(flyPath, "-t", atcServer.URL(), "builds", "-n", "1", "--all")
+				})
+
+				It("lists every build", func() {
+					sess, err := gexec.Start(flyCmd, nil, nil)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(sess).Should(gexec.Exit(0))
+
+					Expect(sess.Out).To(gbytes.Say("some-pipeline/some-job"))
+					Expect(sess.Out).To(gbytes.Say("one-off"))
+					Expect(sess.Out).To(gbytes.Say("one-off"))
+				})
+			})
+		})
+
+		Context("with --json", func() {
+			BeforeEach(func() {
+				flyCmd = exec.Command(flyPath, "-t", atcServer.URL(), "builds", "--json")
+			})
+
+			It("prints the builds as returned by the API, newest first", func() {
+				sess, err := gexec.Start(flyCmd, nil, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(0))
+
+				var printed []atc.Build
+				err = json.Unmarshal(sess.Out.Contents(), &printed)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(printed).To(Equal([]atc.Build{builds[1], builds[0], builds[2]}))
+			})
+		})
+
+		Context("and the api returns an internal server error", func() {
+			BeforeEach(func() {
+				flyCmd = exec.Command(flyPath, "-t", atcServer.URL(), "builds")
+				builds = nil
+			})
+
+			JustBeforeEach(func() {
+				atcServer.SetHandler(0, ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/builds"),
+					ghttp.RespondWith(500, ""),
+				))
+			})
+
+			It("writes an error message to stderr", func() {
+				sess, err := gexec.Start(flyCmd, nil, nil)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(sess.Err).Should(gbytes.Say("Unexpected Response"))
+				Eventually(sess).Should(gexec.Exit(1))
+			})
+		})
+	})
+})
@@ -0,0 +1,128 @@
+package integration_test
+
+import (
+	"net/http"
+	"os/exec"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/tedsuo/rata"
+
+	"github.com/concourse/atc"
+)
+
+var _ = Describe("Fly CLI", func() {
+	var atcServer *ghttp.Server
+
+	Describe("check-resource", func() {
+		var path string
+
+		BeforeEach(func() {
+			atcServer = ghttp.NewServer()
+
+			var err error
+			path, err = atc.Routes.CreatePathForRoute(atc.CheckResource, rata.Params{"pipeline_name": "awesome-pipeline", "resource_name": "awesome-resource"})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			atcServer.Close()
+		})
+
+		check := func(args ...string) *gexec.Session {
+			flyCmd := exec.Command(flyPath, append([]string{"-t", atcServer.URL(), "check-resource", "-r", "awesome-pipeline/awesome-resource"}, args...)...)
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			return sess
+		}
+
+		Context("when the check succeeds", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", path),
+						ghttp.VerifyJSON(`{"from":null}`),
+						ghttp.RespondWith(http.StatusOK, ""),
+					),
+				)
+			})
+
+			It("says so", func() {
+				sess := check()
+
+				Eventually(sess).Should(gexec.Exit(0))
+				Expect(sess.Out).To(gbytes.Say("checked 'awesome-pipeline/awesome-resource'"))
+			})
+		})
+
+		Context("when --from is given", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", path),
+						ghttp.VerifyJSON(`{"from":{"ref":"abcd1234"}}`),
+						ghttp.RespondWith(http.StatusOK, ""),
+					),
+				)
+			})
+
+			It("checks from that version", func() {
+				sess := check("--from", "ref:abcd1234")
+
+				Eventually(sess).Should(gexec.Exit(0))
+			})
+		})
+
+		Context("when the resource's check fails", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", path),
+						ghttp.RespondWith(http.StatusBadRequest, `{"exit_status":1,"stderr":"repository not found\n"}`),
+					),
+				)
+			})
+
+			It("prints the check's error and exits 1", func() {
+				sess := check()
+
+				Eventually(sess).Should(gexec.Exit(1))
+				Expect(sess.Err).To(gbytes.Say("check failed with exit status 1:"))
+				Expect(sess.Err).To(gbytes.Say("repository not found"))
+			})
+		})
+
+		Context("when the resource does not exist", func() {
+			BeforeEach(func() {
+				resourcesPath, err := atc.Routes.CreatePathForRoute(atc.ListResources, rata.Params{"pipeline_name": "awesome-pipeline"})
+				Expect(err).NotTo(HaveOccurred())
+
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", path),
+						ghttp.RespondWith(http.StatusNotFound, ""),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", resourcesPath),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.Resource{
+							{Name: "awesome-resourse"},
+							{Name: "other-resource"},
+						}),
+					),
+				)
+			})
+
+			It("lists the pipeline's resources and exits 1", func() {
+				sess := check()
+
+				Eventually(sess).Should(gexec.Exit(1))
+				Expect(sess.Err).To(gbytes.Say("resource 'awesome-resource' not found in pipeline 'awesome-pipeline'; its resources are: awesome-resourse, other-resource"))
+			})
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package integration_test
 
 import (
+	"encoding/json"
 	"os/exec"
 
 	"github.com/concourse/atc"
@@ -45,6 +46,7 @@ var _ = Describe("Fly CLI", func() {
 							{
 								ID:           "early-handle",
 								PipelineName: "pipeline-name",
+								JobName:      "job-name-1",
 								Type:         "get",
 								Name:         "git-repo",
 								BuildID:      123,
@@ -52,7 +54,8 @@ var _ = Describe("Fly CLI", func() {
 							},
 							{
 								ID:           "other-handle",
-								PipelineName: "pipeline-name",
+								PipelineName: "other-pipeline-name",
+								JobName:      "job-name-2",
 								Type:         "task",
 								Name:         "unit-tests",
 								BuildID:      122,
@@ -69,19 +72,64 @@ var _ = Describe("Fly CLI", func() {
 						{Contents: "handle", Color: color.New(color.Bold)},
 						{Contents: "name", Color: color.New(color.Bold)},
 						{Contents: "pipeline", Color: color.New(color.Bold)},
+						{Contents: "job", Color: color.New(color.Bold)},
 						{Contents: "type", Color: color.New(color.Bold)},
 						{Contents: "build id", Color: color.New(color.Bold)},
 						{Contents: "worker", Color: color.New(color.Bold)},
 					},
 					Data: []ui.TableRow{
-						{{Contents: "early-handle"}, {Contents: "git-repo"}, {Contents: "pipeline-name"}, {Contents: "get"}, {Contents: "123"}, {Contents: "worker-name-1"}},
-						{{Contents: "handle-1"}, {Contents: "git-repo"}, {Contents: "pipeline-name"}, {Contents: "check"}, {Contents: "none", Color: color.New(color.Faint)}, {Contents: "worker-name-1"}},
-						{{Contents: "other-handle"}, {Contents: "unit-tests"}, {Contents: "pipeline-name"}, {Contents: "task"}, {Contents: "122"}, {Contents: "worker-name-2"}},
+						{{Contents: "early-handle"}, {Contents: "git-repo"}, {Contents: "pipeline-name"}, {Contents: "job-name-1"}, {Contents: "get"}, {Contents: "123"}, {Contents: "worker-name-1"}},
+						{{Contents: "handle-1"}, {Contents: "git-repo"}, {Contents: "pipeline-name"}, {Contents: "none", Color: color.New(color.Faint)}, {Contents: "check"}, {Contents: "none", Color: color.New(color.Faint)}, {Contents: "worker-name-1"}},
+						{{Contents: "other-handle"}, {Contents: "unit-tests"}, {Contents: "other-pipeline-name"}, {Contents: "job-name-2"}, {Contents: "task"}, {Contents: "122"}, {Contents: "worker-name-2"}},
 					},
 				}))
 
 				Expect(flyCmd).To(HaveExited(0))
 			})
+
+			Context("when --sort is given", func() {
+				BeforeEach(func() {
+					flyCmd.Args = append(flyCmd.Args, "--sort", "build", "--json")
+				})
+
+				It("orders them by that column", func() {
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(0))
+
+					var containers []atc.Container
+					err = json.Unmarshal(sess.Out.Contents(), &containers)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(containers).To(HaveLen(3))
+					Expect(containers[0].ID).To(Equal("handle-1"))
+					Expect(containers[1].ID).To(Equal("other-handle"))
+					Expect(containers[2].ID).To(Equal("early-handle"))
+				})
+			})
+
+			Context("when filters are given", func() {
+				BeforeEach(func() {
+					flyCmd.Args = append(flyCmd.Args, "--pipeline", "pipeline-name", "--type", "get", "--json")
+				})
+
+				It("only lists the matching containers", func() {
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(0))
+
+					var containers []atc.Container
+					err = json.Unmarshal(sess.Out.Contents(), &containers)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(containers).To(HaveLen(1))
+					Expect(containers[0].ID).To(Equal("early-handle"))
+				})
+			})
 		})
 
 		Context("and the api returns an internal server error", func() {
@@ -101,5 +149,23 @@ var _ = Describe("Fly CLI", func() {
 				Eventually(sess).Should(gexec.Exit(1))
 			})
 		})
+
+		Context("when the ATC doesn't have the endpoint", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/containers"),
+						ghttp.RespondWith(404, ""),
+					),
+				)
+			})
+
+			It("says the ATC may need upgrading", func() {
+				sess, err := gexec.Start(flyCmd, nil, nil)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(sess.Err).Should(gbytes.Say("the target's ATC does not support listing containers"))
+				Eventually(sess).Should(gexec.Exit(1))
+			})
+		})
 	})
 })
@@ -0,0 +1,125 @@
+package integration_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/rc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("choosing a target", func() {
+		var homeDir string
+		var atcServer *ghttp.Server
+
+		BeforeEach(func() {
+			var err error
+
+			homeDir, err = ioutil.TempDir("", "fly-test")
+			Expect(err).NotTo(HaveOccurred())
+
+			if runtime.GOOS == "windows" {
+				os.Setenv("USERPROFILE", homeDir)
+			} else {
+				os.Setenv("HOME", homeDir)
+			}
+
+			atcServer = ghttp.NewServer()
+
+			err = rc.SaveTarget("ci", atcServer.URL(), false, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = rc.SaveTarget("staging", "https://staging.example.com", false, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			atcServer.Close()
+			os.RemoveAll(homeDir)
+		})
+
+		fly := func(env []string, args ...string) *gexec.Session {
+			flyCmd := exec.Command(flyPath, args...)
+			flyCmd.Env = append(os.Environ(), env...)
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			<-sess.Exited
+
+			return sess
+		}
+
+		listsPipelines := func() {
+			atcServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/pipelines"),
+					ghttp.RespondWithJSONEncoded(200, []atc.Pipeline{
+						{Name: "pipeline-1"},
+					}),
+				),
+			)
+		}
+
+		It("uses FLY_TARGET when -t is not given", func() {
+			listsPipelines()
+
+			sess := fly([]string{"FLY_TARGET=ci"}, "pipelines")
+			Expect(sess.ExitCode()).To(Equal(0))
+			Expect(sess.Out).To(gbytes.Say("pipeline-1"))
+		})
+
+		It("prefers -t to FLY_TARGET", func() {
+			listsPipelines()
+
+			sess := fly([]string{"FLY_TARGET=staging"}, "-t", "ci", "pipelines")
+			Expect(sess.ExitCode()).To(Equal(0))
+			Expect(sess.Out).To(gbytes.Say("pipeline-1"))
+		})
+
+		Context("when a default target is set", func() {
+			BeforeEach(func() {
+				sess := fly(nil, "set-default-target", "ci")
+				Expect(sess.ExitCode()).To(Equal(0))
+				Expect(sess.Out).To(gbytes.Say("default target set to `ci`"))
+			})
+
+			It("uses it when neither -t nor FLY_TARGET is given", func() {
+				listsPipelines()
+
+				sess := fly([]string{"FLY_TARGET="}, "pipelines")
+				Expect(sess.ExitCode()).To(Equal(0))
+				Expect(sess.Out).To(gbytes.Say("pipeline-1"))
+			})
+
+			It("prefers FLY_TARGET to it", func() {
+				sess := fly([]string{"FLY_TARGET=missing"}, "pipelines")
+				Expect(sess.ExitCode()).To(Equal(1))
+				Expect(sess.Err).To(gbytes.Say("Unable to find target missing"))
+			})
+		})
+
+		It("refuses to set a default target that isn't saved", func() {
+			sess := fly(nil, "set-default-target", "missing")
+			Expect(sess.ExitCode()).To(Equal(1))
+			Expect(sess.Err).To(gbytes.Say("Unable to find target missing"))
+		})
+
+		It("explains how to choose a target when none is given", func() {
+			sess := fly([]string{"FLY_TARGET="}, "pipelines")
+			Expect(sess.ExitCode()).To(Equal(1))
+			Expect(sess.Err).To(gbytes.Say("no target specified; use -t, set FLY_TARGET, or run 'fly set-default-target <target>'"))
+			Expect(sess.Err).To(gbytes.Say("saved targets:"))
+			Expect(sess.Err).To(gbytes.Say("ci"))
+			Expect(sess.Err).To(gbytes.Say("staging"))
+		})
+	})
+})
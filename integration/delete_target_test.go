@@ -0,0 +1,127 @@
+package integration_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/concourse/fly/rc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("delete-target", func() {
+		var homeDir string
+
+		BeforeEach(func() {
+			var err error
+
+			homeDir, err = ioutil.TempDir("", "fly-test")
+			Expect(err).NotTo(HaveOccurred())
+
+			if runtime.GOOS == "windows" {
+				os.Setenv("USERPROFILE", homeDir)
+			} else {
+				os.Setenv("HOME", homeDir)
+			}
+
+			err = rc.SaveTarget("old-ci", "https://old-ci.example.com", false, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = rc.SaveTarget("ci", "https://ci.example.com", false, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(homeDir)
+		})
+
+		Context("when the target exists", func() {
+			It("removes it, leaving the other targets", func() {
+				sess, err := gexec.Start(exec.Command(flyPath, "-t", "old-ci", "delete-target"), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(0))
+				Expect(sess.Out).To(gbytes.Say("deleted target `old-ci`"))
+
+				targets, err := rc.LoadTargets()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(targets).To(HaveLen(1))
+				Expect(targets).To(HaveKey("ci"))
+			})
+		})
+
+		Context("when the target does not exist", func() {
+			It("errors", func() {
+				sess, err := gexec.Start(exec.Command(flyPath, "-t", "bogus", "delete-target"), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(1))
+				Expect(sess.Err).To(gbytes.Say("Unable to find target bogus"))
+
+				targets, err := rc.LoadTargets()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(targets).To(HaveLen(2))
+			})
+		})
+
+		Context("with --all", func() {
+			var (
+				stdin io.Writer
+				sess  *gexec.Session
+			)
+
+			JustBeforeEach(func() {
+				var err error
+
+				flyCmd := exec.Command(flyPath, "delete-target", "--all")
+				stdin, err = flyCmd.StdinPipe()
+				Expect(err).NotTo(HaveOccurred())
+
+				sess, err = gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(sess).Should(gbytes.Say("!!! this will remove every target from .flyrc"))
+				Eventually(sess).Should(gbytes.Say(`are you sure\? \[yN\]: `))
+			})
+
+			It("deletes every target if the user confirms", func() {
+				fmt.Fprintf(stdin, "y\n")
+				Eventually(sess).Should(gexec.Exit(0))
+
+				targets, err := rc.LoadTargets()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(targets).To(BeEmpty())
+			})
+
+			It("bails out if the user presses no", func() {
+				fmt.Fprintf(stdin, "n\n")
+
+				Eventually(sess).Should(gbytes.Say(`bailing out`))
+				Eventually(sess).Should(gexec.Exit(0))
+
+				targets, err := rc.LoadTargets()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(targets).To(HaveLen(2))
+			})
+		})
+
+		Context("with --all and --non-interactive", func() {
+			It("deletes every target without asking", func() {
+				sess, err := gexec.Start(exec.Command(flyPath, "delete-target", "--all", "--non-interactive"), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(0))
+
+				targets, err := rc.LoadTargets()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(targets).To(BeEmpty())
+			})
+		})
+	})
+})
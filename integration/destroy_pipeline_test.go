@@ -3,8 +3,12 @@ package integration_test
 import (
 	"fmt"
 	"io"
+	"net/http"
 	"os/exec"
 
+	"github.com/concourse/atc"
+	"github.com/tedsuo/rata"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
@@ -42,11 +46,11 @@ var _ = Describe("Fly CLI", func() {
 			)
 
 			yes := func() {
-				fmt.Fprintf(stdin, "y\n")
+				fmt.Fprintf(stdin, "some-pipeline\n")
 			}
 
 			no := func() {
-				fmt.Fprintf(stdin, "n\n")
+				fmt.Fprintf(stdin, "some-other-pipeline\n")
 			}
 
 			JustBeforeEach(func() {
@@ -59,7 +63,7 @@ var _ = Describe("Fly CLI", func() {
 				sess, err = gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(sess).Should(gbytes.Say("!!! this will remove all data for pipeline `some-pipeline`"))
-				Eventually(sess).Should(gbytes.Say(`are you sure\? \[yN\]: `))
+				Eventually(sess).Should(gbytes.Say(`type the pipeline name to confirm`))
 			})
 
 			It("exits successfully if the user confirms", func() {
@@ -74,7 +78,7 @@ var _ = Describe("Fly CLI", func() {
 				Eventually(sess).Should(gexec.Exit(0))
 			})
 
-			It("bails out if the user presses no", func() {
+			It("bails out if the user types a different name", func() {
 				no()
 
 				Eventually(sess).Should(gbytes.Say(`bailing out`))
@@ -108,10 +112,10 @@ var _ = Describe("Fly CLI", func() {
 					)
 				})
 
-				It("writes that it did not exist and exits successfully", func() {
+				It("writes that it did not exist and exits 1", func() {
 					yes()
-					Eventually(sess).Should(gbytes.Say("`some-pipeline` does not exist"))
-					Eventually(sess).Should(gexec.Exit(0))
+					Eventually(sess.Err).Should(gbytes.Say("`some-pipeline` does not exist"))
+					Eventually(sess).Should(gexec.Exit(1))
 				})
 			})
 
@@ -125,12 +129,74 @@ var _ = Describe("Fly CLI", func() {
 					)
 				})
 
-				It("writes an error message to stderr", func() {
+				It("writes an error message to stderr and exits 2", func() {
 					yes()
 					Eventually(sess.Err).Should(gbytes.Say("Unexpected Response"))
-					Eventually(sess).Should(gexec.Exit(1))
+					Eventually(sess).Should(gexec.Exit(2))
 				})
 			})
 		})
+
+		Context("when --non-interactive is given", func() {
+			It("destroys the pipeline without asking", func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("DELETE", "/api/v1/pipelines/some-pipeline"),
+						ghttp.RespondWith(204, ""),
+					),
+				)
+
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "destroy-pipeline", "-p", "some-pipeline", "--non-interactive")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(0))
+				Expect(sess.Out).To(gbytes.Say("`some-pipeline` deleted"))
+				Expect(sess.Out).NotTo(gbytes.Say("confirm"))
+			})
+
+			It("exits 2 when the ATC can't be reached", func() {
+				atcServer.Close()
+
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "destroy-pipeline", "-p", "some-pipeline", "--non-interactive")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(2))
+				Expect(sess.Err).To(gbytes.Say("error: "))
+			})
+		})
+
+		Context("when --show-what-will-be-lost is given", func() {
+			It("lists the jobs and resources without destroying anything", func() {
+				path, err := atc.Routes.CreatePathForRoute(atc.GetConfig, rata.Params{"pipeline_name": "some-pipeline"})
+				Expect(err).NotTo(HaveOccurred())
+
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", path),
+						ghttp.RespondWithJSONEncoded(200, atc.Config{
+							Jobs:      atc.JobConfigs{{Name: "some-job"}},
+							Resources: atc.ResourceConfigs{{Name: "some-resource"}},
+						}, http.Header{atc.ConfigVersionHeader: {"42"}}),
+					),
+				)
+
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "destroy-pipeline", "-p", "some-pipeline", "--show-what-will-be-lost")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(0))
+				Expect(sess.Out).To(gbytes.Say("jobs:"))
+				Expect(sess.Out).To(gbytes.Say("some-job"))
+				Expect(sess.Out).To(gbytes.Say("resources:"))
+				Expect(sess.Out).To(gbytes.Say("some-resource"))
+
+				Expect(atcServer.ReceivedRequests()).To(HaveLen(1))
+			})
+		})
 	})
 })
@@ -0,0 +1,246 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/vito/go-sse/sse"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/atc/event"
+)
+
+var _ = Describe("Fly CLI", func() {
+	var atcServer *ghttp.Server
+	var streaming chan struct{}
+	var events chan atc.Event
+
+	BeforeEach(func() {
+		atcServer = ghttp.NewServer()
+		streaming = make(chan struct{})
+		events = make(chan atc.Event)
+	})
+
+	AfterEach(func() {
+		atcServer.Close()
+	})
+
+	buildHandler := func(status atc.BuildStatus) http.HandlerFunc {
+		return ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/api/v1/builds/128"),
+			ghttp.RespondWithJSONEncoded(200, atc.Build{ID: 128, Name: "128", Status: string(status)}),
+		)
+	}
+
+	eventsHandler := func() http.HandlerFunc {
+		return ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/api/v1/builds/128/events"),
+			func(w http.ResponseWriter, r *http.Request) {
+				flusher := w.(http.Flusher)
+
+				w.Header().Add("Content-Type", "text/event-stream; charset=utf-8")
+				w.Header().Add("Cache-Control", "no-cache, no-store, must-revalidate")
+				w.Header().Add("Connection", "keep-alive")
+
+				w.WriteHeader(http.StatusOK)
+
+				flusher.Flush()
+
+				close(streaming)
+
+				id := 0
+
+				for e := range events {
+					payload, err := json.Marshal(event.Message{Event: e})
+					Expect(err).NotTo(HaveOccurred())
+
+					event := sse.Event{
+						ID:   fmt.Sprintf("%d", id),
+						Name: "event",
+						Data: payload,
+					}
+
+					err = event.Write(w)
+					Expect(err).NotTo(HaveOccurred())
+
+					flusher.Flush()
+
+					id++
+				}
+
+				err := sse.Event{
+					Name: "end",
+				}.Write(w)
+				Expect(err).NotTo(HaveOccurred())
+			},
+		)
+	}
+
+	Describe("execute --attach", func() {
+		Context("when the build is running", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					buildHandler(atc.StatusStarted),
+					eventsHandler(),
+				)
+			})
+
+			It("streams its output without uploading anything and exits with its status", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "--attach", "128")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("attaching to build 128"))
+				Eventually(streaming).Should(BeClosed())
+
+				events <- event.Log{Payload: "sup"}
+				Eventually(sess.Out).Should(gbytes.Say("sup"))
+
+				events <- event.Status{Status: atc.StatusFailed}
+				close(events)
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+
+		Context("when the event stream drops", func() {
+			writeEvent := func(w http.ResponseWriter, id int, payload string) {
+				data, err := json.Marshal(event.Message{Event: event.Log{Payload: payload}})
+				Expect(err).NotTo(HaveOccurred())
+
+				err = sse.Event{
+					ID:   fmt.Sprintf("%d", id),
+					Name: "event",
+					Data: data,
+				}.Write(w)
+				Expect(err).NotTo(HaveOccurred())
+
+				w.(http.Flusher).Flush()
+			}
+
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					buildHandler(atc.StatusStarted),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/builds/128/events"),
+						func(w http.ResponseWriter, r *http.Request) {
+							Expect(r.Header.Get("Last-Event-ID")).To(BeEmpty())
+
+							w.Header().Add("Content-Type", "text/event-stream; charset=utf-8")
+							w.WriteHeader(http.StatusOK)
+
+							writeEvent(w, 0, "line one\n")
+							writeEvent(w, 1, "line two\n")
+
+							conn, _, err := w.(http.Hijacker).Hijack()
+							Expect(err).NotTo(HaveOccurred())
+							conn.Close()
+						},
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/builds/128/events"),
+						ghttp.VerifyHeaderKV("Last-Event-ID", "1"),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.Header().Add("Content-Type", "text/event-stream; charset=utf-8")
+							w.WriteHeader(http.StatusOK)
+
+							// overlaps with what was already received
+							writeEvent(w, 1, "line two\n")
+							writeEvent(w, 2, "line three\n")
+
+							err := sse.Event{Name: "end"}.Write(w)
+							Expect(err).NotTo(HaveOccurred())
+						},
+					),
+				)
+			})
+
+			It("resumes after the last event received without repeating any", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "--attach", "128")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess, 5).Should(gexec.Exit(0))
+
+				Expect(strings.Count(string(sess.Out.Contents()), "line two")).To(Equal(1))
+				Expect(sess.Out).To(gbytes.Say("line one\nline two\nline three\n"))
+			})
+		})
+
+		Context("when the event stream is not authorized", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					buildHandler(atc.StatusStarted),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/builds/128/events"),
+						ghttp.RespondWith(http.StatusUnauthorized, nil),
+					),
+				)
+			})
+
+			It("fails fast when not interactive, suggesting logging in again", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "--attach", "128")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess, 5).Should(gexec.Exit(1))
+
+				Expect(sess.Err).To(gbytes.Say("not authorized to access target '" + atcServer.URL() + "'; run 'fly -t " + atcServer.URL() + " login' and try again"))
+				Expect(atcServer.ReceivedRequests()).To(HaveLen(2))
+			})
+		})
+
+		Context("when the build has finished", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					buildHandler(atc.StatusSucceeded),
+					eventsHandler(),
+				)
+			})
+
+			It("refuses to attach", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "--attach", "128")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+
+				Expect(sess.Err).To(gbytes.Say("build 128 has already finished \\(succeeded\\); use --replay to see its output"))
+			})
+
+			Context("with --replay", func() {
+				It("replays its output and exits with its status", func() {
+					flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "--attach", "128", "--replay")
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(streaming).Should(BeClosed())
+
+					events <- event.Log{Payload: "old news"}
+					events <- event.Status{Status: atc.StatusSucceeded}
+					close(events)
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(0))
+
+					Expect(sess.Out).To(gbytes.Say("old news"))
+				})
+			})
+		})
+	})
+})
@@ -2,15 +2,19 @@ package integration_test
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"syscall"
 	"time"
 
@@ -30,6 +34,7 @@ var _ = Describe("Fly CLI", func() {
 	var tmpdir string
 	var buildDir string
 	var taskConfigPath string
+	var scriptModTime time.Time
 
 	var atcServer *ghttp.Server
 	var streaming chan struct{}
@@ -73,6 +78,25 @@ run:
 		)
 		Expect(err).NotTo(HaveOccurred())
 
+		scriptPath := filepath.Join(buildDir, "run.sh")
+
+		err = ioutil.WriteFile(scriptPath, []byte("#!/bin/sh\n"), 0755)
+		Expect(err).NotTo(HaveOccurred())
+
+		scriptModTime = time.Now().Add(-time.Hour).Truncate(time.Second)
+
+		err = os.Chtimes(scriptPath, scriptModTime, scriptModTime)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.MkdirAll(filepath.Join(buildDir, "releases", "3"), 0755)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.Symlink("releases/3", filepath.Join(buildDir, "current"))
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.Symlink("../../elsewhere", filepath.Join(buildDir, "outside"))
+		Expect(err).NotTo(HaveOccurred())
+
 		atcServer = ghttp.NewServer()
 
 		streaming = make(chan struct{})
@@ -218,10 +242,32 @@ run:
 
 					Expect(hdr.Name).To(Equal("./"))
 
-					hdr, err = tr.Next()
-					Expect(err).NotTo(HaveOccurred())
+					headers := map[string]*tar.Header{}
+					for {
+						hdr, err = tr.Next()
+						if err == io.EOF {
+							break
+						}
+
+						Expect(err).NotTo(HaveOccurred())
 
-					Expect(hdr.Name).To(MatchRegexp("(./)?task.yml$"))
+						headers[strings.TrimPrefix(hdr.Name, "./")] = hdr
+					}
+
+					Expect(headers).To(HaveKey("task.yml"))
+
+					Expect(headers).To(HaveKey("run.sh"))
+					Expect(headers["run.sh"].Typeflag).To(Equal(byte(tar.TypeReg)))
+					Expect(headers["run.sh"].Mode & 0777).To(Equal(int64(0755)))
+					Expect(headers["run.sh"].ModTime.Unix()).To(Equal(scriptModTime.Unix()))
+
+					Expect(headers).To(HaveKey("current"))
+					Expect(headers["current"].Typeflag).To(Equal(byte(tar.TypeSymlink)))
+					Expect(headers["current"].Linkname).To(Equal("releases/3"))
+
+					Expect(headers).To(HaveKey("outside"))
+					Expect(headers["outside"].Typeflag).To(Equal(byte(tar.TypeSymlink)))
+					Expect(headers["outside"].Linkname).To(Equal("../../elsewhere"))
 				},
 				ghttp.RespondWith(200, ""),
 			),
@@ -236,7 +282,8 @@ run:
 		Expect(err).NotTo(HaveOccurred())
 
 		Eventually(streaming).Should(BeClosed())
-		Eventually(sess.Out).Should(gbytes.Say("executing build 128"))
+		Eventually(sess.Err).Should(gbytes.Say("executing build 128"))
+		Eventually(sess.Err).Should(gbytes.Say(atcServer.URL() + "/builds/128"))
 
 		events <- event.Log{Payload: "sup"}
 
@@ -250,42 +297,67 @@ run:
 		Expect(uploadingBits).To(BeClosed())
 	})
 
-	Context("when the build config is invalid", func() {
-		BeforeEach(func() {
-			// missing platform and run path
-			err := ioutil.WriteFile(
-				filepath.Join(buildDir, "task.yml"),
-				[]byte(`---
-run: {}
-`),
-				0644,
-			)
-			Expect(err).NotTo(HaveOccurred())
-		})
+	It("sends cookies set when creating the build with later requests", func() {
+		flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+		flyCmd.Dir = buildDir
 
-		It("prints the failure and exits 1", func() {
-			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
-			flyCmd.Dir = buildDir
+		sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
 
-			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
-			Expect(err).NotTo(HaveOccurred())
+		Eventually(streaming).Should(BeClosed())
 
-			Eventually(sess.Err).Should(gbytes.Say("missing"))
+		close(events)
 
-			<-sess.Exited
-			Expect(sess.ExitCode()).To(Equal(1))
-		})
+		<-sess.Exited
+		Expect(sess.ExitCode()).To(Equal(0))
+
+		var eventsRequest *http.Request
+		for _, request := range atcServer.ReceivedRequests() {
+			if request.URL.Path == "/api/v1/builds/128/events" {
+				eventsRequest = request
+			}
+		}
+
+		Expect(eventsRequest).NotTo(BeNil())
+
+		cookie, err := eventsRequest.Cookie("Some-Cookie")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cookie.Value).To(Equal("some-cookie-data"))
 	})
 
-	Context("when arguments are passed through", func() {
-		BeforeEach(func() {
-			expectedPlan.OnSuccess.Next.Task.Config.Run.Args = []string{".", "-name", `foo "bar" baz`}
-		})
+	It("identifies itself on every request", func() {
+		flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+		flyCmd.Dir = buildDir
 
-		It("inserts them into the config template", func() {
+		sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(streaming).Should(BeClosed())
+
+		close(events)
+
+		<-sess.Exited
+		Expect(sess.ExitCode()).To(Equal(0))
+
+		userAgent := fmt.Sprintf("fly/0.0.0-dev (%s/%s)", runtime.GOOS, runtime.GOARCH)
+
+		paths := []string{}
+		for _, request := range atcServer.ReceivedRequests() {
+			Expect(request.Header.Get("User-Agent")).To(Equal(userAgent))
+			paths = append(paths, request.Method+" "+request.URL.Path)
+		}
+
+		Expect(paths).To(ContainElement("POST /api/v1/pipes"))
+		Expect(paths).To(ContainElement("PUT /api/v1/pipes/some-pipe-id"))
+		Expect(paths).To(ContainElement("POST /api/v1/builds"))
+		Expect(paths).To(ContainElement("GET /api/v1/builds/128/events"))
+	})
+
+	Context("when the config path is a directory", func() {
+		It("executes the task.yml within it", func() {
 			atcServer.AllowUnhandledRequests = true
 
-			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--", "-name", "foo \"bar\" baz")
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", buildDir)
 			flyCmd.Dir = buildDir
 
 			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
@@ -301,52 +373,40 @@ run: {}
 
 			Expect(uploadingBits).To(BeClosed())
 		})
-	})
 
-	Context("when invalid inputs are passed", func() {
-		It("prints an error", func() {
-			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "-i", "fixture=.", "-i", "evan=.")
+		It("lists the candidates it tried when there's no task config within it", func() {
+			emptyDir := filepath.Join(tmpdir, "empty")
+			err := os.Mkdir(emptyDir, 0755)
+			Expect(err).NotTo(HaveOccurred())
+
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", emptyDir)
 			flyCmd.Dir = buildDir
 
 			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
 			Expect(err).NotTo(HaveOccurred())
 
-			Eventually(sess.Err).Should(gbytes.Say("unknown input `evan`"))
+			Eventually(sess.Err).Should(gbytes.Say("is a directory with no task config \\(tried .*task.yml, .*task.yaml\\)"))
 
 			<-sess.Exited
 			Expect(sess.ExitCode()).To(Equal(1))
 		})
-
-		Context("when invalid inputs are passed and the single valid input is correctly ommited", func() {
-			It("prints an error about invalid inputs instead of missing inputs", func() {
-				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "-i", "evan=.")
-				flyCmd.Dir = buildDir
-
-				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
-				Expect(err).NotTo(HaveOccurred())
-
-				Eventually(sess.Err).Should(gbytes.Say("unknown input `evan`"))
-
-				<-sess.Exited
-				Expect(sess.ExitCode()).To(Equal(1))
-			})
-		})
 	})
 
-	Context("when the task specifies more than one input", func() {
-
+	Context("when the config specifies an image_resource", func() {
 		BeforeEach(func() {
-			// missing platform and run path
 			err := ioutil.WriteFile(
-				filepath.Join(buildDir, "task.yml"),
+				taskConfigPath,
 				[]byte(`---
 platform: some-platform
 
-image: ubuntu
+image_resource:
+  type: docker-image
+  source:
+    repository: some/image
+    tag: 1.2
 
 inputs:
 - name: fixture
-- name: something
 
 params:
   FOO: bar
@@ -360,50 +420,53 @@ run:
 				0644,
 			)
 			Expect(err).NotTo(HaveOccurred())
-		})
-		Context("When some required inputs are not passed", func() {
-			It("Prints an error", func() {
-				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "-i", "something=.")
-				flyCmd.Dir = buildDir
 
-				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
-				Expect(err).NotTo(HaveOccurred())
-
-				Eventually(sess.Err).Should(gbytes.Say("missing required input `fixture`"))
+			expectedPlan.OnSuccess.Next.Task.Config.Image = "docker:///some/image#1.2"
+		})
 
-				<-sess.Exited
-				Expect(sess.ExitCode()).To(Equal(1))
-			})
+		It("runs the task in the resource's image", func() {
+			atcServer.AllowUnhandledRequests = true
 
-		})
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+			flyCmd.Dir = buildDir
 
-		Context("When no inputs are passed", func() {
-			It("Prints an error", func() {
-				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
-				flyCmd.Dir = buildDir
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
 
-				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
-				Expect(err).NotTo(HaveOccurred())
+			// sync with after create
+			Eventually(streaming, 5.0).Should(BeClosed())
 
-				Eventually(sess.Err).Should(gbytes.Say("missing required input"))
+			close(events)
 
-				<-sess.Exited
-				Expect(sess.ExitCode()).To(Equal(1))
-			})
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
 
+			Expect(uploadingBits).To(BeClosed())
 		})
-
 	})
 
-	Context("when running with --privileged", func() {
+	Context("when the config is JSON", func() {
 		BeforeEach(func() {
-			expectedPlan.OnSuccess.Next.Task.Privileged = true
+			taskConfigPath = filepath.Join(buildDir, "task.json")
+
+			err := ioutil.WriteFile(
+				taskConfigPath,
+				[]byte(`{
+  "platform": "some-platform",
+  "image": "ubuntu",
+  "inputs": [{"name": "fixture"}],
+  "params": {"FOO": "bar", "BAZ": "buzz", "X": "1"},
+  "run": {"path": "find", "args": ["."]}
+}`),
+				0644,
+			)
+			Expect(err).NotTo(HaveOccurred())
 		})
 
-		It("inserts them into the config template", func() {
+		It("executes it just like YAML", func() {
 			atcServer.AllowUnhandledRequests = true
 
-			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--privileged")
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
 			flyCmd.Dir = buildDir
 
 			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
@@ -419,38 +482,81 @@ run:
 
 			Expect(uploadingBits).To(BeClosed())
 		})
-	})
 
-	Context("when running with bogus flags", func() {
-		It("exits 1", func() {
-			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--bogus-flag")
+		It("accepts params that aren't strings, just like YAML", func() {
+			err := ioutil.WriteFile(
+				taskConfigPath,
+				[]byte(`{
+  "platform": "some-platform",
+  "image": "ubuntu",
+  "inputs": [{"name": "fixture"}],
+  "params": {"FOO": "bar", "BAZ": "buzz", "X": 1},
+  "run": {"path": "find", "args": ["."]}
+}`),
+				0644,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			atcServer.AllowUnhandledRequests = true
+
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
 			flyCmd.Dir = buildDir
 
 			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
 			Expect(err).NotTo(HaveOccurred())
 
-			Eventually(sess.Err).Should(gbytes.Say("unknown flag `bogus-flag'"))
+			// sync with after create; the plan is expected to have X as "1"
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+		})
+
+		It("reports syntax errors with their byte offset", func() {
+			err := ioutil.WriteFile(taskConfigPath, []byte(`{"platform": "some-platform",}`), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess.Err).Should(gbytes.Say("could not parse config file: invalid JSON at byte offset 30"))
 
 			<-sess.Exited
 			Expect(sess.ExitCode()).To(Equal(1))
 		})
-	})
 
-	Context("when parameters are specified in the environment", func() {
-		BeforeEach(func() {
-			expectedPlan.OnSuccess.Next.Task.Config.Params = map[string]string{
-				"FOO": "newbar",
-				"BAZ": "buzz",
-				"X":   "",
-			}
+		It("reports values of the wrong type with their byte offset", func() {
+			err := ioutil.WriteFile(taskConfigPath, []byte(`{"platform": 42}`), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess.Err).Should(gbytes.Say(`could not parse config file: invalid JSON at byte offset \d+: cannot use number as string`))
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
 		})
+	})
 
-		It("overrides the build's parameter values", func() {
+	Context("when the config is read from stdin", func() {
+		It("executes it, resolving inputs against the working directory", func() {
 			atcServer.AllowUnhandledRequests = true
 
-			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+			taskConfig, err := ioutil.ReadFile(taskConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", "-")
 			flyCmd.Dir = buildDir
-			flyCmd.Env = append(os.Environ(), "FOO=newbar", "X=")
+			flyCmd.Stdin = bytes.NewReader(taskConfig)
 
 			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
 			Expect(err).NotTo(HaveOccurred())
@@ -465,51 +571,1543 @@ run:
 
 			Expect(uploadingBits).To(BeClosed())
 		})
-	})
 
-	Context("when the build is interrupted", func() {
-		var aborted chan struct{}
+		It("refers to stdin when the config can't be parsed", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", "-")
+			flyCmd.Dir = buildDir
+			flyCmd.Stdin = bytes.NewBufferString("{not: yaml")
 
-		JustBeforeEach(func() {
-			aborted = make(chan struct{})
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
 
-			atcServer.AppendHandlers(
-				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("POST", "/api/v1/builds/128/abort"),
-					func(w http.ResponseWriter, r *http.Request) {
-						close(aborted)
-					},
-				),
+			Eventually(sess.Err).Should(gbytes.Say("could not parse config from stdin"))
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
+		})
+	})
+
+	Context("when the build config is invalid", func() {
+		BeforeEach(func() {
+			// missing platform and run path
+			err := ioutil.WriteFile(
+				filepath.Join(buildDir, "task.yml"),
+				[]byte(`---
+run: {}
+`),
+				0644,
 			)
+			Expect(err).NotTo(HaveOccurred())
 		})
 
-		if runtime.GOOS != "windows" {
-			Describe("with SIGINT", func() {
-				It("aborts the build and exits nonzero", func() {
-					flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
-					flyCmd.Dir = buildDir
+		It("prints the failure and exits 1", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+			flyCmd.Dir = buildDir
 
-					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
-					Expect(err).ToNot(HaveOccurred())
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
 
-					Eventually(streaming, 5).Should(BeClosed())
+			Eventually(sess.Err).Should(gbytes.Say("missing"))
 
-					Eventually(uploadingBits).Should(BeClosed())
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
+		})
+	})
+
+	Context("when the build config has misspelled and missing fields", func() {
+		BeforeEach(func() {
+			err := ioutil.WriteFile(
+				filepath.Join(buildDir, "task.yml"),
+				[]byte(`---
+platform: some-platform
+
+imge: ubuntu
+
+run:
+  args: [a, b]
+  pth: find
+`),
+				0644,
+			)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("reports every problem with its line number and exits 1", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
+
+			Expect(sess.Err).To(gbytes.Say(`warning: task.yml:4: unknown field 'imge', did you mean 'image'\?`))
+			Expect(sess.Err).To(gbytes.Say(`warning: task.yml:8: unknown field 'run.pth', did you mean 'run.path'\?`))
+			Expect(sess.Err).To(gbytes.Say(`task.yml:6: missing required field 'run.path'`))
+		})
+	})
+
+	Context("when the task config declares caches", func() {
+		Context("that collide with an output", func() {
+			BeforeEach(func() {
+				err := ioutil.WriteFile(
+					filepath.Join(buildDir, "task.yml"),
+					[]byte(`---
+platform: some-platform
+
+image: ubuntu
+
+outputs:
+- name: built
+
+caches:
+- path: built
+
+run:
+  path: find
+`),
+					0644,
+				)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("prints the failure and exits 1", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("cache path 'built' collides with output 'built'"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+	})
+
+	Context("when arguments are passed through", func() {
+		BeforeEach(func() {
+			expectedPlan.OnSuccess.Next.Task.Config.Run.Args = []string{".", "-name", `foo "bar" baz`}
+		})
+
+		It("inserts them into the config template", func() {
+			atcServer.AllowUnhandledRequests = true
+
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--", "-name", "foo \"bar\" baz")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			// sync with after create
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(uploadingBits).To(BeClosed())
+		})
+	})
+
+	Context("when invalid inputs are passed", func() {
+		It("prints an error", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "-i", "fixture=.", "-i", "evan=.")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess.Err).Should(gbytes.Say("unknown input `evan`"))
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
+		})
+
+		Context("when invalid inputs are passed and the single valid input is correctly ommited", func() {
+			It("prints an error about invalid inputs instead of missing inputs", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "-i", "evan=.")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("unknown input `evan`"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+	})
+
+	Context("when the task's single input is not named after the current directory", func() {
+		BeforeEach(func() {
+			err := ioutil.WriteFile(
+				filepath.Join(buildDir, "task.yml"),
+				[]byte(`---
+platform: some-platform
+
+image: ubuntu
+
+inputs:
+- name: some-input
+
+params:
+  FOO: bar
+  BAZ: buzz
+  X: 1
+
+run:
+  path: find
+  args: [.]
+`),
+				0644,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			expectedPlan.OnSuccess.Step.Aggregate = &atc.AggregatePlan{
+				atc.Plan{
+					Location: &atc.Location{
+						ParallelGroup: 1,
+						ParentID:      0,
+						ID:            2,
+					},
+					Get: &atc.GetPlan{
+						Name: "some-input",
+						Type: "archive",
+						Source: atc.Source{
+							"uri": atcServer.URL() + "/api/v1/pipes/some-pipe-id",
+						},
+					},
+				},
+			}
+
+			expectedPlan.OnSuccess.Next.Task.Config.Inputs = []atc.TaskInputConfig{
+				{Name: "some-input"},
+			}
+		})
+
+		It("uses the current directory as the input", func() {
+			atcServer.AllowUnhandledRequests = true
+
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess.Err).Should(gbytes.Say("using current directory as input 'some-input'"))
+
+			// sync with after create
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(uploadingBits).To(BeClosed())
+		})
+	})
+
+	Context("when an input is a remote git repository", func() {
+		BeforeEach(func() {
+			err := ioutil.WriteFile(
+				filepath.Join(buildDir, "task.yml"),
+				[]byte(`---
+platform: some-platform
+
+image: ubuntu
+
+inputs:
+- name: fixture
+- name: repo
+
+params:
+  FOO: bar
+  BAZ: buzz
+  X: 1
+
+run:
+  path: find
+  args: [.]
+`),
+				0644,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			expectedPlan.OnSuccess.Step.Aggregate = &atc.AggregatePlan{
+				atc.Plan{
+					Location: &atc.Location{
+						ParallelGroup: 1,
+						ParentID:      0,
+						ID:            2,
+					},
+					Get: &atc.GetPlan{
+						Name: "fixture",
+						Type: "archive",
+						Source: atc.Source{
+							"uri": atcServer.URL() + "/api/v1/pipes/some-pipe-id",
+						},
+					},
+				},
+				atc.Plan{
+					Location: &atc.Location{
+						ParallelGroup: 1,
+						ParentID:      0,
+						ID:            3,
+					},
+					Get: &atc.GetPlan{
+						Name: "repo",
+						Type: "git",
+						Source: atc.Source{
+							"uri":    "https://github.com/foo/bar.git",
+							"branch": "develop",
+						},
+					},
+				},
+			}
+
+			expectedPlan.OnSuccess.Next.Location.ID = 4
+			expectedPlan.OnSuccess.Next.Task.Config.Inputs = []atc.TaskInputConfig{
+				{Name: "fixture"},
+				{Name: "repo"},
+			}
+		})
+
+		It("gets it with a git resource alongside the uploaded inputs", func() {
+			atcServer.AllowUnhandledRequests = true
+
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "-i", "fixture=.", "-i", "repo=https://github.com/foo/bar.git#develop")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			// sync with after create
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(uploadingBits).To(BeClosed())
+		})
+	})
+
+	Context("when an input is renamed with --input-mapping", func() {
+		BeforeEach(func() {
+			err := ioutil.WriteFile(
+				filepath.Join(buildDir, "task.yml"),
+				[]byte(`---
+platform: some-platform
+
+image: ubuntu
+
+inputs:
+- name: source-code
+
+params:
+  FOO: bar
+  BAZ: buzz
+  X: 1
+
+run:
+  path: find
+  args: [.]
+`),
+				0644,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			expectedPlan.OnSuccess.Step.Aggregate = &atc.AggregatePlan{
+				atc.Plan{
+					Location: &atc.Location{
+						ParallelGroup: 1,
+						ParentID:      0,
+						ID:            2,
+					},
+					Get: &atc.GetPlan{
+						Name: "source-code",
+						Type: "archive",
+						Source: atc.Source{
+							"uri": atcServer.URL() + "/api/v1/pipes/some-pipe-id",
+						},
+					},
+				},
+			}
+
+			expectedPlan.OnSuccess.Next.Task.Config.Inputs = []atc.TaskInputConfig{
+				{Name: "source-code"},
+			}
+		})
+
+		It("provides the local input under the task's input name", func() {
+			atcServer.AllowUnhandledRequests = true
+
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "-i", "myapp=.", "--input-mapping", "source-code=myapp")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			// sync with after create
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(uploadingBits).To(BeClosed())
+		})
+
+		Context("when the mappings conflict", func() {
+			It("prints an error and exits 1", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "-i", "myapp=.", "--input-mapping", "source-code=myapp", "--input-mapping", "source-code=other")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("conflicting mappings for input `source-code`: `myapp` and `other`"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+	})
+
+	Context("when an input is read from stdin", func() {
+		var uploaded chan map[string]string
+
+		JustBeforeEach(func() {
+			uploaded = make(chan map[string]string, 1)
+
+			atcServer.RouteToHandler("PUT", "/api/v1/pipes/some-pipe-id", func(w http.ResponseWriter, r *http.Request) {
+				gr, err := gzip.NewReader(r.Body)
+				Expect(err).NotTo(HaveOccurred())
+
+				files := map[string]string{}
+
+				tr := tar.NewReader(gr)
+				for {
+					hdr, err := tr.Next()
+					if err == io.EOF {
+						break
+					}
+
+					Expect(err).NotTo(HaveOccurred())
+
+					contents, err := ioutil.ReadAll(tr)
+					Expect(err).NotTo(HaveOccurred())
+
+					files[hdr.Name] = string(contents)
+				}
+
+				uploaded <- files
+			})
+		})
+
+		execute := func(stdin string, args ...string) *gexec.Session {
+			flyCmd := exec.Command(flyPath, append([]string{"-t", atcServer.URL(), "e"}, args...)...)
+			flyCmd.Dir = buildDir
+			flyCmd.Stdin = strings.NewReader(stdin)
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			return sess
+		}
+
+		succeeds := func(sess *gexec.Session) {
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+		}
+
+		It("uploads it as the input, containing a single file", func() {
+			sess := execute("a,b\n1,2\n", "-c", taskConfigPath, "--input-from-stdin", "fixture=input.csv")
+
+			var files map[string]string
+			Eventually(uploaded).Should(Receive(&files))
+			Expect(files).To(Equal(map[string]string{
+				"./":          "",
+				"./input.csv": "a,b\n1,2\n",
+			}))
+
+			succeeds(sess)
+		})
+
+		It("uploads an empty file when stdin is empty", func() {
+			sess := execute("", "-c", taskConfigPath, "--input-from-stdin", "fixture=input.csv")
+
+			var files map[string]string
+			Eventually(uploaded).Should(Receive(&files))
+			Expect(files).To(HaveKeyWithValue("./input.csv", ""))
+
+			succeeds(sess)
+		})
+
+		It("rejects inputs the task does not declare, like -i", func() {
+			sess := execute("data", "-c", taskConfigPath, "--input-from-stdin", "bogus=input.csv")
+
+			Eventually(sess.Err).Should(gbytes.Say("unknown input `bogus`"))
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
+		})
+
+		It("cannot also read the config from stdin", func() {
+			sess := execute("data", "-c", "-", "--input-from-stdin", "fixture=input.csv")
+
+			Eventually(sess.Err).Should(gbytes.Say("stdin cannot be used for both --config and --input-from-stdin"))
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
+		})
+	})
+
+	Context("when the task declares no inputs", func() {
+		BeforeEach(func() {
+			err := ioutil.WriteFile(
+				taskConfigPath,
+				[]byte(`---
+platform: some-platform
+
+image: ubuntu
+
+run:
+  path: echo
+  args: [hello]
+`),
+				0644,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			expectedPlan = atc.Plan{
+				Location: &atc.Location{
+					ParallelGroup: 0,
+					ParentID:      0,
+					ID:            2,
+				},
+				Task: &atc.TaskPlan{
+					Name: "one-off",
+					Config: &atc.TaskConfig{
+						Platform: "some-platform",
+						Image:    "ubuntu",
+						Run: atc.TaskRunConfig{
+							Path: "echo",
+							Args: []string{"hello"},
+						},
+					},
+				},
+			}
+		})
+
+		It("runs only the task, without creating a pipe or uploading anything", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess.Err).Should(gbytes.Say("no inputs declared; nothing to upload"))
+
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(uploadingBits).NotTo(BeClosed())
+
+			for _, request := range atcServer.ReceivedRequests() {
+				Expect(request.URL.Path).NotTo(Equal("/api/v1/pipes"))
+			}
+		})
+
+		It("rejects inputs given with -i", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "-i", "fixture=.")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess.Err).Should(gbytes.Say("unknown input `fixture`"))
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
+		})
+	})
+
+	Context("when the task's run config sets a dir and a user", func() {
+		BeforeEach(func() {
+			err := ioutil.WriteFile(
+				taskConfigPath,
+				[]byte(`---
+platform: some-platform
+
+image: ubuntu
+
+inputs:
+- name: fixture
+
+params:
+  FOO: bar
+  BAZ: buzz
+  X: 1
+
+run:
+  path: make
+  dir: src/app
+  user: root
+`),
+				0644,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			expectedPlan.OnSuccess.Next.Task.Config.Run = atc.TaskRunConfig{
+				Path: "make",
+			}
+		})
+
+		It("warns that the targeted ATC will ignore both", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			// sync with after create
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(sess.Err).NotTo(gbytes.Say("unknown field"))
+			Expect(sess.Err).To(gbytes.Say("warning: 'run.dir' is not supported by the targeted ATC and will be ignored"))
+			Expect(sess.Err).To(gbytes.Say("warning: 'run.user' is not supported by the targeted ATC and will be ignored"))
+		})
+
+		Context("when the dir is absolute", func() {
+			BeforeEach(func() {
+				err := ioutil.WriteFile(
+					taskConfigPath,
+					[]byte(`---
+platform: some-platform
+
+image: ubuntu
+
+inputs:
+- name: fixture
+
+run:
+  path: make
+  dir: /src/app
+`),
+					0644,
+				)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("fails without contacting the ATC", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+
+				Expect(sess.Err).To(gbytes.Say(`task.yml:11: 'run.dir' must be a path relative to the task's working directory, not '/src/app'`))
+				Expect(atcServer.ReceivedRequests()).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("when the task specifies more than one input", func() {
+
+		BeforeEach(func() {
+			// missing platform and run path
+			err := ioutil.WriteFile(
+				filepath.Join(buildDir, "task.yml"),
+				[]byte(`---
+platform: some-platform
+
+image: ubuntu
+
+inputs:
+- name: fixture
+- name: something
+
+params:
+  FOO: bar
+  BAZ: buzz
+  X: 1
+
+run:
+  path: find
+  args: [.]
+`),
+				0644,
+			)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		Context("When some required inputs are not passed", func() {
+			It("Prints an error", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "-i", "something=.")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("missing required input `fixture`"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+
+		})
+
+		Context("When no inputs are passed", func() {
+			It("Prints an error", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("missing required input"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+
+		})
+
+	})
+
+	Context("when running with --privileged", func() {
+		BeforeEach(func() {
+			expectedPlan.OnSuccess.Next.Task.Privileged = true
+		})
+
+		It("inserts them into the config template", func() {
+			atcServer.AllowUnhandledRequests = true
+
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--privileged")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			// sync with after create
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(uploadingBits).To(BeClosed())
+		})
+	})
+
+	Context("when both --config and --config-from are given", func() {
+		It("prints an error and exits 1", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--config-from", "some-pipeline/some-job")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess.Err).Should(gbytes.Say("only one of --config and --config-from may be specified"))
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
+		})
+	})
+
+	Context("when running with --tag", func() {
+		BeforeEach(func() {
+			expectedPlan.OnSuccess.Next.Task.Tags = []string{"gpu", "bosh-lite"}
+		})
+
+		It("adds the tags to the task plan", func() {
+			atcServer.AllowUnhandledRequests = true
+
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--tag", "gpu", "--tag", "bosh-lite")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			// sync with after create
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(uploadingBits).To(BeClosed())
+		})
+
+		Context("and --privileged", func() {
+			BeforeEach(func() {
+				expectedPlan.OnSuccess.Next.Task.Privileged = true
+			})
+
+			It("includes both in the task plan", func() {
+				atcServer.AllowUnhandledRequests = true
+
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--tag", "gpu", "--tag", "bosh-lite", "--privileged")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				// sync with after create
+				Eventually(streaming, 5.0).Should(BeClosed())
+
+				close(events)
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				Expect(uploadingBits).To(BeClosed())
+			})
+		})
+	})
+
+	Context("when running with --image", func() {
+		BeforeEach(func() {
+			expectedPlan.OnSuccess.Next.Task.Config.Image = "docker:///some/other-image"
+		})
+
+		It("overrides the image in the task config and prints it", func() {
+			atcServer.AllowUnhandledRequests = true
+
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--image", "docker:///some/other-image")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			// sync with after create
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			Eventually(sess.Err).Should(gbytes.Say("executing build 128 \\(image: docker:///some/other-image\\)"))
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(uploadingBits).To(BeClosed())
+		})
+	})
+
+	Context("when running with --build-name", func() {
+		BeforeEach(func() {
+			expectedPlan.OnSuccess.Next.Task.Name = "my-experiment"
+		})
+
+		It("names the task step and prints it", func() {
+			atcServer.AllowUnhandledRequests = true
+
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--build-name", "my-experiment")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			// sync with after create
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			Eventually(sess.Err).Should(gbytes.Say("executing build 128 as 'my-experiment'"))
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(uploadingBits).To(BeClosed())
+		})
+
+		Context("when the name is invalid", func() {
+			It("fails without contacting the ATC", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--build-name", "My Experiment")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+
+				Expect(sess.Err).To(gbytes.Say("invalid build name 'My Experiment'"))
+				Expect(atcServer.ReceivedRequests()).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("when running with --dry-run", func() {
+		It("prints the plan without creating pipes or a build", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--dry-run", "--privileged")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			var plan atc.Plan
+			err = json.Unmarshal(sess.Out.Contents(), &plan)
+			Expect(err).NotTo(HaveOccurred())
+
+			expectedPlan.OnSuccess.Step.Aggregate = &atc.AggregatePlan{
+				atc.Plan{
+					Location: &atc.Location{
+						ParallelGroup: 1,
+						ParentID:      0,
+						ID:            2,
+					},
+					Get: &atc.GetPlan{
+						Name: "fixture",
+						Type: "archive",
+						Source: atc.Source{
+							"uri": "((pipe:fixture))",
+						},
+					},
+				},
+			}
+			expectedPlan.OnSuccess.Next.Task.Privileged = true
+
+			Expect(plan).To(Equal(expectedPlan))
+
+			Expect(atcServer.ReceivedRequests()).To(BeEmpty())
+		})
+
+		Context("and --print-config", func() {
+			It("prints the config as it would be sent to stderr, and still only the plan to stdout", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--dry-run", "--print-config", "--param", "FOO=overridden", "--image", "some-image", "--", "extra-arg")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				Expect(sess.Err).To(gbytes.Say(`image: some-image`))
+				Expect(sess.Err).To(gbytes.Say(`params:\n  BAZ: buzz\n  FOO: overridden`))
+				Expect(sess.Err).To(gbytes.Say(`- extra-arg`))
+
+				var plan atc.Plan
+				err = json.Unmarshal(sess.Out.Contents(), &plan)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(atcServer.ReceivedRequests()).To(BeEmpty())
+			})
+
+			It("hides param values with --redact-params", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--dry-run", "--print-config", "--redact-params")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				Expect(sess.Err).To(gbytes.Say(`params:\n  BAZ: '\[redacted\]'\n  FOO: '\[redacted\]'`))
+				Expect(sess.Err.Contents()).NotTo(ContainSubstring("bar"))
+			})
+		})
+	})
+
+	Context("when running with --redact-params alone", func() {
+		It("fails without contacting the ATC", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--redact-params")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
+
+			Expect(sess.Err).To(gbytes.Say("--redact-params can only be used with --print-config"))
+			Expect(atcServer.ReceivedRequests()).To(BeEmpty())
+		})
+	})
+
+	Context("when running with bogus flags", func() {
+		It("exits 1", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--bogus-flag")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess.Err).Should(gbytes.Say("unknown flag `bogus-flag'"))
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
+		})
+	})
+
+	Context("when the ATC rejects the build", func() {
+		BeforeEach(func() {
+			atcServer.RouteToHandler("POST", "/api/v1/builds",
+				ghttp.RespondWith(http.StatusBadRequest, `{"errors":["invalid task config: missing platform"]}`),
+			)
+		})
+
+		It("prints the ATC's messages and exits 1", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess.Err).Should(gbytes.Say(`bad response creating build \(400 Bad Request\):`))
+			Eventually(sess.Err).Should(gbytes.Say("invalid task config: missing platform"))
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
+		})
+	})
+
+	Context("when creating a pipe fails", func() {
+		BeforeEach(func() {
+			atcServer.RouteToHandler("POST", "/api/v1/pipes",
+				ghttp.RespondWith(http.StatusInternalServerError, "no more pipes"),
+			)
+		})
+
+		It("names the endpoint that failed and exits 1", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess.Err).Should(gbytes.Say(`bad response creating pipe for input '.*' \(POST /api/v1/pipes\) \(500 Internal Server Error\):`))
+			Eventually(sess.Err).Should(gbytes.Say("no more pipes"))
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
+		})
+	})
+
+	Context("when the ATC is briefly unavailable", func() {
+		BeforeEach(func() {
+			attempts := 0
+
+			atcServer.RouteToHandler("POST", "/api/v1/pipes", func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+
+				if attempts == 1 {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+
+				ghttp.RespondWithJSONEncoded(http.StatusCreated, atc.Pipe{ID: "some-pipe-id"})(w, r)
+			})
+		})
+
+		It("retries, saying why", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess.Err).Should(gbytes.Say(`creating pipe failed \(502 Bad Gateway\); retrying \(attempt 2 of 5\)`))
+
+			Eventually(streaming, 5).Should(BeClosed())
+
+			events <- event.Status{Status: atc.StatusSucceeded}
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+		})
+
+		Context("when retries are turned off", func() {
+			It("fails straight away", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--retry", "1")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say(`bad response creating pipe for input '.*' \(POST /api/v1/pipes\) \(502 Bad Gateway\)`))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+	})
+
+	Context("when an input is larger than --max-input-size", func() {
+		BeforeEach(func() {
+			err := ioutil.WriteFile(filepath.Join(buildDir, "big"), make([]byte, 2048), 0644)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		execute := func(args ...string) *gexec.Session {
+			flyCmd := exec.Command(flyPath, append([]string{"-t", atcServer.URL(), "e", "-c", taskConfigPath, "--max-input-size", "1KiB"}, args...)...)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			return sess
+		}
+
+		It("lists its largest entries and refuses to upload it without a terminal", func() {
+			sess := execute()
+
+			Eventually(sess.Err).Should(gbytes.Say(`input 'fixture' is 2\.\d KiB, more than --max-input-size \(1\.0 KiB\):`))
+			Eventually(sess.Err).Should(gbytes.Say(`2\.0 KiB  big`))
+			Eventually(sess.Err).Should(gbytes.Say(`task\.yml`))
+			Eventually(sess.Err).Should(gbytes.Say(`not uploading input 'fixture'; exclude paths with --exclude, raise --max-input-size, or pass --force-upload to upload it anyway`))
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
+
+			for _, request := range atcServer.ReceivedRequests() {
+				Expect(request.URL.Path).NotTo(Equal("/api/v1/builds"))
+			}
+		})
+
+		succeeds := func(sess *gexec.Session) {
+			Eventually(streaming).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(uploadingBits).To(BeClosed())
+		}
+
+		It("uploads it anyway with --force-upload", func() {
+			succeeds(execute("--force-upload"))
+		})
+
+		It("does not count excluded paths", func() {
+			succeeds(execute("--exclude", "big"))
+		})
+	})
+
+	Context("when a compression level is given", func() {
+		var uploaded chan []byte
+
+		JustBeforeEach(func() {
+			uploaded = make(chan []byte, 1)
+
+			atcServer.RouteToHandler("PUT", "/api/v1/pipes/some-pipe-id", func(w http.ResponseWriter, r *http.Request) {
+				body, err := ioutil.ReadAll(r.Body)
+				Expect(err).NotTo(HaveOccurred())
+
+				uploaded <- body
+			})
+		})
+
+		upload := func(args ...string) []byte {
+			flyCmd := exec.Command(flyPath, append([]string{"-t", atcServer.URL(), "e", "-c", taskConfigPath}, args...)...)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			var body []byte
+			Eventually(uploaded).Should(Receive(&body))
+
+			Eventually(streaming).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			gr, err := gzip.NewReader(bytes.NewReader(body))
+			Expect(err).NotTo(HaveOccurred())
+
+			hdr, err := tar.NewReader(gr).Next()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hdr.Name).To(Equal("./"))
+
+			return body
+		}
+
+		// byte 8 of a gzip header flags the compressor's effort, and the
+		// two bits after the first of the deflate stream give the type of
+		// its first block
+		const extraFlags = 8
+		const firstBlock = 10
+
+		It("compresses as hard as it can with best", func() {
+			Expect(upload("--compression", "best")[extraFlags]).To(Equal(byte(2)))
+		})
+
+		It("compresses as quickly as it can with fast", func() {
+			Expect(upload("--compression", "fast")[extraFlags]).To(Equal(byte(4)))
+		})
+
+		It("stores the archive uncompressed, but still gzipped, with none", func() {
+			Expect((upload("--compression", "none")[firstBlock] >> 1) & 3).To(Equal(byte(0)))
+		})
+
+		It("stores the archive uncompressed with --no-compress", func() {
+			Expect((upload("--no-compress")[firstBlock] >> 1) & 3).To(Equal(byte(0)))
+		})
+
+		It("compresses it by default", func() {
+			Expect((upload()[firstBlock] >> 1) & 3).NotTo(Equal(byte(0)))
+		})
+	})
+
+	Context("when uploading the bits fails briefly", func() {
+		var uploads int
+
+		JustBeforeEach(func() {
+			uploads = 0
+
+			atcServer.RouteToHandler("PUT", "/api/v1/pipes/some-pipe-id", func(w http.ResponseWriter, r *http.Request) {
+				uploads++
+
+				_, err := io.Copy(ioutil.Discard, r.Body)
+				Expect(err).NotTo(HaveOccurred())
+
+				if uploads == 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+
+				w.WriteHeader(http.StatusOK)
+			})
+		})
+
+		It("streams the input again, saying why", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess.Err).Should(gbytes.Say(`uploading .* failed \(503 Service Unavailable\); retrying \(attempt 2 of 5\)`))
+
+			Eventually(streaming, 5).Should(BeClosed())
+
+			events <- event.Status{Status: atc.StatusSucceeded}
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+			Expect(uploads).To(Equal(2))
+		})
+	})
+
+	Context("when parameters are specified in the environment", func() {
+		BeforeEach(func() {
+			expectedPlan.OnSuccess.Next.Task.Config.Params = map[string]string{
+				"FOO": "newbar",
+				"BAZ": "buzz",
+				"X":   "",
+			}
+		})
+
+		It("overrides the build's parameter values", func() {
+			atcServer.AllowUnhandledRequests = true
+
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+			flyCmd.Dir = buildDir
+			flyCmd.Env = append(os.Environ(), "FOO=newbar", "X=")
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			// sync with after create
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			Expect(sess.Err).To(gbytes.Say("params:"))
+			Expect(sess.Err).To(gbytes.Say("FOO: \\[redacted\\]"))
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(uploadingBits).To(BeClosed())
+		})
+
+		Context("with --no-env-params", func() {
+			BeforeEach(func() {
+				expectedPlan.OnSuccess.Next.Task.Config.Params = map[string]string{
+					"FOO": "bar",
+					"BAZ": "buzz",
+					"X":   "1",
+				}
+			})
+
+			It("does not override the build's parameter values", func() {
+				atcServer.AllowUnhandledRequests = true
+
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--no-env-params")
+				flyCmd.Dir = buildDir
+				flyCmd.Env = append(os.Environ(), "FOO=newbar", "X=")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				// sync with after create
+				Eventually(streaming, 5.0).Should(BeClosed())
+
+				close(events)
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+			})
+		})
+
+		Context("with --env for an undeclared variable", func() {
+			BeforeEach(func() {
+				expectedPlan.OnSuccess.Next.Task.Config.Params["EXTRA"] = "extra-value"
+			})
+
+			It("forwards it into the build's params", func() {
+				atcServer.AllowUnhandledRequests = true
+
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--env", "EXTRA", "--show-params")
+				flyCmd.Dir = buildDir
+				flyCmd.Env = append(os.Environ(), "FOO=newbar", "X=", "EXTRA=extra-value")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				// sync with after create
+				Eventually(streaming, 5.0).Should(BeClosed())
+
+				Expect(sess.Err).To(gbytes.Say("EXTRA: extra-value"))
+
+				close(events)
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+			})
+		})
+	})
+
+	Context("when parameters are specified as flags", func() {
+		BeforeEach(func() {
+			expectedPlan.OnSuccess.Next.Task.Config.Params = map[string]string{
+				"FOO": "from=flag",
+				"BAZ": "buzz",
+				"X":   "",
+			}
+		})
+
+		It("overrides the build's parameter values, taking precedence over the environment", func() {
+			atcServer.AllowUnhandledRequests = true
+
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--param", "FOO=from=flag", "--param", "X=")
+			flyCmd.Dir = buildDir
+			flyCmd.Env = append(os.Environ(), "FOO=newbar")
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			// sync with after create
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(uploadingBits).To(BeClosed())
+		})
+
+		Context("when the param is not declared by the task config", func() {
+			It("prints an error and exits 1", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--param", "FOOO=typo")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("unknown param 'FOOO'"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+	})
+
+	Context("when variables are loaded from files", func() {
+		var varsPath string
+		var otherVarsPath string
+
+		BeforeEach(func() {
+			err := ioutil.WriteFile(
+				taskConfigPath,
+				[]byte(`---
+platform: some-platform
+
+image: {{image}}
+
+inputs:
+- name: fixture
+
+params:
+  FOO: {{foo}}
+  BAZ: buzz
+  X: 1
+
+run:
+  path: find
+  args: [.]
+`),
+				0644,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			varsPath = filepath.Join(tmpdir, "vars.yml")
+			err = ioutil.WriteFile(varsPath, []byte("image: ubuntu\nfoo: bar\n"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			otherVarsPath = filepath.Join(tmpdir, "other-vars.yml")
+			err = ioutil.WriteFile(otherVarsPath, []byte("foo: newbar\n"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			expectedPlan.OnSuccess.Next.Task.Config.Params["FOO"] = "newbar"
+		})
+
+		It("templates them into the config, with later files taking precedence", func() {
+			atcServer.AllowUnhandledRequests = true
+
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "-l", varsPath, "-l", otherVarsPath)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			// sync with after create
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(uploadingBits).To(BeClosed())
+		})
+
+		Context("when variables are also passed as flags", func() {
+			BeforeEach(func() {
+				expectedPlan.OnSuccess.Next.Task.Config.Params["FOO"] = "from=flag"
+			})
+
+			It("gives the flags precedence over the files", func() {
+				atcServer.AllowUnhandledRequests = true
+
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "-l", varsPath, "-v", "foo=from=flag", "-v", "unused=value")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("warning: variable 'unused' is not used by the task config"))
+
+				// sync with after create
+				Eventually(streaming, 5.0).Should(BeClosed())
+
+				close(events)
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				Expect(uploadingBits).To(BeClosed())
+			})
+		})
+
+		Context("when a variable is not provided", func() {
+			It("prints the missing variables and exits 1", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "-l", otherVarsPath)
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("unbound variable in template: 'image'"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+	})
+
+	Context("when the build is interrupted", func() {
+		var aborted chan struct{}
+
+		JustBeforeEach(func() {
+			aborted = make(chan struct{})
+
+			atcServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v1/builds/128/abort"),
+					func(w http.ResponseWriter, r *http.Request) {
+						close(aborted)
+					},
+				),
+			)
+		})
+
+		if runtime.GOOS != "windows" {
+			Describe("with SIGINT", func() {
+				It("aborts the build and exits 3", func() {
+					flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+					flyCmd.Dir = buildDir
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).ToNot(HaveOccurred())
+
+					Eventually(streaming, 5).Should(BeClosed())
+
+					Eventually(uploadingBits).Should(BeClosed())
 
 					sess.Signal(os.Interrupt)
 
 					Eventually(aborted, 5.0).Should(BeClosed())
 
-					events <- event.Status{Status: atc.StatusErrored}
+					events <- event.Status{Status: atc.StatusAborted}
 					close(events)
 
 					<-sess.Exited
-					Expect(sess.ExitCode()).To(Equal(2))
+					Expect(sess.ExitCode()).To(Equal(3))
 				})
 			})
 
 			Describe("with SIGTERM", func() {
-				It("aborts the build and exits nonzero", func() {
+				It("aborts the build and exits 3", func() {
 					flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
 					flyCmd.Dir = buildDir
 
@@ -524,14 +2122,68 @@ run:
 
 					Eventually(aborted, 5.0).Should(BeClosed())
 
-					events <- event.Status{Status: atc.StatusErrored}
+					events <- event.Status{Status: atc.StatusAborted}
 					close(events)
 
 					<-sess.Exited
-					Expect(sess.ExitCode()).To(Equal(2))
+					Expect(sess.ExitCode()).To(Equal(3))
+				})
+			})
+		}
+
+		if runtime.GOOS != "windows" {
+			Describe("with a second SIGINT", func() {
+				It("detaches from the build without waiting for it to finish", func() {
+					flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+					flyCmd.Dir = buildDir
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).ToNot(HaveOccurred())
+
+					Eventually(streaming, 5).Should(BeClosed())
+
+					Eventually(uploadingBits).Should(BeClosed())
+
+					sess.Signal(os.Interrupt)
+
+					Eventually(aborted, 5.0).Should(BeClosed())
+
+					sess.Signal(os.Interrupt)
+
+					Eventually(sess.Err).Should(gbytes.Say("detached from build 128 at " + atcServer.URL() + "/builds/128"))
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(4))
+
+					close(events)
 				})
 			})
 		}
+
+		Describe("by --timeout", func() {
+			It("aborts the build once the timeout elapses and exits 2", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--timeout", "1s")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(streaming, 5).Should(BeClosed())
+
+				Eventually(uploadingBits).Should(BeClosed())
+
+				Consistently(aborted, 0.5).ShouldNot(BeClosed())
+				Eventually(aborted, 5.0).Should(BeClosed())
+
+				Eventually(sess.Err).Should(gbytes.Say("timed out after 1s"))
+
+				events <- event.Status{Status: atc.StatusSucceeded}
+				close(events)
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(2))
+			})
+		})
 	})
 
 	Context("when the target has an auth token", func() {
@@ -563,6 +2215,7 @@ run:
 				atcServer.URL(),
 				true,
 				&token,
+				"",
 			)
 			Expect(err).ToNot(HaveOccurred())
 
@@ -570,6 +2223,13 @@ run:
 				"uri":           atcServer.URL() + "/api/v1/pipes/some-pipe-id",
 				"authorization": "Bearer some-token",
 			}
+
+			atcServer.RouteToHandler("GET", "/api/v1/workers",
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("Authorization", "Bearer some-token"),
+					ghttp.RespondWithJSONEncoded(200, []atc.Worker{}),
+				),
+			)
 		})
 
 		AfterEach(func() {
@@ -593,6 +2253,82 @@ run:
 
 			Expect(uploadingBits).To(BeClosed())
 		})
+
+		createdPipes := func() int {
+			count := 0
+			for _, request := range atcServer.ReceivedRequests() {
+				if request.Method == "POST" && request.URL.Path == "/api/v1/pipes" {
+					count++
+				}
+			}
+
+			return count
+		}
+
+		Context("when the token is rejected", func() {
+			BeforeEach(func() {
+				atcServer.RouteToHandler("GET", "/api/v1/workers", ghttp.RespondWith(401, ""))
+			})
+
+			It("fails before uploading anything", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath)
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+
+				Expect(sess.Err).To(gbytes.Say("token for target 'foo' has expired, run fly login -t foo"))
+				Expect(createdPipes()).To(BeZero())
+			})
+
+			Context("with --skip-auth-check", func() {
+				It("does not check the token", func() {
+					flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--skip-auth-check")
+					flyCmd.Dir = buildDir
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).ToNot(HaveOccurred())
+
+					Eventually(streaming, 5).Should(BeClosed())
+
+					events <- event.Status{Status: atc.StatusSucceeded}
+					close(events)
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when the token is a JWT that has expired", func() {
+			BeforeEach(func() {
+				segment := func(s string) string {
+					return base64.RawURLEncoding.EncodeToString([]byte(s))
+				}
+
+				expiredJWT := segment(`{"alg":"RS256"}`) + "." + segment(fmt.Sprintf(`{"exp":%d}`, time.Now().Add(-time.Hour).Unix())) + "." + segment("signature")
+
+				err := rc.SaveTarget(targetName, atcServer.URL(), true, &rc.TargetToken{Type: "Bearer", Value: expiredJWT}, "")
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("fails without making any requests", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath)
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+
+				Expect(sess.Err).To(gbytes.Say("token for target 'foo' has expired, run fly login -t foo"))
+				Expect(atcServer.ReceivedRequests()).To(BeEmpty())
+			})
+		})
 	})
 
 	Context("when the build succeeds", func() {
@@ -615,6 +2351,230 @@ run:
 		})
 	})
 
+	Context("when the task writes to stderr", func() {
+		It("writes it to stderr, keeping stdout for the task's stdout", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(streaming, 5).Should(BeClosed())
+
+			events <- event.Log{Payload: "to stdout\n", Origin: event.Origin{Source: event.OriginSourceStdout}}
+			events <- event.Log{Payload: "to stderr\n", Origin: event.Origin{Source: event.OriginSourceStderr}}
+			events <- event.Status{Status: atc.StatusSucceeded}
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(string(sess.Out.Contents())).To(Equal("to stdout\n"))
+			Expect(sess.Err).To(gbytes.Say("executing build 128"))
+			Expect(sess.Err).To(gbytes.Say("to stderr"))
+			Expect(sess.Err).To(gbytes.Say("succeeded"))
+		})
+
+		Context("with --merge-output", func() {
+			It("writes everything to stdout", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--merge-output")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(streaming, 5).Should(BeClosed())
+
+				events <- event.Log{Payload: "to stdout\n", Origin: event.Origin{Source: event.OriginSourceStdout}}
+				events <- event.Log{Payload: "to stderr\n", Origin: event.Origin{Source: event.OriginSourceStderr}}
+				events <- event.Status{Status: atc.StatusSucceeded}
+				close(events)
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				Expect(sess.Out).To(gbytes.Say("executing build 128"))
+				Expect(sess.Out).To(gbytes.Say("to stdout\nto stderr\n"))
+				Expect(sess.Out).To(gbytes.Say("succeeded"))
+				Expect(sess.Err).NotTo(gbytes.Say("to stderr"))
+			})
+		})
+	})
+
+	Context("when running with --format json", func() {
+		It("prints only events and a summary to stdout, as lines of JSON", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--format", "json")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(streaming, 5).Should(BeClosed())
+
+			events <- event.Log{Payload: "sup"}
+			events <- event.Status{Status: atc.StatusSucceeded}
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			lines := strings.Split(strings.TrimSpace(string(sess.Out.Contents())), "\n")
+			Expect(lines).To(HaveLen(3))
+
+			var summary map[string]interface{}
+			err = json.Unmarshal([]byte(lines[2]), &summary)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(summary["event"]).To(Equal("fly-summary"))
+			Expect(summary["status"]).To(Equal("succeeded"))
+			Expect(summary["exit_code"]).To(Equal(float64(0)))
+			Expect(summary["build_id"]).To(Equal(float64(128)))
+
+			Expect(sess.Err).NotTo(gbytes.Say("executing build"))
+		})
+	})
+
+	Context("when running with --log-file", func() {
+		It("also writes the build's output to the file, without colors", func() {
+			logPath := filepath.Join(tmpdir, "build.log")
+
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--log-file", logPath, "--color", "always")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(streaming, 5).Should(BeClosed())
+
+			events <- event.Log{Payload: "sup\n"}
+			events <- event.Status{Status: atc.StatusSucceeded}
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(sess.Out).To(gbytes.Say("sup"))
+
+			contents, err := ioutil.ReadFile(logPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			log := gbytes.BufferWithBytes(contents)
+			Expect(log).To(gbytes.Say("build: 128"))
+			Expect(log).To(gbytes.Say("url: " + atcServer.URL() + "/builds/128"))
+			Expect(log).To(gbytes.Say("config:"))
+			Expect(log).To(gbytes.Say("platform: some-platform"))
+			Expect(log).To(gbytes.Say("FOO: \\[redacted\\]"))
+			Expect(log).To(gbytes.Say("executing build 128"))
+			Expect(log).To(gbytes.Say("sup\n"))
+			Expect(log).To(gbytes.Say("succeeded\n"))
+
+			Expect(string(contents)).NotTo(ContainSubstring("\x1b"))
+		})
+
+		It("fails before creating the build if the file can't be created", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--log-file", filepath.Join(tmpdir, "bogus", "build.log"))
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
+
+			Expect(sess.Err).To(gbytes.Say("failed to create log file"))
+			Expect(streaming).NotTo(BeClosed())
+		})
+
+		It("rejects -", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--log-file", "-")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
+
+			Expect(sess.Err).To(gbytes.Say("use --format json"))
+		})
+	})
+
+	Context("when running with --quiet", func() {
+		It("prints only the build's status and errors", func() {
+			logPath := filepath.Join(tmpdir, "build.log")
+
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--quiet", "--log-file", logPath)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(streaming, 5).Should(BeClosed())
+
+			events <- event.Log{Payload: "sup\n"}
+			events <- event.Log{Payload: "uh oh\n", Origin: event.Origin{Source: event.OriginSourceStderr}}
+			events <- event.Error{Message: "something went wrong"}
+			events <- event.Status{Status: atc.StatusErrored}
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(2))
+
+			Expect(sess.Out.Contents()).To(BeEmpty())
+			Expect(sess.Err).To(gbytes.Say(atcServer.URL() + "/builds/128"))
+			Expect(sess.Err).To(gbytes.Say("something went wrong"))
+			Expect(sess.Err).To(gbytes.Say("errored"))
+			Expect(string(sess.Err.Contents())).NotTo(ContainSubstring("uh oh"))
+
+			contents, err := ioutil.ReadFile(logPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("sup\n"))
+			Expect(string(contents)).To(ContainSubstring("uh oh\n"))
+		})
+	})
+
+	Context("when the build finishes", func() {
+		It("prints a summary of how long each phase took", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(streaming, 5).Should(BeClosed())
+
+			events <- event.Status{Status: atc.StatusSucceeded}
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(sess.Err).To(gbytes.Say(`build 128 succeeded in \d+s \(upload \d+s, run \d+s\)`))
+		})
+
+		Context("with an error", func() {
+			It("includes the first error in the summary", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(streaming, 5).Should(BeClosed())
+
+				events <- event.Error{Message: "no workers"}
+				events <- event.Error{Message: "giving up"}
+				events <- event.Status{Status: atc.StatusErrored}
+				close(events)
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(2))
+
+				Expect(sess.Err).To(gbytes.Say(`build 128 errored in \d+s \(.*\): no workers`))
+			})
+		})
+	})
+
 	Context("when the build fails", func() {
 		It("exits 1", func() {
 			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath)
@@ -625,14 +2585,41 @@ run:
 
 			Eventually(streaming, 5).Should(BeClosed())
 
+			Eventually(sess.Err).Should(gbytes.Say(atcServer.URL() + "/builds/128"))
+
 			events <- event.Status{Status: atc.StatusFailed}
 			close(events)
 
+			Eventually(sess.Err).Should(gbytes.Say("failed"))
+			Eventually(sess.Err).Should(gbytes.Say(atcServer.URL() + "/builds/128"))
+
 			<-sess.Exited
 			Expect(sess.ExitCode()).To(Equal(1))
 
 			Expect(uploadingBits).To(BeClosed())
 		})
+
+		Context("with --hijack-on-failure and stdin is not a terminal", func() {
+			It("warns that the flag is ignored and exits with the build's exit code", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--hijack-on-failure")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("warning: ignoring --hijack-on-failure as stdin is not a terminal"))
+
+				Eventually(streaming, 5).Should(BeClosed())
+
+				events <- event.Status{Status: atc.StatusFailed}
+				close(events)
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+
+				Expect(sess.Err).ToNot(gbytes.Say("hijacking"))
+			})
+		})
 	})
 
 	Context("when the build errors", func() {
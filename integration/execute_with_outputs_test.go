@@ -2,6 +2,7 @@ package integration_test
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
 	"encoding/json"
 	"fmt"
@@ -332,6 +333,259 @@ run:
 			})
 		})
 
+		Context("when the output directory does not exist", func() {
+			It("creates it, including its parents", func() {
+				atcServer.AllowUnhandledRequests = true
+
+				nestedDir := filepath.Join(outputDir, "does", "not-exist-yet")
+
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--output", "some-dir="+nestedDir)
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				// sync with after create
+				Eventually(streaming, 5.0).Should(BeClosed())
+
+				close(events)
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				data, err := ioutil.ReadFile(filepath.Join(nestedDir, "some-file"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(data).To(Equal([]byte("tar-contents")))
+			})
+		})
+
+		Context("when the output path is a .tgz file", func() {
+			It("saves the archive without extracting it", func() {
+				atcServer.AllowUnhandledRequests = true
+
+				archivePath := filepath.Join(outputDir, "artifacts.tgz")
+
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--output", "some-dir="+archivePath)
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				// sync with after create
+				Eventually(streaming, 5.0).Should(BeClosed())
+
+				close(events)
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				archive, err := os.Open(archivePath)
+				Expect(err).NotTo(HaveOccurred())
+				defer archive.Close()
+
+				gr, err := gzip.NewReader(archive)
+				Expect(err).NotTo(HaveOccurred())
+
+				hdr, err := tar.NewReader(gr).Next()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(hdr.Name).To(Equal("some-file"))
+
+				outputFiles, err := ioutil.ReadDir(outputDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(outputFiles).To(HaveLen(1))
+			})
+		})
+
+		Context("when the output path is -", func() {
+			It("streams the archive to stdout, logging to stderr", func() {
+				atcServer.AllowUnhandledRequests = true
+
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--output", "some-dir=-")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				// sync with after create
+				Eventually(streaming, 5.0).Should(BeClosed())
+
+				Eventually(sess.Err).Should(gbytes.Say("executing build 128"))
+
+				close(events)
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				gr, err := gzip.NewReader(bytes.NewReader(sess.Out.Contents()))
+				Expect(err).NotTo(HaveOccurred())
+
+				hdr, err := tar.NewReader(gr).Next()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(hdr.Name).To(Equal("some-file"))
+			})
+		})
+
+		Context("when the output pipe is closed partway through", func() {
+			JustBeforeEach(func() {
+				atcServer.AllowUnhandledRequests = true
+
+				atcServer.RouteToHandler("GET", "/api/v1/pipes/some-other-pipe-id", func(w http.ResponseWriter, req *http.Request) {
+					buf := new(bytes.Buffer)
+
+					gw := gzip.NewWriter(buf)
+					tw := tar.NewWriter(gw)
+
+					tarContents := bytes.Repeat([]byte("tar-contents"), 1024)
+
+					err := tw.WriteHeader(&tar.Header{
+						Name: "some-file",
+						Mode: 0644,
+						Size: int64(len(tarContents)),
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = tw.Write(tarContents)
+					Expect(err).NotTo(HaveOccurred())
+
+					err = tw.Close()
+					Expect(err).NotTo(HaveOccurred())
+
+					err = gw.Close()
+					Expect(err).NotTo(HaveOccurred())
+
+					w.Write(buf.Bytes()[:buf.Len()/2])
+					w.(http.Flusher).Flush()
+
+					conn, _, err := w.(http.Hijacker).Hijack()
+					Expect(err).NotTo(HaveOccurred())
+
+					conn.Close()
+				})
+			})
+
+			run := func(outputPath string) *gexec.Session {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--output", "some-dir="+outputPath)
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				// sync with after create
+				Eventually(streaming, 5.0).Should(BeClosed())
+
+				close(events)
+
+				<-sess.Exited
+
+				return sess
+			}
+
+			It("exits 2 even though the build succeeded, marking the directory as incomplete", func() {
+				sess := run(outputDir)
+
+				Expect(sess.ExitCode()).To(Equal(2))
+				Expect(sess.Err).To(gbytes.Say("failed to download outputs"))
+				Expect(sess.Err).To(gbytes.Say("some-dir: .*marked with .fly-partial"))
+
+				Expect(filepath.Join(outputDir, ".fly-partial")).To(BeAnExistingFile())
+			})
+
+			It("exits 2 without leaving an archive behind", func() {
+				archivePath := filepath.Join(outputDir, "artifacts.tgz")
+
+				sess := run(archivePath)
+
+				Expect(sess.ExitCode()).To(Equal(2))
+				Expect(sess.Err).To(gbytes.Say("some-dir: unexpected EOF"))
+
+				outputFiles, err := ioutil.ReadDir(outputDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(outputFiles).To(BeEmpty())
+			})
+
+			It("exits 2 after streaming what arrived to stdout", func() {
+				sess := run("-")
+
+				Expect(sess.ExitCode()).To(Equal(2))
+				Expect(sess.Err).To(gbytes.Say("some-dir: unexpected EOF"))
+			})
+		})
+
+		Context("when more than one output path is -", func() {
+			BeforeEach(func() {
+				err := ioutil.WriteFile(
+					taskConfigPath,
+					[]byte(`---
+platform: some-platform
+
+image: ubuntu
+
+inputs:
+- name: fixture
+
+outputs:
+- name: some-dir
+- name: some-other-dir
+
+run:
+  path: ls
+`),
+					0644,
+				)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("exits 1", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "-o", "some-dir=-", "-o", "some-other-dir=-")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("error: only one output may be written to stdout"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+
+			It("exits 1 when two outputs would be written to the same directory", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "-o", "some-dir="+outputDir, "-o", "some-other-dir="+filepath.Join(outputDir, "nested"))
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("error: outputs 'some-dir' and 'some-other-dir' would both be written to"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+
+		Context("when the output path is a file", func() {
+			It("exits 1 without creating a build", func() {
+				filePath := filepath.Join(outputDir, "some-file")
+
+				err := ioutil.WriteFile(filePath, []byte("not a directory"), 0644)
+				Expect(err).NotTo(HaveOccurred())
+
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--output", "some-dir="+filePath)
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("error: output path '.*' exists and is not a directory"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+
+				for _, req := range atcServer.ReceivedRequests() {
+					Expect(req.URL.Path).NotTo(Equal("/api/v1/builds"))
+				}
+			})
+		})
+
 		Context("when the task does not specify those outputs", func() {
 			It("exits 1", func() {
 				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "-o", "wrong-output=wrong-path")
@@ -347,4 +601,77 @@ run:
 			})
 		})
 	})
+
+	Context("when running with --output-mapping", func() {
+		var mappedDir string
+
+		BeforeEach(func() {
+			mappedDir = filepath.Join(outputDir, "mapped")
+		})
+
+		It("downloads the task's output into the mapped directory", func() {
+			atcServer.AllowUnhandledRequests = true
+
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--output-mapping", "some-dir="+mappedDir)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			// sync with after create
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			data, err := ioutil.ReadFile(filepath.Join(mappedDir, "some-file"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(data).To(Equal([]byte("tar-contents")))
+		})
+
+		Context("when --output is also given for the output", func() {
+			It("downloads to the path given by --output", func() {
+				atcServer.AllowUnhandledRequests = true
+
+				explicitDir := filepath.Join(outputDir, "explicit")
+
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--output-mapping", "some-dir="+mappedDir, "-o", "some-dir="+explicitDir)
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				// sync with after create
+				Eventually(streaming, 5.0).Should(BeClosed())
+
+				close(events)
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				_, err = os.Stat(filepath.Join(explicitDir, "some-file"))
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = os.Stat(mappedDir)
+				Expect(os.IsNotExist(err)).To(BeTrue())
+			})
+		})
+
+		Context("when the task does not declare the mapped output", func() {
+			It("exits 1", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "e", "-c", taskConfigPath, "--output-mapping", "wrong-output="+mappedDir)
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("error: unknown output 'wrong-output'"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+	})
 })
@@ -0,0 +1,94 @@
+package integration_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/concourse/fly/rc"
+	"github.com/concourse/fly/ui"
+	"github.com/fatih/color"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("choosing the flyrc", func() {
+		var flyrcDir string
+		var envFlyrc string
+		var flagFlyrc string
+
+		BeforeEach(func() {
+			var err error
+
+			flyrcDir, err = ioutil.TempDir("", "fly-test")
+			Expect(err).NotTo(HaveOccurred())
+
+			envFlyrc = filepath.Join(flyrcDir, "env", "flyrc")
+			flagFlyrc = filepath.Join(flyrcDir, "flag", "flyrc")
+
+			os.Setenv("FLYRC", envFlyrc)
+			err = rc.SaveTarget("from-env", "https://env.example.com", false, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			os.Setenv("FLYRC", flagFlyrc)
+			err = rc.SaveTarget("from-flag", "https://flag.example.com", false, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			os.Setenv("FLYRC", "")
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(flyrcDir)
+		})
+
+		targetsTable := func(name string, url string) ui.Table {
+			return ui.Table{
+				Headers: ui.TableRow{
+					{Contents: "name", Color: color.New(color.Bold)},
+					{Contents: "url", Color: color.New(color.Bold)},
+					{Contents: "insecure", Color: color.New(color.Bold)},
+					{Contents: "expiry", Color: color.New(color.Bold)},
+				},
+				Data: []ui.TableRow{
+					{{Contents: name}, {Contents: url}, {Contents: "false"}, {Contents: "n/a", Color: color.New(color.Faint)}},
+				},
+			}
+		}
+
+		It("uses the file in $FLYRC", func() {
+			flyCmd := exec.Command(flyPath, "targets")
+			flyCmd.Env = append(os.Environ(), "FLYRC="+envFlyrc)
+
+			Expect(flyCmd).To(PrintTable(targetsTable("from-env", "https://env.example.com")))
+		})
+
+		It("prefers the file given with --flyrc", func() {
+			flyCmd := exec.Command(flyPath, "--flyrc", flagFlyrc, "targets")
+			flyCmd.Env = append(os.Environ(), "FLYRC="+envFlyrc)
+
+			Expect(flyCmd).To(PrintTable(targetsTable("from-flag", "https://flag.example.com")))
+		})
+
+		It("saves to the file given with --flyrc", func() {
+			flyCmd := exec.Command(flyPath, "--flyrc", flagFlyrc, "rename-target", "-o", "from-flag", "-n", "renamed")
+			flyCmd.Env = append(os.Environ(), "FLYRC="+envFlyrc)
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			flagContents, err := ioutil.ReadFile(flagFlyrc)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(flagContents)).To(ContainSubstring("renamed"))
+
+			envContents, err := ioutil.ReadFile(envFlyrc)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(envContents)).To(ContainSubstring("from-env"))
+		})
+	})
+})
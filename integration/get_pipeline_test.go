@@ -118,6 +118,18 @@ var _ = Describe("Fly CLI", func() {
 					Expect(printedConfig).To(Equal(config))
 				})
 
+				It("heads the yaml with the config version", func() {
+					flyCmd := exec.Command(flyPath, "-t", atcServer.URL()+"/", "get-pipeline", "--pipeline", "some-pipeline")
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(0))
+
+					Expect(string(sess.Out.Contents())).To(HavePrefix("# version: 42\n"))
+				})
+
 				Context("when -j is given", func() {
 					It("prints the config as json to stdout", func() {
 						flyCmd := exec.Command(flyPath, "-t", atcServer.URL()+"/", "get-pipeline", "--pipeline", "some-pipeline", "-j")
@@ -136,6 +148,33 @@ var _ = Describe("Fly CLI", func() {
 					})
 				})
 			})
+
+			Context("when the pipeline does not exist", func() {
+				BeforeEach(func() {
+					path, err := atc.Routes.CreatePathForRoute(atc.GetConfig, rata.Params{"pipeline_name": "some-pipeline"})
+					Expect(err).NotTo(HaveOccurred())
+
+					atcServer.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", path),
+							ghttp.RespondWith(http.StatusNotFound, ""),
+						),
+					)
+				})
+
+				It("says so and exits 1", func() {
+					flyCmd := exec.Command(flyPath, "-t", atcServer.URL()+"/", "get-pipeline", "--pipeline", "some-pipeline")
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(1))
+
+					Expect(sess.Err).To(gbytes.Say("pipeline 'some-pipeline' not found"))
+					Expect(sess.Out.Contents()).To(BeEmpty())
+				})
+			})
 		})
 	})
 })
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"os/exec"
+	"strings"
 
 	"github.com/concourse/atc"
 	"github.com/mgutz/ansi"
@@ -158,6 +159,85 @@ var _ = Describe("Hijacking", func() {
 		})
 	})
 
+	Context("when stdin is piped in", func() {
+		BeforeEach(func() {
+			didHijack := make(chan struct{})
+			hijacked = didHijack
+
+			atcServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/builds/2"),
+					ghttp.RespondWithJSONEncoded(200, atc.Build{ID: 2, Name: "2", Status: "started"}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/containers", "build-id=2&name=some-step"),
+					ghttp.RespondWithJSONEncoded(200, []atc.Container{
+						{ID: "container-id-1", Type: "task", Name: "some-step", BuildID: 2},
+					}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v1/containers/container-id-1/hijack"),
+					func(w http.ResponseWriter, r *http.Request) {
+						defer GinkgoRecover()
+
+						w.WriteHeader(http.StatusOK)
+
+						var processSpec atc.HijackProcessSpec
+						err := json.NewDecoder(r.Body).Decode(&processSpec)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(processSpec.Path).To(Equal("cat"))
+						Expect(processSpec.TTY).To(BeNil())
+
+						sconn, sbr, err := w.(http.Hijacker).Hijack()
+						Expect(err).NotTo(HaveOccurred())
+
+						defer sconn.Close()
+
+						close(didHijack)
+
+						decoder := json.NewDecoder(sbr)
+						encoder := json.NewEncoder(sconn)
+
+						var stdin []byte
+						for {
+							var payload atc.HijackInput
+							err := decoder.Decode(&payload)
+							Expect(err).NotTo(HaveOccurred())
+
+							if payload.Closed {
+								break
+							}
+
+							stdin = append(stdin, payload.Stdin...)
+						}
+
+						err = encoder.Encode(atc.HijackOutput{Stdout: stdin})
+						Expect(err).NotTo(HaveOccurred())
+
+						exitStatus := 0
+						err = encoder.Encode(atc.HijackOutput{ExitStatus: &exitStatus})
+						Expect(err).NotTo(HaveOccurred())
+					},
+				),
+			)
+		})
+
+		It("closes the process's stdin when the input ends", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "intercept", "-b", "2", "-s", "some-step", "--", "cat")
+			flyCmd.Stdin = strings.NewReader("some piped input")
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(hijacked).Should(BeClosed())
+
+			Eventually(sess.Out).Should(gbytes.Say("some piped input"))
+
+			Eventually(sess).Should(gexec.Exit(0))
+		})
+	})
+
 	Context("when no containers are found", func() {
 		BeforeEach(func() {
 			didHijack := make(chan struct{})
@@ -1,10 +1,12 @@
 package integration_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"runtime"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
@@ -14,13 +16,37 @@ import (
 
 var flyPath string
 
+// versionedFlyPath is a release build of fly, as opposed to a development one
+var versionedFlyPath string
+
+const (
+	versionedFlyVersion   = "1.2.0"
+	versionedFlyGitSHA    = "abc123"
+	versionedFlyBuildDate = "2016-05-01T12:00:00Z"
+)
+
 var _ = SynchronizedBeforeSuite(func() []byte {
 	binPath, err := gexec.Build("github.com/concourse/fly")
 	Expect(err).NotTo(HaveOccurred())
 
-	return []byte(binPath)
+	versionedBinPath, err := gexec.Build("github.com/concourse/fly", "-ldflags", strings.Join([]string{
+		"-X github.com/concourse/fly/version.Version=" + versionedFlyVersion,
+		"-X github.com/concourse/fly/version.GitSHA=" + versionedFlyGitSHA,
+		"-X github.com/concourse/fly/version.BuildDate=" + versionedFlyBuildDate,
+	}, " "))
+	Expect(err).NotTo(HaveOccurred())
+
+	data, err := json.Marshal([]string{binPath, versionedBinPath})
+	Expect(err).NotTo(HaveOccurred())
+
+	return data
 }, func(data []byte) {
-	flyPath = string(data)
+	var paths []string
+	err := json.Unmarshal(data, &paths)
+	Expect(err).NotTo(HaveOccurred())
+
+	flyPath = paths[0]
+	versionedFlyPath = paths[1]
 })
 
 var _ = SynchronizedAfterSuite(func() {
@@ -1,13 +1,22 @@
 package integration_test
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"math/big"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -111,6 +120,8 @@ var _ = Describe("login -k Command", func() {
 
 				<-sess.Exited
 				Expect(sess.ExitCode()).To(Equal(0))
+
+				Expect(sess.Err).To(gbytes.Say("warning: the SSL certificate of target 'some-target' will not be verified"))
 			})
 
 			Context("login to existing target", func() {
@@ -208,6 +219,150 @@ var _ = Describe("login -k Command", func() {
 
 		})
 
+		Context("to new target with --ca-cert", func() {
+			var caCertPath string
+
+			BeforeEach(func() {
+				caCertFile, err := ioutil.TempFile("", "fly-ca-cert")
+				Expect(err).NotTo(HaveOccurred())
+
+				// the test server's certificate is self-signed, so it is its own CA
+				err = pem.Encode(caCertFile, &pem.Block{
+					Type:  "CERTIFICATE",
+					Bytes: atcServer.HTTPTestServer.TLS.Certificates[0].Certificate[0],
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				caCertFile.Close()
+				caCertPath = caCertFile.Name()
+
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/auth/methods"),
+						ghttp.RespondWithJSONEncoded(200, []atc.AuthMethod{}),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/pipelines"),
+						ghttp.RespondWithJSONEncoded(200, []atc.Pipeline{
+							{Name: "pipeline-1"},
+						}),
+					),
+				)
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(caCertPath)
+			})
+
+			It("verifies the target with it, now and on later commands", func() {
+				flyCmd = exec.Command(flyPath, "-t", "some-target", "login", "-c", atcServer.URL(), "--ca-cert", caCertPath)
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				otherCmd := exec.Command(flyPath, "-t", "some-target", "pipelines")
+
+				sess, err = gexec.Start(otherCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+				Expect(sess.Out).To(gbytes.Say("pipeline-1"))
+			})
+		})
+
+		Context("to new target requiring a client certificate", func() {
+			var certDir string
+			var clientCertPath string
+			var clientKeyPath string
+
+			BeforeEach(func() {
+				atcServer.HTTPTestServer.TLS.ClientAuth = tls.RequireAnyClientCert
+
+				var err error
+				certDir, err = ioutil.TempDir("", "fly-client-cert")
+				Expect(err).NotTo(HaveOccurred())
+
+				key, err := rsa.GenerateKey(rand.Reader, 1024)
+				Expect(err).NotTo(HaveOccurred())
+
+				template := &x509.Certificate{
+					SerialNumber: big.NewInt(1),
+					Subject:      pkix.Name{CommonName: "fly"},
+					NotBefore:    time.Now().Add(-time.Hour),
+					NotAfter:     time.Now().Add(time.Hour),
+					ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+				}
+
+				der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+				Expect(err).NotTo(HaveOccurred())
+
+				clientCertPath = filepath.Join(certDir, "client.crt")
+				err = ioutil.WriteFile(clientCertPath, pem.EncodeToMemory(&pem.Block{
+					Type:  "CERTIFICATE",
+					Bytes: der,
+				}), 0600)
+				Expect(err).NotTo(HaveOccurred())
+
+				clientKeyPath = filepath.Join(certDir, "client.key")
+				err = ioutil.WriteFile(clientKeyPath, pem.EncodeToMemory(&pem.Block{
+					Type:  "RSA PRIVATE KEY",
+					Bytes: x509.MarshalPKCS1PrivateKey(key),
+				}), 0600)
+				Expect(err).NotTo(HaveOccurred())
+
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/auth/methods"),
+						ghttp.RespondWithJSONEncoded(200, []atc.AuthMethod{}),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/pipelines"),
+						ghttp.RespondWithJSONEncoded(200, []atc.Pipeline{
+							{Name: "pipeline-1"},
+						}),
+					),
+				)
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(certDir)
+			})
+
+			It("presents it, now and on later commands", func() {
+				flyCmd = exec.Command(flyPath, "-t", "some-target", "login", "-k", "-c", atcServer.URL(), "--client-cert", clientCertPath, "--client-key", clientKeyPath)
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				otherCmd := exec.Command(flyPath, "-t", "some-target", "pipelines")
+
+				sess, err = gexec.Start(otherCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+				Expect(sess.Out).To(gbytes.Say("pipeline-1"))
+			})
+
+			It("errors when only the certificate is given", func() {
+				flyCmd = exec.Command(flyPath, "-t", "some-target", "login", "-k", "-c", atcServer.URL(), "--client-cert", clientCertPath)
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+				Expect(sess.Err).To(gbytes.Say("--client-cert and --client-key must be given together"))
+			})
+		})
+
 		Context("to new target with invalid SSL without -k", func() {
 			BeforeEach(func() {
 				flyCmd = exec.Command(flyPath, "-t", "some-target", "login", "-c", atcServer.URL())
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"regexp"
 	"runtime"
 
 	. "github.com/onsi/ginkgo"
@@ -15,6 +18,7 @@ import (
 	"github.com/onsi/gomega/ghttp"
 
 	"github.com/concourse/atc"
+	"github.com/concourse/fly/rc"
 )
 
 var _ = Describe("login Command", func() {
@@ -83,41 +87,16 @@ var _ = Describe("login Command", func() {
 			})
 
 			Context("when an OAuth method is chosen", func() {
-				It("asks for manual token entry for oauth methods", func() {
-					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
-					Expect(err).NotTo(HaveOccurred())
-
-					Eventually(sess.Out).Should(gbytes.Say("1. Basic"))
-					Eventually(sess.Out).Should(gbytes.Say("2. OAuth Type 1"))
-					Eventually(sess.Out).Should(gbytes.Say("3. OAuth Type 2"))
-					Eventually(sess.Out).Should(gbytes.Say("choose an auth method: "))
-
-					_, err = fmt.Fprintf(stdin, "3\n")
-					Expect(err).NotTo(HaveOccurred())
-
-					Eventually(sess.Out).Should(gbytes.Say("navigate to the following URL in your browser:"))
-					Eventually(sess.Out).Should(gbytes.Say("    https://example.com/auth/oauth-2"))
-					Eventually(sess.Out).Should(gbytes.Say("enter token: "))
-
-					_, err = fmt.Fprintf(stdin, "bogustoken\n")
-					Expect(err).NotTo(HaveOccurred())
-
-					Eventually(sess.Out).Should(gbytes.Say("token must be of the format 'TYPE VALUE', e.g. 'Bearer ...'"))
-
-					_, err = fmt.Fprintf(stdin, "Bearer grylls\n")
-					Expect(err).NotTo(HaveOccurred())
-
-					Eventually(sess.Out).Should(gbytes.Say("token saved"))
-
-					err = stdin.Close()
-					Expect(err).NotTo(HaveOccurred())
+				Context("with --no-browser", func() {
+					BeforeEach(func() {
+						flyCmd = exec.Command(flyPath, "-t", "some-target", "login", "-c", atcServer.URL(), "--no-browser")
 
-					<-sess.Exited
-					Expect(sess.ExitCode()).To(Equal(0))
-				})
+						var err error
+						stdin, err = flyCmd.StdinPipe()
+						Expect(err).NotTo(HaveOccurred())
+					})
 
-				Context("after logging in succeeds", func() {
-					BeforeEach(func() {
+					It("asks for manual token entry for oauth methods", func() {
 						sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
 						Expect(err).NotTo(HaveOccurred())
 
@@ -129,9 +108,16 @@ var _ = Describe("login Command", func() {
 						_, err = fmt.Fprintf(stdin, "3\n")
 						Expect(err).NotTo(HaveOccurred())
 
+						Eventually(sess.Out).Should(gbytes.Say("navigate to the following URL in your browser:"))
+						Eventually(sess.Out).Should(gbytes.Say("    https://example.com/auth/oauth-2"))
 						Eventually(sess.Out).Should(gbytes.Say("enter token: "))
 
-						_, err = fmt.Fprintf(stdin, "Bearer some-entered-token\n")
+						_, err = fmt.Fprintf(stdin, "bogustoken\n")
+						Expect(err).NotTo(HaveOccurred())
+
+						Eventually(sess.Out).Should(gbytes.Say("token must be of the format 'TYPE VALUE', e.g. 'Bearer ...'"))
+
+						_, err = fmt.Fprintf(stdin, "Bearer grylls\n")
 						Expect(err).NotTo(HaveOccurred())
 
 						Eventually(sess.Out).Should(gbytes.Say("token saved"))
@@ -143,31 +129,174 @@ var _ = Describe("login Command", func() {
 						Expect(sess.ExitCode()).To(Equal(0))
 					})
 
-					Describe("running other commands", func() {
+					Context("after logging in succeeds", func() {
 						BeforeEach(func() {
-							atcServer.AppendHandlers(
-								ghttp.CombineHandlers(
-									ghttp.VerifyRequest("GET", "/api/v1/pipelines"),
-									ghttp.VerifyHeaderKV("Authorization", "Bearer some-entered-token"),
-									ghttp.RespondWithJSONEncoded(200, []atc.Pipeline{
-										{Name: "pipeline-1"},
-									}),
-								),
-							)
-						})
+							sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+							Expect(err).NotTo(HaveOccurred())
 
-						It("uses the saved token", func() {
-							otherCmd := exec.Command(flyPath, "-t", "some-target", "pipelines")
+							Eventually(sess.Out).Should(gbytes.Say("1. Basic"))
+							Eventually(sess.Out).Should(gbytes.Say("2. OAuth Type 1"))
+							Eventually(sess.Out).Should(gbytes.Say("3. OAuth Type 2"))
+							Eventually(sess.Out).Should(gbytes.Say("choose an auth method: "))
 
-							sess, err := gexec.Start(otherCmd, GinkgoWriter, GinkgoWriter)
+							_, err = fmt.Fprintf(stdin, "3\n")
 							Expect(err).NotTo(HaveOccurred())
 
-							<-sess.Exited
+							Eventually(sess.Out).Should(gbytes.Say("enter token: "))
 
-							Expect(sess).To(gbytes.Say("pipeline-1"))
+							_, err = fmt.Fprintf(stdin, "Bearer some-entered-token\n")
+							Expect(err).NotTo(HaveOccurred())
+
+							Eventually(sess.Out).Should(gbytes.Say("token saved"))
+
+							err = stdin.Close()
+							Expect(err).NotTo(HaveOccurred())
 
+							<-sess.Exited
 							Expect(sess.ExitCode()).To(Equal(0))
 						})
+
+						Describe("running other commands", func() {
+							BeforeEach(func() {
+								atcServer.AppendHandlers(
+									ghttp.CombineHandlers(
+										ghttp.VerifyRequest("GET", "/api/v1/pipelines"),
+										ghttp.VerifyHeaderKV("Authorization", "Bearer some-entered-token"),
+										ghttp.RespondWithJSONEncoded(200, []atc.Pipeline{
+											{Name: "pipeline-1"},
+										}),
+									),
+								)
+							})
+
+							It("uses the saved token", func() {
+								otherCmd := exec.Command(flyPath, "-t", "some-target", "pipelines")
+
+								sess, err := gexec.Start(otherCmd, GinkgoWriter, GinkgoWriter)
+								Expect(err).NotTo(HaveOccurred())
+
+								<-sess.Exited
+
+								Expect(sess).To(gbytes.Say("pipeline-1"))
+
+								Expect(sess.ExitCode()).To(Equal(0))
+							})
+						})
+					})
+				})
+
+				Context("when the browser completes the login", func() {
+					var sess *gexec.Session
+
+					BeforeEach(func() {
+						flyCmd = exec.Command(flyPath, "-t", "some-target", "login", "-c", atcServer.URL())
+
+						// keep the test from opening a real browser
+						flyCmd.Env = []string{"HOME=" + homeDir, "USERPROFILE=" + homeDir, "PATH="}
+
+						var err error
+						stdin, err = flyCmd.StdinPipe()
+						Expect(err).NotTo(HaveOccurred())
+
+						sess, err = gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+						Expect(err).NotTo(HaveOccurred())
+
+						Eventually(sess.Out).Should(gbytes.Say("choose an auth method: "))
+
+						_, err = fmt.Fprintf(stdin, "3\n")
+						Expect(err).NotTo(HaveOccurred())
+
+						Eventually(sess.Out).Should(gbytes.Say("navigate to the following URL in your browser:"))
+						Eventually(sess.Out).Should(gbytes.Say(`https://example.com/auth/oauth-2\?fly_local_port=(\d+)&fly_state=([0-9a-f]{32})`))
+						Eventually(sess.Out).Should(gbytes.Say("waiting for the login to complete"))
+					})
+
+					AfterEach(func() {
+						sess.Kill()
+					})
+
+					callbackWithState := func(token string, state string) *http.Response {
+						port := regexp.MustCompile(`fly_local_port=(\d+)`).FindStringSubmatch(string(sess.Out.Contents()))[1]
+
+						response, err := http.Get("http://127.0.0.1:" + port + "/oauth/callback?token=" + url.QueryEscape(token) + "&fly_state=" + url.QueryEscape(state))
+						Expect(err).NotTo(HaveOccurred())
+
+						return response
+					}
+
+					callback := func(token string) *http.Response {
+						state := regexp.MustCompile(`fly_state=([0-9a-f]+)`).FindStringSubmatch(string(sess.Out.Contents()))[1]
+						return callbackWithState(token, state)
+					}
+
+					It("saves the token the ATC redirects back with", func() {
+						response := callback("Bearer some-browser-token")
+						Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+						Eventually(sess.Out).Should(gbytes.Say("token saved"))
+
+						<-sess.Exited
+						Expect(sess.ExitCode()).To(Equal(0))
+
+						target, err := rc.SelectTarget("some-target")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(target.Token).To(Equal(&rc.TargetToken{Type: "Bearer", Value: "some-browser-token"}))
+					})
+
+					It("rejects a callback without the state it sent, and keeps waiting", func() {
+						response := callbackWithState("Bearer some-forged-token", "")
+						Expect(response.StatusCode).To(Equal(http.StatusForbidden))
+
+						response = callbackWithState("Bearer some-forged-token", "bogus-state")
+						Expect(response.StatusCode).To(Equal(http.StatusForbidden))
+
+						Consistently(sess.Exited).ShouldNot(BeClosed())
+
+						response = callback("Bearer some-browser-token")
+						Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+						<-sess.Exited
+						Expect(sess.ExitCode()).To(Equal(0))
+
+						target, err := rc.SelectTarget("some-target")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(target.Token).To(Equal(&rc.TargetToken{Type: "Bearer", Value: "some-browser-token"}))
+					})
+
+					It("rejects a malformed token and keeps waiting", func() {
+						response := callback("bogustoken")
+						Expect(response.StatusCode).To(Equal(http.StatusBadRequest))
+
+						Consistently(sess.Exited).ShouldNot(BeClosed())
+
+						response = callback("Bearer some-browser-token")
+						Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+						<-sess.Exited
+						Expect(sess.ExitCode()).To(Equal(0))
+					})
+				})
+
+				Context("when the browser never completes the login", func() {
+					It("times out and exits 1", func() {
+						flyCmd = exec.Command(flyPath, "-t", "some-target", "login", "-c", atcServer.URL(), "--oauth-timeout", "1s")
+						flyCmd.Env = []string{"HOME=" + homeDir, "USERPROFILE=" + homeDir, "PATH="}
+
+						stdin, err := flyCmd.StdinPipe()
+						Expect(err).NotTo(HaveOccurred())
+
+						sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+						Expect(err).NotTo(HaveOccurred())
+
+						Eventually(sess.Out).Should(gbytes.Say("choose an auth method: "))
+
+						_, err = fmt.Fprintf(stdin, "3\n")
+						Expect(err).NotTo(HaveOccurred())
+
+						Eventually(sess.Err, 5).Should(gbytes.Say("timed out after 1s waiting for the OAuth login"))
+
+						<-sess.Exited
+						Expect(sess.ExitCode()).To(Equal(1))
 					})
 				})
 			})
@@ -406,6 +535,88 @@ var _ = Describe("login Command", func() {
 			})
 		})
 
+		Context("when credentials are given as flags", func() {
+			BeforeEach(func() {
+				flyCmd = exec.Command(flyPath, "-t", "some-target", "login", "-c", atcServer.URL(), "-u", "some username", "-p", "some password")
+
+				err := rc.SaveTarget("some-target", atcServer.URL(), false, &rc.TargetToken{Type: "Bearer", Value: "old-token"}, "")
+				Expect(err).NotTo(HaveOccurred())
+
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/auth/methods"),
+						ghttp.RespondWithJSONEncoded(200, []atc.AuthMethod{
+							{
+								Type:        atc.AuthTypeOAuth,
+								DisplayName: "OAuth Type 1",
+								AuthURL:     "https://example.com/auth/oauth-1",
+							},
+							{
+								Type:        atc.AuthTypeBasic,
+								DisplayName: "Basic",
+								AuthURL:     "https://example.com/login/basic",
+							},
+						}),
+					),
+				)
+			})
+
+			Context("when the credentials are accepted", func() {
+				BeforeEach(func() {
+					atcServer.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/api/v1/auth/token"),
+							ghttp.VerifyBasicAuth("some username", "some password"),
+							ghttp.RespondWithJSONEncoded(200, atc.AuthToken{
+								Type:  "Bearer",
+								Value: "some-token",
+							}),
+						),
+					)
+				})
+
+				It("logs in with basic auth without prompting", func() {
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(sess.Out).Should(gbytes.Say("token saved"))
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(0))
+
+					Expect(sess.Out.Contents()).NotTo(ContainSubstring("choose an auth method"))
+					Expect(sess.Out.Contents()).NotTo(ContainSubstring("username: "))
+
+					target, err := rc.SelectTarget("some-target")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(target.Token).To(Equal(&rc.TargetToken{Type: "Bearer", Value: "some-token"}))
+				})
+			})
+
+			Context("when the credentials are rejected", func() {
+				BeforeEach(func() {
+					atcServer.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/api/v1/auth/token"),
+							ghttp.RespondWith(401, ""),
+						),
+					)
+				})
+
+				It("exits 1, keeping the existing token", func() {
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(1))
+
+					target, err := rc.SelectTarget("some-target")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(target.Token).To(Equal(&rc.TargetToken{Type: "Bearer", Value: "old-token"}))
+				})
+			})
+		})
+
 		Context("when no auth methods are returned from the API", func() {
 			BeforeEach(func() {
 				atcServer.AppendHandlers(
@@ -457,6 +668,48 @@ var _ = Describe("login Command", func() {
 			})
 		})
 
+		Context("when the URL has a trailing slash", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/auth/methods"),
+						ghttp.RespondWithJSONEncoded(200, []atc.AuthMethod{}),
+					),
+				)
+
+				flyCmd = exec.Command(flyPath, "-t", "some-target", "login", "-c", atcServer.URL()+"/")
+			})
+
+			It("saves the URL without it", func() {
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				targets, err := rc.LoadTargets()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(targets["some-target"].API).To(Equal(atcServer.URL()))
+			})
+		})
+
+		Context("when the URL has a path", func() {
+			BeforeEach(func() {
+				flyCmd = exec.Command(flyPath, "-t", "some-target", "login", "-c", atcServer.URL()+"/some/path")
+			})
+
+			It("errors without contacting the ATC", func() {
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+				Expect(sess.Err).To(gbytes.Say("must not have a path, query or fragment"))
+
+				Expect(atcServer.ReceivedRequests()).To(BeEmpty())
+			})
+		})
+
 		Context("and the api returns an internal server error", func() {
 			BeforeEach(func() {
 				atcServer.AppendHandlers(
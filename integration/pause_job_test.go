@@ -0,0 +1,82 @@
+package integration_test
+
+import (
+	"net/http"
+	"os/exec"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/tedsuo/rata"
+
+	"github.com/concourse/atc"
+)
+
+var _ = Describe("Fly CLI", func() {
+	var atcServer *ghttp.Server
+
+	BeforeEach(func() {
+		atcServer = ghttp.NewServer()
+	})
+
+	AfterEach(func() {
+		atcServer.Close()
+	})
+
+	for _, c := range []struct {
+		command string
+		route   string
+		done    string
+	}{
+		{"pause-job", atc.PauseJob, "paused"},
+		{"unpause-job", atc.UnpauseJob, "unpaused"},
+	} {
+		c := c
+
+		Describe(c.command, func() {
+			var path string
+
+			BeforeEach(func() {
+				var err error
+				path, err = atc.Routes.CreatePathForRoute(c.route, rata.Params{"pipeline_name": "awesome-pipeline", "job_name": "awesome-job"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("flips the job's paused flag", func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", path),
+						ghttp.RespondWith(http.StatusOK, nil),
+					),
+				)
+
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), c.command, "-j", "awesome-pipeline/awesome-job")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(0))
+				Expect(sess.Out).To(gbytes.Say(c.done + " 'awesome-pipeline/awesome-job'"))
+			})
+
+			It("exits 1 naming the job when it doesn't exist", func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", path),
+						ghttp.RespondWith(http.StatusNotFound, nil),
+					),
+				)
+
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), c.command, "-j", "awesome-pipeline/awesome-job")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(1))
+				Expect(sess.Err).To(gbytes.Say("job 'awesome-pipeline/awesome-job' not found"))
+			})
+		})
+	}
+})
@@ -1,6 +1,7 @@
 package integration_test
 
 import (
+	"encoding/json"
 	"os/exec"
 
 	"github.com/concourse/atc"
@@ -34,7 +35,7 @@ var _ = Describe("Fly CLI", func() {
 					ghttp.CombineHandlers(
 						ghttp.VerifyRequest("GET", "/api/v1/pipelines"),
 						ghttp.RespondWithJSONEncoded(200, []atc.Pipeline{
-							{Name: "pipeline-1-longer", URL: "/pipelines/pipeline-1", Paused: false},
+							{Name: "pipeline-1-longer", URL: "/pipelines/pipeline-1", Paused: false, Public: true},
 							{Name: "pipeline-2", URL: "/pipelines/pipeline-2", Paused: true},
 							{Name: "pipeline-3", URL: "/pipelines/pipeline-3", Paused: false},
 						}),
@@ -47,16 +48,40 @@ var _ = Describe("Fly CLI", func() {
 					Headers: ui.TableRow{
 						{Contents: "name", Color: color.New(color.Bold)},
 						{Contents: "paused", Color: color.New(color.Bold)},
+						{Contents: "public", Color: color.New(color.Bold)},
 					},
 					Data: []ui.TableRow{
-						{{Contents: "pipeline-1-longer"}, {Contents: "no"}},
-						{{Contents: "pipeline-2"}, {Contents: "yes", Color: color.New(color.FgCyan)}},
-						{{Contents: "pipeline-3"}, {Contents: "no"}},
+						{{Contents: "pipeline-1-longer"}, {Contents: "no"}, {Contents: "yes", Color: color.New(color.FgCyan)}},
+						{{Contents: "pipeline-2"}, {Contents: "yes", Color: color.New(color.FgCyan)}, {Contents: "no"}},
+						{{Contents: "pipeline-3"}, {Contents: "no"}, {Contents: "no"}},
 					},
 				}))
 
 				Expect(flyCmd).To(HaveExited(0))
 			})
+
+			Context("when --json is given", func() {
+				BeforeEach(func() {
+					flyCmd.Args = append(flyCmd.Args, "--json")
+				})
+
+				It("prints the pipelines as JSON", func() {
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(0))
+
+					var pipelines []atc.Pipeline
+					err = json.Unmarshal(sess.Out.Contents(), &pipelines)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(pipelines).To(HaveLen(3))
+					Expect(pipelines[0].Name).To(Equal("pipeline-1-longer"))
+					Expect(pipelines[0].Public).To(BeTrue())
+					Expect(pipelines[1].Paused).To(BeTrue())
+				})
+			})
 		})
 
 		Context("and the api returns an internal server error", func() {
@@ -0,0 +1,61 @@
+package integration_test
+
+import (
+	"net/http"
+	"os/exec"
+
+	"github.com/concourse/atc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("--proxy", func() {
+		var proxyServer *ghttp.Server
+
+		BeforeEach(func() {
+			proxyServer = ghttp.NewServer()
+		})
+
+		AfterEach(func() {
+			proxyServer.Close()
+		})
+
+		It("sends requests to the target through the proxy", func() {
+			proxyServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/pipelines"),
+					func(w http.ResponseWriter, r *http.Request) {
+						Expect(r.Host).To(Equal("atc.example.com"))
+					},
+					ghttp.RespondWithJSONEncoded(200, []atc.Pipeline{
+						{Name: "pipeline-1"},
+					}),
+				),
+			)
+
+			flyCmd := exec.Command(flyPath, "-t", "http://atc.example.com", "--proxy", proxyServer.URL(), "pipelines")
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess).Should(gexec.Exit(0))
+			Expect(sess.Out).To(gbytes.Say("pipeline-1"))
+
+			Expect(proxyServer.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("errors for a proxy that isn't a URL", func() {
+			flyCmd := exec.Command(flyPath, "-t", "http://atc.example.com", "--proxy", "bogus", "pipelines")
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess).Should(gexec.Exit(1))
+			Expect(sess.Err).To(gbytes.Say("invalid proxy URL: bogus"))
+		})
+	})
+})
@@ -0,0 +1,123 @@
+package integration_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/rc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/tedsuo/rata"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("when the ATC redirects", func() {
+		var homeDir string
+
+		var atcServer *ghttp.Server
+		var router *ghttp.Server
+
+		var redirectTo string
+
+		BeforeEach(func() {
+			var err error
+
+			homeDir, err = ioutil.TempDir("", "fly-test")
+			Expect(err).NotTo(HaveOccurred())
+
+			if runtime.GOOS == "windows" {
+				os.Setenv("USERPROFILE", homeDir)
+			} else {
+				os.Setenv("HOME", homeDir)
+			}
+
+			atcServer = ghttp.NewServer()
+			router = ghttp.NewServer()
+
+			redirectTo = atcServer.URL()
+
+			redirect := func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, redirectTo+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			}
+
+			router.RouteToHandler("GET", regexp.MustCompile(".*"), redirect)
+			router.RouteToHandler("POST", regexp.MustCompile(".*"), redirect)
+
+			// the saved target still points at the router
+			err = rc.SaveTarget("ci", router.URL(), false, &rc.TargetToken{Type: "Bearer", Value: "some-token"}, "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			router.Close()
+			atcServer.Close()
+			os.RemoveAll(homeDir)
+		})
+
+		fly := func(args ...string) *gexec.Session {
+			flyCmd := exec.Command(flyPath, append([]string{"-t", "ci"}, args...)...)
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			<-sess.Exited
+
+			return sess
+		}
+
+		It("follows redirects to the same host, keeping the token", func() {
+			atcServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/pipelines"),
+					ghttp.VerifyHeaderKV("Authorization", "Bearer some-token"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.Pipeline{{Name: "some-pipeline"}}),
+				),
+			)
+
+			sess := fly("pipelines")
+			Expect(sess.ExitCode()).To(Equal(0))
+			Expect(sess.Out).To(gbytes.Say("some-pipeline"))
+		})
+
+		It("keeps the method and body of a POST", func() {
+			path, err := atc.Routes.CreatePathForRoute(atc.CheckResource, rata.Params{"pipeline_name": "some-pipeline", "resource_name": "some-resource"})
+			Expect(err).NotTo(HaveOccurred())
+
+			atcServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", path),
+					ghttp.VerifyHeaderKV("Authorization", "Bearer some-token"),
+					ghttp.VerifyJSON(`{"from":{"ref":"abcd"}}`),
+					ghttp.RespondWith(http.StatusOK, ""),
+				),
+			)
+
+			sess := fly("check-resource", "-r", "some-pipeline/some-resource", "--from", "ref:abcd")
+			Expect(sess.ExitCode()).To(Equal(0))
+			Expect(sess.Out).To(gbytes.Say("checked 'some-pipeline/some-resource'"))
+		})
+
+		Context("to another host", func() {
+			BeforeEach(func() {
+				redirectTo = strings.Replace(atcServer.URL(), "127.0.0.1", "localhost", 1)
+			})
+
+			It("refuses, suggesting the target's URL be updated", func() {
+				sess := fly("pipelines")
+				Expect(sess.ExitCode()).NotTo(Equal(0))
+				Expect(sess.Err).To(gbytes.Say(`the target redirected to ` + regexp.QuoteMeta(redirectTo) + `/api/v1/pipelines, which is on a different host; if the ATC has moved, update the target's URL to ` + regexp.QuoteMeta(redirectTo)))
+
+				Expect(atcServer.ReceivedRequests()).To(BeEmpty())
+			})
+		})
+	})
+})
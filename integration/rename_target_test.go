@@ -0,0 +1,104 @@
+package integration_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/concourse/fly/rc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("rename-target", func() {
+		var homeDir string
+
+		BeforeEach(func() {
+			var err error
+
+			homeDir, err = ioutil.TempDir("", "fly-test")
+			Expect(err).NotTo(HaveOccurred())
+
+			if runtime.GOOS == "windows" {
+				os.Setenv("USERPROFILE", homeDir)
+			} else {
+				os.Setenv("HOME", homeDir)
+			}
+
+			err = rc.SaveTarget("prod-ci", "https://ci.example.com", true, &rc.TargetToken{Type: "Bearer", Value: "some-token"}, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = rc.SaveTarget("production", "https://old.example.com", false, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(homeDir)
+		})
+
+		rename := func(args ...string) *gexec.Session {
+			sess, err := gexec.Start(exec.Command(flyPath, append([]string{"rename-target"}, args...)...), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			<-sess.Exited
+
+			return sess
+		}
+
+		Context("when the new name is free", func() {
+			It("renames the target", func() {
+				sess := rename("-o", "prod-ci", "-n", "ci")
+				Expect(sess.ExitCode()).To(Equal(0))
+				Expect(sess.Out).To(gbytes.Say("renamed target `prod-ci` to `ci`"))
+
+				targets, err := rc.LoadTargets()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(targets).NotTo(HaveKey("prod-ci"))
+				Expect(targets["ci"].API).To(Equal("https://ci.example.com"))
+				Expect(targets["ci"].Token.Value).To(Equal("some-token"))
+			})
+		})
+
+		Context("when the new name is taken", func() {
+			It("fails, leaving both targets alone", func() {
+				sess := rename("-o", "prod-ci", "-n", "production")
+				Expect(sess.ExitCode()).To(Equal(1))
+				Expect(sess.Err).To(gbytes.Say("Target production already exists"))
+
+				targets, err := rc.LoadTargets()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(targets["prod-ci"].API).To(Equal("https://ci.example.com"))
+				Expect(targets["production"].API).To(Equal("https://old.example.com"))
+			})
+
+			Context("with --force", func() {
+				It("replaces the existing target", func() {
+					sess := rename("-o", "prod-ci", "-n", "production", "--force")
+					Expect(sess.ExitCode()).To(Equal(0))
+
+					targets, err := rc.LoadTargets()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(targets).To(HaveLen(1))
+					Expect(targets["production"].API).To(Equal("https://ci.example.com"))
+					Expect(targets["production"].Insecure).To(BeTrue())
+				})
+			})
+		})
+
+		Context("when the target does not exist", func() {
+			It("fails", func() {
+				sess := rename("-o", "bogus", "-n", "production", "--force")
+				Expect(sess.ExitCode()).To(Equal(1))
+				Expect(sess.Err).To(gbytes.Say("Unable to find target bogus"))
+
+				targets, err := rc.LoadTargets()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(targets).To(HaveKey("production"))
+			})
+		})
+	})
+})
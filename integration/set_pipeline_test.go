@@ -410,6 +410,75 @@ var _ = Describe("Fly CLI", func() {
 				})
 			})
 
+			Context("when someone else changed the pipeline in the meantime", func() {
+				BeforeEach(func() {
+					getPath, err := atc.Routes.CreatePathForRoute(atc.GetConfig, rata.Params{"pipeline_name": "awesome-pipeline"})
+					Expect(err).NotTo(HaveOccurred())
+
+					fetched := 0
+					atcServer.RouteToHandler("GET", getPath, func(w http.ResponseWriter, r *http.Request) {
+						fetched++
+						version := fmt.Sprintf("%d", 41+fetched)
+						ghttp.RespondWithJSONEncoded(http.StatusOK, config, http.Header{atc.ConfigVersionHeader: {version}})(w, r)
+					})
+
+					path, err := atc.Routes.CreatePathForRoute(atc.SaveConfig, rata.Params{"pipeline_name": "awesome-pipeline"})
+					Expect(err).NotTo(HaveOccurred())
+
+					atcServer.RouteToHandler("PUT", path,
+						ghttp.RespondWith(http.StatusConflict, "version mismatch"),
+					)
+				})
+
+				It("reports the conflict and exits 1", func() {
+					flyCmd := exec.Command(flyPath, "-t", atcServer.URL()+"/", "set-pipeline", "-c", configFile.Name(), "-p", "awesome-pipeline")
+
+					stdin, err := flyCmd.StdinPipe()
+					Expect(err).NotTo(HaveOccurred())
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(sess).Should(gbytes.Say(`apply configuration\? \[yN\]: `))
+					yes(stdin)
+
+					Eventually(sess.Err).Should(gbytes.Say("pipeline 'awesome-pipeline' was changed by someone else since its config was fetched"))
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(1))
+				})
+			})
+
+			Context("when the configuration is invalid", func() {
+				BeforeEach(func() {
+					path, err := atc.Routes.CreatePathForRoute(atc.SaveConfig, rata.Params{"pipeline_name": "awesome-pipeline"})
+					Expect(err).NotTo(HaveOccurred())
+
+					atcServer.RouteToHandler("PUT", path,
+						ghttp.RespondWith(http.StatusBadRequest, "jobs.some-job has no plan"),
+					)
+				})
+
+				It("reports the validation error and exits 1", func() {
+					flyCmd := exec.Command(flyPath, "-t", atcServer.URL()+"/", "set-pipeline", "-c", configFile.Name(), "-p", "awesome-pipeline")
+
+					stdin, err := flyCmd.StdinPipe()
+					Expect(err).NotTo(HaveOccurred())
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(sess).Should(gbytes.Say(`apply configuration\? \[yN\]: `))
+					yes(stdin)
+
+					Eventually(sess.Err).Should(gbytes.Say("configuration is invalid:"))
+					Eventually(sess.Err).Should(gbytes.Say("jobs.some-job has no plan"))
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(1))
+				})
+			})
+
 			Context("when the server says this is the first time it's creating the pipeline", func() {
 				Context("when the user doesn't mention paused", func() {
 					BeforeEach(func() {
@@ -0,0 +1,164 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/rc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("status", func() {
+		var homeDir string
+		var atcServer *ghttp.Server
+
+		BeforeEach(func() {
+			var err error
+
+			homeDir, err = ioutil.TempDir("", "fly-test")
+			Expect(err).NotTo(HaveOccurred())
+
+			if runtime.GOOS == "windows" {
+				os.Setenv("USERPROFILE", homeDir)
+			} else {
+				os.Setenv("HOME", homeDir)
+			}
+
+			atcServer = ghttp.NewServer()
+
+			err = rc.SaveTarget("ci", atcServer.URL(), false, &rc.TargetToken{Type: "Bearer", Value: "some-token"}, "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			atcServer.Close()
+			os.RemoveAll(homeDir)
+		})
+
+		status := func(args ...string) *gexec.Session {
+			flyCmd := exec.Command(flyPath, append([]string{"-t", "ci", "status"}, args...)...)
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			<-sess.Exited
+
+			return sess
+		}
+
+		respondsWithInfo := func() {
+			atcServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/info"),
+					ghttp.RespondWith(http.StatusOK, `{"version":"1.2.3"}`),
+				),
+			)
+		}
+
+		Context("when the ATC accepts the token", func() {
+			BeforeEach(func() {
+				respondsWithInfo()
+
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/workers"),
+						ghttp.VerifyHeaderKV("Authorization", "Bearer some-token"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.Worker{}),
+					),
+				)
+			})
+
+			It("says so and exits 0", func() {
+				sess := status()
+				Expect(sess.ExitCode()).To(Equal(0))
+				Expect(sess.Out).To(gbytes.Say(`target: ci \(` + atcServer.URL() + `\)`))
+				Expect(sess.Out).To(gbytes.Say("atc: reachable, version 1.2.3"))
+				Expect(sess.Out).To(gbytes.Say("auth: token accepted"))
+			})
+
+			It("prints JSON with --json", func() {
+				sess := status("--json")
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				var printed map[string]interface{}
+				err := json.Unmarshal(sess.Out.Contents(), &printed)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(printed).To(Equal(map[string]interface{}{
+					"target":        "ci",
+					"api":           atcServer.URL(),
+					"reachable":     true,
+					"version":       "1.2.3",
+					"authenticated": true,
+				}))
+			})
+		})
+
+		Context("when the ATC has no info endpoint", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					ghttp.RespondWith(http.StatusNotFound, ""),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.Worker{}),
+				)
+			})
+
+			It("reports an unknown version", func() {
+				sess := status()
+				Expect(sess.ExitCode()).To(Equal(0))
+				Expect(sess.Out).To(gbytes.Say("atc: reachable, version unknown"))
+			})
+		})
+
+		Context("when the ATC rejects the token", func() {
+			BeforeEach(func() {
+				respondsWithInfo()
+
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/workers"),
+						ghttp.RespondWith(http.StatusUnauthorized, ""),
+					),
+				)
+			})
+
+			It("exits 1", func() {
+				sess := status()
+				Expect(sess.ExitCode()).To(Equal(1))
+				Expect(sess.Out).To(gbytes.Say("auth: token rejected; run 'fly -t ci login'"))
+			})
+		})
+
+		Context("when the ATC can't be reached", func() {
+			BeforeEach(func() {
+				atcServer.Close()
+			})
+
+			It("exits 2", func() {
+				sess := status()
+				Expect(sess.ExitCode()).To(Equal(2))
+				Expect(sess.Out).To(gbytes.Say("atc: unreachable"))
+			})
+		})
+
+		Context("when the target doesn't exist", func() {
+			It("exits 2", func() {
+				sess, err := gexec.Start(exec.Command(flyPath, "-t", "missing", "status"), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(2))
+				Expect(sess.Out).To(gbytes.Say("target: Unable to find target missing"))
+			})
+		})
+	})
+})
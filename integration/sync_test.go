@@ -13,6 +13,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"github.com/onsi/gomega/gbytes"
 	"github.com/onsi/gomega/gexec"
 	"github.com/onsi/gomega/ghttp"
 )
@@ -25,7 +26,19 @@ var _ = Describe("Syncing", func() {
 		newFlyPath string
 	)
 
-	cliHandler := func() http.HandlerFunc {
+	// enough of an executable to pass for one
+	executableHeader := func() string {
+		switch runtime.GOOS {
+		case "windows":
+			return "MZ"
+		case "darwin":
+			return "\xcf\xfa\xed\xfe"
+		default:
+			return "\x7fELF"
+		}
+	}
+
+	cliHandler := func(contents string) http.HandlerFunc {
 		return ghttp.CombineHandlers(
 			ghttp.VerifyRequest("GET", "/api/v1/cli"),
 			func(w http.ResponseWriter, r *http.Request) {
@@ -38,23 +51,23 @@ var _ = Describe("Syncing", func() {
 				}
 
 				w.WriteHeader(http.StatusOK)
-				fmt.Fprint(w, "this will totally execute")
+				fmt.Fprint(w, contents)
 			},
 		)
 	}
 
-	BeforeEach(func() {
-		var err error
-
-		newFlyDir, err = ioutil.TempDir("", "fly-sync")
-		Expect(err).NotTo(HaveOccurred())
-
-		newFlyPath = filepath.Join(newFlyDir, "new-fly.exe.tga.bat.legit.notavirus")
+	infoHandler := func(version string) http.HandlerFunc {
+		return ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/api/v1/info"),
+			ghttp.RespondWith(http.StatusOK, `{"version":"`+version+`"}`),
+		)
+	}
 
+	copyFly := func(from string) {
 		newFly, err := os.Create(newFlyPath)
 		Expect(err).NotTo(HaveOccurred())
 
-		oldFly, err := os.Open(flyPath)
+		oldFly, err := os.Open(from)
 		Expect(err).NotTo(HaveOccurred())
 
 		_, err = io.Copy(newFly, oldFly)
@@ -65,33 +78,139 @@ var _ = Describe("Syncing", func() {
 
 		err = os.Chmod(newFlyPath, 0755)
 		Expect(err).NotTo(HaveOccurred())
+	}
+
+	sync := func(args ...string) *gexec.Session {
+		flyCmd := exec.Command(newFlyPath, append([]string{"-t", atcServer.URL(), "sync"}, args...)...)
+
+		sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+
+		<-sess.Exited
+
+		return sess
+	}
+
+	// don't let ginkgo try and output the entire binary as ascii
+	//
+	// that is the way to the dark side
+	flyStart := func() string {
+		contents, err := ioutil.ReadFile(newFlyPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		return string(contents[:len(executableHeader())+8])
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		newFlyDir, err = ioutil.TempDir("", "fly-sync")
+		Expect(err).NotTo(HaveOccurred())
+
+		newFlyPath = filepath.Join(newFlyDir, "new-fly.exe.tga.bat.legit.notavirus")
 
 		atcServer = ghttp.NewServer()
-		atcServer.AppendHandlers(cliHandler())
 	})
 
 	AfterEach(func() {
+		atcServer.Close()
 		os.RemoveAll(newFlyDir)
 	})
 
-	It("downloads and replaces the currently running executable", func() {
-		flyCmd := exec.Command(newFlyPath, "-t", atcServer.URL(), "sync")
+	Context("with a development build of fly", func() {
+		BeforeEach(func() {
+			copyFly(flyPath)
+		})
+
+		It("downloads and replaces the currently running executable", func() {
+			atcServer.AppendHandlers(
+				ghttp.RespondWith(http.StatusNotFound, ""),
+				cliHandler(executableHeader()+"this will totally execute"),
+			)
+
+			sess := sync()
+			Expect(sess.ExitCode()).To(Equal(0))
+			Expect(sess.Out).To(gbytes.Say("update successful!"))
+			Expect(sess.Out).To(gbytes.Say("0.0.0-dev -> unknown"))
+
+			Expect(flyStart()).To(Equal(executableHeader() + "this wil"))
+		})
+
+		It("refuses to install something that isn't an executable", func() {
+			atcServer.AppendHandlers(
+				infoHandler("1.2.0"),
+				cliHandler("this will totally execute"),
+			)
+
+			sess := sync()
+			Expect(sess.ExitCode()).To(Equal(1))
+			Expect(sess.Err).To(gbytes.Say("download failed: not a " + runtime.GOOS + " executable"))
+
+			Expect(flyStart()).NotTo(ContainSubstring("this wil"))
+		})
+
+		if runtime.GOOS != "windows" {
+			It("saves the new fly elsewhere if it can't replace itself", func() {
+				if os.Getuid() == 0 {
+					Skip("root can write anywhere")
+				}
 
-		sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
-		Expect(err).NotTo(HaveOccurred())
+				atcServer.AppendHandlers(
+					infoHandler("1.2.0"),
+					cliHandler(executableHeader()+"this will totally execute"),
+				)
 
-		<-sess.Exited
-		Expect(sess.ExitCode()).To(Equal(0))
+				err := os.Chmod(newFlyDir, 0555)
+				Expect(err).NotTo(HaveOccurred())
 
-		contents, err := ioutil.ReadFile(newFlyPath)
-		Expect(err).NotTo(HaveOccurred())
+				defer os.Chmod(newFlyDir, 0755)
 
-		// don't let ginkgo try and output the entire binary as ascii
-		//
-		// that is the way to the dark side
-		contents = contents[:8]
+				sess := sync()
+				Expect(sess.ExitCode()).To(Equal(1))
+				Expect(sess.Err).To(gbytes.Say("cannot replace fly"))
+				Expect(sess.Err).To(gbytes.Say("the new one has been saved to (.*)\n"))
+			})
+		}
+	})
 
-		expected := []byte("this will totally execute")
-		Expect(contents).To(Equal(expected[:8]))
+	Context("with a release build of fly", func() {
+		BeforeEach(func() {
+			copyFly(versionedFlyPath)
+		})
+
+		It("prints the old and new versions", func() {
+			atcServer.AppendHandlers(
+				infoHandler("1.3.0"),
+				cliHandler(executableHeader()+"this will totally execute"),
+			)
+
+			sess := sync()
+			Expect(sess.ExitCode()).To(Equal(0))
+			Expect(sess.Out).To(gbytes.Say(versionedFlyVersion + " -> 1.3.0"))
+		})
+
+		Context("when the target's fly is older", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					infoHandler("1.1.0"),
+					cliHandler(executableHeader()+"this will totally execute"),
+				)
+			})
+
+			It("refuses to downgrade", func() {
+				sess := sync()
+				Expect(sess.ExitCode()).To(Equal(1))
+				Expect(sess.Err).To(gbytes.Say(`the target's fly \(1.1.0\) is older than this one \(` + versionedFlyVersion + `\); use --force to downgrade`))
+
+				Expect(flyStart()).NotTo(ContainSubstring("this wil"))
+			})
+
+			It("downgrades with --force", func() {
+				sess := sync("--force")
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				Expect(flyStart()).To(Equal(executableHeader() + "this wil"))
+			})
+		})
 	})
 })
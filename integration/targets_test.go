@@ -0,0 +1,157 @@
+package integration_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/concourse/fly/rc"
+	"github.com/concourse/fly/ui"
+	"github.com/fatih/color"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("targets", func() {
+		var (
+			homeDir string
+
+			flyCmd *exec.Cmd
+
+			validJWT   string
+			expiredJWT string
+
+			validExpiry   time.Time
+			expiredExpiry time.Time
+		)
+
+		jwt := func(exp time.Time) string {
+			segment := func(s string) string {
+				return base64.RawURLEncoding.EncodeToString([]byte(s))
+			}
+
+			return segment(`{"alg":"RS256","typ":"JWT"}`) + "." + segment(fmt.Sprintf(`{"exp":%d}`, exp.Unix())) + "." + segment("signature")
+		}
+
+		BeforeEach(func() {
+			var err error
+
+			homeDir, err = ioutil.TempDir("", "fly-test")
+			Expect(err).NotTo(HaveOccurred())
+
+			if runtime.GOOS == "windows" {
+				os.Setenv("USERPROFILE", homeDir)
+			} else {
+				os.Setenv("HOME", homeDir)
+			}
+
+			validExpiry = time.Unix(time.Now().Add(24*time.Hour).Unix(), 0)
+			expiredExpiry = time.Unix(time.Now().Add(-24*time.Hour).Unix(), 0)
+
+			validJWT = jwt(validExpiry)
+			expiredJWT = jwt(expiredExpiry)
+
+			err = rc.SaveTarget("ci", "https://ci.example.com", false, &rc.TargetToken{Type: "Bearer", Value: validJWT}, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = rc.SaveTarget("another", "https://another.example.com", true, &rc.TargetToken{Type: "Bearer", Value: expiredJWT}, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = rc.SaveTarget("basic", "http://basic.example.com", false, &rc.TargetToken{Type: "Basic", Value: "dXNlcm5hbWU6cGFzc3dvcmQ="}, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = rc.SaveTarget("local", "http://192.168.100.4:8080", false, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(homeDir)
+		})
+
+		Context("with no flags", func() {
+			BeforeEach(func() {
+				flyCmd = exec.Command(flyPath, "targets")
+			})
+
+			It("lists the saved targets, flagging expired tokens", func() {
+				Expect(flyCmd).To(PrintTable(ui.Table{
+					Headers: ui.TableRow{
+						{Contents: "name", Color: color.New(color.Bold)},
+						{Contents: "url", Color: color.New(color.Bold)},
+						{Contents: "insecure", Color: color.New(color.Bold)},
+						{Contents: "expiry", Color: color.New(color.Bold)},
+					},
+					Data: []ui.TableRow{
+						{{Contents: "another"}, {Contents: "https://another.example.com"}, {Contents: "true"}, {Contents: expiredExpiry.Format("2006-01-02 15:04:05") + " (expired)", Color: color.New(color.FgRed)}},
+						{{Contents: "basic"}, {Contents: "http://basic.example.com"}, {Contents: "false"}, {Contents: "unknown", Color: color.New(color.Faint)}},
+						{{Contents: "ci"}, {Contents: "https://ci.example.com"}, {Contents: "false"}, {Contents: validExpiry.Format("2006-01-02 15:04:05")}},
+						{{Contents: "local"}, {Contents: "http://192.168.100.4:8080"}, {Contents: "false"}, {Contents: "n/a", Color: color.New(color.Faint)}},
+					},
+				}))
+			})
+		})
+
+		Context("with --json", func() {
+			type printedTarget struct {
+				Name     string          `json:"name"`
+				API      string          `json:"api"`
+				Insecure bool            `json:"insecure"`
+				Token    *rc.TargetToken `json:"token"`
+				Expiry   *time.Time      `json:"expiry"`
+				Expired  bool            `json:"expired"`
+			}
+
+			printedTargets := func(args ...string) []printedTarget {
+				sess, err := gexec.Start(exec.Command(flyPath, append([]string{"targets", "--json"}, args...)...), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(0))
+
+				var targets []printedTarget
+				err = json.Unmarshal(sess.Out.Contents(), &targets)
+				Expect(err).NotTo(HaveOccurred())
+
+				return targets
+			}
+
+			It("prints the targets with their token values redacted", func() {
+				targets := printedTargets()
+				Expect(targets).To(HaveLen(4))
+
+				Expect(targets[0].Name).To(Equal("another"))
+				Expect(targets[0].API).To(Equal("https://another.example.com"))
+				Expect(targets[0].Insecure).To(BeTrue())
+				Expect(targets[0].Token.Type).To(Equal("Bearer"))
+				Expect(targets[0].Token.Value).To(Equal("REDACTED"))
+				Expect(targets[0].Expiry.Equal(expiredExpiry)).To(BeTrue())
+				Expect(targets[0].Expired).To(BeTrue())
+
+				Expect(targets[1].Name).To(Equal("basic"))
+				Expect(targets[1].Expiry).To(BeNil())
+				Expect(targets[1].Expired).To(BeFalse())
+
+				Expect(targets[2].Name).To(Equal("ci"))
+				Expect(targets[2].Expiry.Equal(validExpiry)).To(BeTrue())
+				Expect(targets[2].Expired).To(BeFalse())
+
+				Expect(targets[3].Name).To(Equal("local"))
+				Expect(targets[3].Token).To(BeNil())
+			})
+
+			Context("with --show-tokens", func() {
+				It("includes the token values", func() {
+					targets := printedTargets("--show-tokens")
+					Expect(targets[0].Token.Value).To(Equal(expiredJWT))
+					Expect(targets[2].Token.Value).To(Equal(validJWT))
+				})
+			})
+		})
+	})
+})
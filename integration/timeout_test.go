@@ -0,0 +1,101 @@
+package integration_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/rc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("timeouts", func() {
+		var homeDir string
+		var atcServer *ghttp.Server
+		var stall chan struct{}
+
+		BeforeEach(func() {
+			var err error
+
+			homeDir, err = ioutil.TempDir("", "fly-test")
+			Expect(err).NotTo(HaveOccurred())
+
+			if runtime.GOOS == "windows" {
+				os.Setenv("USERPROFILE", homeDir)
+			} else {
+				os.Setenv("HOME", homeDir)
+			}
+
+			atcServer = ghttp.NewServer()
+			atcServer.AllowUnhandledRequests = true
+
+			err = rc.SaveTarget("ci", atcServer.URL(), false, &rc.TargetToken{Type: "Bearer", Value: "some-token"}, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			stall = make(chan struct{})
+
+			atcServer.RouteToHandler("GET", "/api/v1/pipelines", func(w http.ResponseWriter, r *http.Request) {
+				<-stall
+				ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.Pipeline{})(w, r)
+			})
+		})
+
+		AfterEach(func() {
+			close(stall)
+			atcServer.Close()
+			os.RemoveAll(homeDir)
+		})
+
+		start := func(env []string, args ...string) *gexec.Session {
+			flyCmd := exec.Command(flyPath, append([]string{"-t", "ci"}, args...)...)
+			flyCmd.Env = append(os.Environ(), env...)
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			return sess
+		}
+
+		Context("when a response doesn't arrive within --request-timeout", func() {
+			It("fails, naming the request and the flag", func() {
+				sess := start(nil, "--request-timeout", "100ms", "pipelines")
+
+				Eventually(sess, 5*time.Second).Should(gexec.Exit(1))
+				Expect(sess.Err).To(gbytes.Say(`/api/v1/pipelines.*timeout awaiting response headers`))
+				Expect(sess.Err).To(gbytes.Say(`--request-timeout or set FLY_REQUEST_TIMEOUT`))
+			})
+
+			It("can be set with FLY_REQUEST_TIMEOUT", func() {
+				sess := start([]string{"FLY_REQUEST_TIMEOUT=100ms"}, "pipelines")
+
+				Eventually(sess, 5*time.Second).Should(gexec.Exit(1))
+				Expect(sess.Err).To(gbytes.Say(`nothing within 100ms`))
+			})
+		})
+
+		Context("when the command outlasts --timeout", func() {
+			It("fails, naming the request that stalled", func() {
+				sess := start(nil, "--timeout", "200ms", "pipelines")
+
+				Eventually(sess, 5*time.Second).Should(gexec.Exit(1))
+				Expect(sess.Err).To(gbytes.Say(`/api/v1/pipelines.*--timeout of 200ms ran out`))
+			})
+		})
+
+		It("rejects a timeout that isn't a duration", func() {
+			sess := start(nil, "--timeout", "soon", "pipelines")
+
+			Eventually(sess).Should(gexec.Exit(1))
+			Expect(sess.Err).To(gbytes.Say(`invalid timeout 'soon'`))
+		})
+	})
+})
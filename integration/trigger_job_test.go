@@ -0,0 +1,144 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/tedsuo/rata"
+	"github.com/vito/go-sse/sse"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/atc/event"
+)
+
+var _ = Describe("Fly CLI", func() {
+	var atcServer *ghttp.Server
+
+	Describe("trigger-job", func() {
+		var path string
+
+		BeforeEach(func() {
+			atcServer = ghttp.NewServer()
+
+			var err error
+			path, err = atc.Routes.CreatePathForRoute(atc.CreateJobBuild, rata.Params{"pipeline_name": "awesome-pipeline", "job_name": "awesome-job"})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			atcServer.Close()
+		})
+
+		Context("when the job exists", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", path),
+						ghttp.RespondWithJSONEncoded(http.StatusCreated, atc.Build{ID: 57, Name: "42", Status: "pending", JobName: "awesome-job", PipelineName: "awesome-pipeline"}),
+					),
+				)
+			})
+
+			It("starts a build and prints where to find it", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "trigger-job", "-j", "awesome-pipeline/awesome-job")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(0))
+
+				Expect(sess.Out).To(gbytes.Say("started awesome-pipeline/awesome-job #42"))
+				Expect(sess.Out).To(gbytes.Say(atcServer.URL() + "/builds/57"))
+			})
+
+			Context("when --watch is given", func() {
+				var events chan atc.Event
+
+				BeforeEach(func() {
+					events = make(chan atc.Event, 2)
+
+					atcServer.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/api/v1/builds/57/events"),
+							func(w http.ResponseWriter, r *http.Request) {
+								w.Header().Add("Content-Type", "text/event-stream; charset=utf-8")
+								w.WriteHeader(http.StatusOK)
+
+								id := 0
+								for e := range events {
+									payload, err := json.Marshal(event.Message{Event: e})
+									Expect(err).NotTo(HaveOccurred())
+
+									err = sse.Event{ID: fmt.Sprintf("%d", id), Name: "event", Data: payload}.Write(w)
+									Expect(err).NotTo(HaveOccurred())
+
+									id++
+								}
+
+								err := sse.Event{Name: "end"}.Write(w)
+								Expect(err).NotTo(HaveOccurred())
+							},
+						),
+					)
+				})
+
+				It("streams the build and exits with its status", func() {
+					events <- event.Log{Payload: "sup"}
+					events <- event.Status{Status: atc.StatusFailed}
+					close(events)
+
+					flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "trigger-job", "-j", "awesome-pipeline/awesome-job", "--watch")
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(sess).Should(gexec.Exit(1))
+
+					Expect(sess.Out).To(gbytes.Say("started awesome-pipeline/awesome-job #42"))
+					Expect(sess.Out).To(gbytes.Say("sup"))
+				})
+			})
+		})
+
+		Context("when the job does not exist", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", path),
+						ghttp.RespondWith(http.StatusNotFound, ""),
+					),
+				)
+			})
+
+			It("says so and exits 1", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "trigger-job", "-j", "awesome-pipeline/awesome-job")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(1))
+				Expect(sess.Err).To(gbytes.Say("job 'awesome-pipeline/awesome-job' not found"))
+			})
+		})
+
+		Context("when the job is not given as pipeline/job", func() {
+			It("explains the format and exits 1", func() {
+				flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "trigger-job", "-j", "awesome-job")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(1))
+				Expect(sess.Err).To(gbytes.Say("argument format should be <pipeline>/<job>"))
+				Expect(atcServer.ReceivedRequests()).To(BeEmpty())
+			})
+		})
+	})
+})
@@ -36,6 +36,12 @@ var _ = Describe("Fly CLI", func() {
 			Context("when the pipeline exists", func() {
 				BeforeEach(func() {
 					atcServer.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/api/v1/pipelines"),
+							ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.Pipeline{
+								{Name: "awesome-pipeline", Paused: true},
+							}),
+						),
 						ghttp.CombineHandlers(
 							ghttp.VerifyRequest("PUT", path),
 							ghttp.RespondWith(http.StatusOK, nil),
@@ -51,6 +57,32 @@ var _ = Describe("Fly CLI", func() {
 
 					Eventually(sess).Should(gbytes.Say(`unpaused 'awesome-pipeline'`))
 
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(0))
+					Expect(atcServer.ReceivedRequests()).To(HaveLen(2))
+				})
+			})
+
+			Context("when the pipeline is already unpaused", func() {
+				BeforeEach(func() {
+					atcServer.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/api/v1/pipelines"),
+							ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.Pipeline{
+								{Name: "awesome-pipeline", Paused: false},
+							}),
+						),
+					)
+				})
+
+				It("says so and succeeds without unpauseing it again", func() {
+					flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "unpaus-pipeline", "-p", "awesome-pipeline")
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(sess).Should(gbytes.Say(`'awesome-pipeline' is already unpaused`))
+
 					<-sess.Exited
 					Expect(sess.ExitCode()).To(Equal(0))
 					Expect(atcServer.ReceivedRequests()).To(HaveLen(1))
@@ -61,8 +93,10 @@ var _ = Describe("Fly CLI", func() {
 				BeforeEach(func() {
 					atcServer.AppendHandlers(
 						ghttp.CombineHandlers(
-							ghttp.VerifyRequest("PUT", path),
-							ghttp.RespondWith(http.StatusNotFound, nil),
+							ghttp.VerifyRequest("GET", "/api/v1/pipelines"),
+							ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.Pipeline{
+								{Name: "some-other-pipeline"},
+							}),
 						),
 					)
 				})
@@ -0,0 +1,127 @@
+package integration_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("validate-task", func() {
+		var tmpdir string
+		var taskConfigPath string
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir("", "fly-validate-task")
+			Expect(err).NotTo(HaveOccurred())
+
+			taskConfigPath = filepath.Join(tmpdir, "task.yml")
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tmpdir)
+		})
+
+		writeConfig := func(config string) {
+			err := ioutil.WriteFile(taskConfigPath, []byte(config), 0644)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		validate := func(args ...string) *gexec.Session {
+			// no target is given, and none is reachable; nothing should need it
+			flyCmd := exec.Command(flyPath, append([]string{"validate-task", "-c", taskConfigPath}, args...)...)
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			<-sess.Exited
+			return sess
+		}
+
+		Context("when the config is valid", func() {
+			BeforeEach(func() {
+				writeConfig(`---
+platform: some-platform
+image: {{image}}
+run:
+  path: find
+`)
+			})
+
+			It("evaluates variables and exits 0", func() {
+				sess := validate("-v", "image=ubuntu")
+				Expect(sess.ExitCode()).To(Equal(0))
+				Expect(sess.Out).To(gbytes.Say("looks good"))
+			})
+		})
+
+		Context("when the config has errors", func() {
+			BeforeEach(func() {
+				writeConfig(`---
+run: {}
+`)
+			})
+
+			It("prints all of them and exits 1", func() {
+				sess := validate()
+				Expect(sess.ExitCode()).To(Equal(1))
+				Expect(sess.Err).To(gbytes.Say("task.yml:1: missing required field 'platform'"))
+				Expect(sess.Err).To(gbytes.Say("task.yml:2: missing required field 'run.path'"))
+			})
+		})
+
+		Context("when the run dir is absolute", func() {
+			BeforeEach(func() {
+				writeConfig(`---
+platform: some-platform
+run:
+  path: make
+  dir: /src/app
+  user: root
+`)
+			})
+
+			It("says it must be relative and exits 1", func() {
+				sess := validate()
+				Expect(sess.ExitCode()).To(Equal(1))
+				Expect(sess.Err).To(gbytes.Say("task.yml:5: 'run.dir' must be a path relative to the task's working directory, not '/src/app'"))
+				Expect(sess.Err).NotTo(gbytes.Say("unknown field"))
+			})
+		})
+
+		Context("when the config only has warnings", func() {
+			BeforeEach(func() {
+				writeConfig(`---
+platform: some-platform
+imge: ubuntu
+params:
+  PATH: /bin
+run:
+  path: find
+`)
+			})
+
+			It("prints them and exits 0", func() {
+				sess := validate()
+				Expect(sess.ExitCode()).To(Equal(0))
+				Expect(sess.Err).To(gbytes.Say("warning: task.yml:3: unknown field 'imge', did you mean 'image'\\?"))
+				Expect(sess.Err).To(gbytes.Say("warning: task.yml:5: param 'PATH' shadows the environment variable of the same name"))
+			})
+
+			Context("with --strict", func() {
+				It("exits 1", func() {
+					sess := validate("--strict")
+					Expect(sess.ExitCode()).To(Equal(1))
+					Expect(sess.Err).To(gbytes.Say("unknown field 'imge'"))
+				})
+			})
+		})
+	})
+})
@@ -0,0 +1,143 @@
+package integration_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/rc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("--verbose", func() {
+		var homeDir string
+		var atcServer *ghttp.Server
+		var pipelines []atc.Pipeline
+
+		BeforeEach(func() {
+			var err error
+
+			homeDir, err = ioutil.TempDir("", "fly-test")
+			Expect(err).NotTo(HaveOccurred())
+
+			if runtime.GOOS == "windows" {
+				os.Setenv("USERPROFILE", homeDir)
+			} else {
+				os.Setenv("HOME", homeDir)
+			}
+
+			atcServer = ghttp.NewServer()
+
+			err = rc.SaveTarget("ci", atcServer.URL(), false, &rc.TargetToken{Type: "Bearer", Value: "some-token"}, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			pipelines = []atc.Pipeline{{Name: "some-pipeline"}}
+
+			atcServer.RouteToHandler("GET", "/api/v1/pipelines", func(w http.ResponseWriter, r *http.Request) {
+				ghttp.RespondWithJSONEncoded(http.StatusOK, pipelines)(w, r)
+			})
+		})
+
+		AfterEach(func() {
+			atcServer.Close()
+			os.RemoveAll(homeDir)
+		})
+
+		run := func(env []string, args ...string) *gexec.Session {
+			flyCmd := exec.Command(flyPath, append([]string{"-t", "ci"}, args...)...)
+			flyCmd.Env = append(os.Environ(), env...)
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			return sess
+		}
+
+		It("logs each request's method, URL, status and duration", func() {
+			sess := run(nil, "--verbose", "pipelines")
+
+			Expect(sess.Err).To(gbytes.Say(`GET ` + atcServer.URL() + `/api/v1/pipelines: 200 OK \(.+\)`))
+			Expect(string(sess.Err.Contents())).NotTo(ContainSubstring("Authorization"))
+		})
+
+		It("can be turned on with FLY_VERBOSE", func() {
+			sess := run([]string{"FLY_VERBOSE=1"}, "pipelines")
+
+			Expect(sess.Err).To(gbytes.Say(`GET ` + atcServer.URL() + `/api/v1/pipelines: 200 OK`))
+		})
+
+		It("logs nothing otherwise", func() {
+			sess := run(nil, "pipelines")
+
+			Expect(string(sess.Err.Contents())).NotTo(ContainSubstring("/api/v1/pipelines"))
+		})
+
+		Context("at level 2", func() {
+			It("logs headers, redacting the token, and bodies", func() {
+				sess := run(nil, "--verbose=2", "pipelines")
+
+				stderr := string(sess.Err.Contents())
+				Expect(stderr).To(ContainSubstring("  Authorization: [redacted]\n"))
+				Expect(stderr).NotTo(ContainSubstring("some-token"))
+				Expect(stderr).To(ContainSubstring("  Content-Type: application/json\n"))
+				Expect(stderr).To(ContainSubstring(`"name":"some-pipeline"`))
+			})
+
+			It("redacts cookies", func() {
+				atcServer.RouteToHandler("GET", "/api/v1/pipelines", func(w http.ResponseWriter, r *http.Request) {
+					http.SetCookie(w, &http.Cookie{Name: "session", Value: "some-session-secret"})
+					ghttp.RespondWithJSONEncoded(http.StatusOK, pipelines)(w, r)
+				})
+
+				sess := run(nil, "--verbose=2", "pipelines")
+
+				stderr := string(sess.Err.Contents())
+				Expect(stderr).To(ContainSubstring("  Set-Cookie: [redacted]\n"))
+				Expect(stderr).NotTo(ContainSubstring("some-session-secret"))
+			})
+
+			It("redacts the bodies of requests for tokens", func() {
+				atcServer.RouteToHandler("GET", "/api/v1/auth/methods",
+					ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.AuthMethod{
+						{Type: atc.AuthTypeBasic, DisplayName: "Basic", AuthURL: "https://example.com/login/basic"},
+					}),
+				)
+				atcServer.RouteToHandler("GET", "/api/v1/auth/token",
+					ghttp.RespondWithJSONEncoded(http.StatusOK, atc.AuthToken{Type: "Bearer", Value: "some-new-token"}),
+				)
+
+				sess := run(nil, "--verbose=2", "login", "-u", "some username", "-p", "some password")
+
+				stderr := string(sess.Err.Contents())
+				Expect(stderr).To(ContainSubstring("/api/v1/auth/token: 200 OK"))
+				Expect(stderr).To(ContainSubstring("(body redacted)"))
+				Expect(stderr).NotTo(ContainSubstring("some-new-token"))
+			})
+
+			It("truncates bodies to 4 KB", func() {
+				pipelines = []atc.Pipeline{{Name: strings.Repeat("x", 8*1024)}}
+
+				sess := run(nil, "--verbose=2", "pipelines")
+
+				stderr := string(sess.Err.Contents())
+				Expect(stderr).To(ContainSubstring("... (truncated)"))
+				Expect(stderr).NotTo(ContainSubstring(strings.Repeat("x", 5*1024)))
+
+				// the command still sees the whole body
+				Expect(sess.Out).To(gbytes.Say(strings.Repeat("x", 8*1024)))
+			})
+		})
+	})
+})
@@ -0,0 +1,95 @@
+package integration_test
+
+import (
+	"net/http"
+	"os/exec"
+
+	"github.com/concourse/atc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("version mismatches", func() {
+		var atcServer *ghttp.Server
+
+		BeforeEach(func() {
+			atcServer = ghttp.NewServer()
+		})
+
+		AfterEach(func() {
+			atcServer.Close()
+		})
+
+		pipelines := func(path string, args ...string) *gexec.Session {
+			flyCmd := exec.Command(path, append([]string{"-t", atcServer.URL()}, append(args, "pipelines")...)...)
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			return sess
+		}
+
+		atcVersion := func(version string) {
+			atcServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/info"),
+					ghttp.RespondWith(http.StatusOK, `{"version":"`+version+`"}`),
+				),
+			)
+		}
+
+		listsPipelines := func() {
+			atcServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/pipelines"),
+					ghttp.RespondWithJSONEncoded(200, []atc.Pipeline{}),
+				),
+			)
+		}
+
+		It("warns when the minor versions differ", func() {
+			atcVersion("1.3.0")
+			listsPipelines()
+
+			sess := pipelines(versionedFlyPath)
+			Expect(sess.Err).To(gbytes.Say(`warning: fly version 1.2.0 does not match the target's version 1.3.0; run 'fly -t ` + atcServer.URL() + ` sync' to update fly`))
+		})
+
+		It("doesn't warn when only the patch versions differ", func() {
+			atcVersion("1.2.5")
+			listsPipelines()
+
+			sess := pipelines(versionedFlyPath)
+			Expect(sess.Err).NotTo(gbytes.Say("warning"))
+		})
+
+		It("doesn't check with --ignore-version-mismatch", func() {
+			listsPipelines()
+
+			sess := pipelines(versionedFlyPath, "--ignore-version-mismatch")
+			Expect(sess.Err).NotTo(gbytes.Say("warning"))
+		})
+
+		It("carries on when the version can't be fetched", func() {
+			atcServer.AppendHandlers(ghttp.RespondWith(http.StatusInternalServerError, ""))
+			listsPipelines()
+
+			sess := pipelines(versionedFlyPath)
+			Expect(sess.Err).NotTo(gbytes.Say("warning"))
+		})
+
+		It("doesn't check development builds", func() {
+			listsPipelines()
+
+			sess := pipelines(flyPath)
+			Expect(sess.Err).NotTo(gbytes.Say("warning"))
+		})
+	})
+})
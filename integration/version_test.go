@@ -0,0 +1,68 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"os/exec"
+	"runtime"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Fly CLI", func() {
+	run := func(args ...string) *gexec.Session {
+		sess, err := gexec.Start(exec.Command(versionedFlyPath, args...), GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+
+		<-sess.Exited
+		Expect(sess.ExitCode()).To(Equal(0))
+
+		return sess
+	}
+
+	Describe("version", func() {
+		It("prints the version and build metadata", func() {
+			sess := run("version")
+			Expect(sess.Out).To(gbytes.Say("fly " + versionedFlyVersion))
+			Expect(sess.Out).To(gbytes.Say("git sha: " + versionedFlyGitSHA))
+			Expect(sess.Out).To(gbytes.Say("build date: " + versionedFlyBuildDate))
+			Expect(sess.Out).To(gbytes.Say("platform: " + runtime.GOOS + "/" + runtime.GOARCH))
+		})
+
+		It("prints JSON with --json", func() {
+			sess := run("version", "--json")
+
+			var printed map[string]string
+			err := json.Unmarshal(sess.Out.Contents(), &printed)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(printed).To(Equal(map[string]string{
+				"version":    versionedFlyVersion,
+				"git_sha":    versionedFlyGitSHA,
+				"build_date": versionedFlyBuildDate,
+				"platform":   runtime.GOOS + "/" + runtime.GOARCH,
+			}))
+		})
+	})
+
+	Describe("--version", func() {
+		It("prints the version without needing a command", func() {
+			sess := run("--version")
+			Expect(sess.Out).To(gbytes.Say("fly " + versionedFlyVersion))
+		})
+	})
+
+	Context("with a development build", func() {
+		It("reports what it doesn't know", func() {
+			sess, err := gexec.Start(exec.Command(flyPath, "version"), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+			Expect(sess.Out).To(gbytes.Say("fly 0.0.0-dev"))
+			Expect(sess.Out).To(gbytes.Say("git sha: unknown"))
+		})
+	})
+})
@@ -1,6 +1,8 @@
 package integration_test
 
 import (
+	"encoding/json"
+	"net/http"
 	"os/exec"
 
 	"github.com/concourse/atc"
@@ -74,17 +76,70 @@ var _ = Describe("Fly CLI", func() {
 						{Contents: "validity", Color: color.New(color.Bold)},
 						{Contents: "worker", Color: color.New(color.Bold)},
 						{Contents: "version", Color: color.New(color.Bold)},
+						{Contents: "type", Color: color.New(color.Bold)},
+						{Contents: "size", Color: color.New(color.Bold)},
 					},
 					Data: []ui.TableRow{
-						{{Contents: "aaabbb"}, {Contents: "01:23:20"}, {Contents: "01:40:00"}, {Contents: "cccccc"}, {Contents: "another: field, version: two"}},
-						{{Contents: "bbbbbb"}, {Contents: "00:00:50"}, {Contents: "00:10:00"}, {Contents: "cccccc"}, {Contents: "version: one"}},
-						{{Contents: "aaaaaa"}, {Contents: "23:59:00"}, {Contents: "24:00:00"}, {Contents: "dddddd"}, {Contents: "version: three"}},
-						{{Contents: "cccccc"}, {Contents: "00:03:20"}, {Contents: "00:05:00"}, {Contents: "dddddd"}, {Contents: "n/a", Color: color.New(color.Faint)}},
+						{{Contents: "aaabbb"}, {Contents: "01:23:20"}, {Contents: "01:40:00"}, {Contents: "cccccc"}, {Contents: "another: field, version: two"}, {Contents: "resource"}, {Contents: "n/a", Color: color.New(color.Faint)}},
+						{{Contents: "bbbbbb"}, {Contents: "00:00:50"}, {Contents: "00:10:00"}, {Contents: "cccccc"}, {Contents: "version: one"}, {Contents: "resource"}, {Contents: "n/a", Color: color.New(color.Faint)}},
+						{{Contents: "aaaaaa"}, {Contents: "23:59:00"}, {Contents: "24:00:00"}, {Contents: "dddddd"}, {Contents: "version: three"}, {Contents: "resource"}, {Contents: "n/a", Color: color.New(color.Faint)}},
+						{{Contents: "cccccc"}, {Contents: "00:03:20"}, {Contents: "00:05:00"}, {Contents: "dddddd"}, {Contents: "n/a", Color: color.New(color.Faint)}, {Contents: "unknown", Color: color.New(color.Faint)}, {Contents: "n/a", Color: color.New(color.Faint)}},
 					},
 				}))
 
 				Expect(flyCmd).To(HaveExited(0))
 			})
+
+			Context("when --worker is given", func() {
+				BeforeEach(func() {
+					flyCmd.Args = append(flyCmd.Args, "--worker", "dddddd", "--json")
+				})
+
+				It("only lists the volumes on that worker", func() {
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(0))
+
+					var volumes []atc.Volume
+					err = json.Unmarshal(sess.Out.Contents(), &volumes)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(volumes).To(HaveLen(2))
+					Expect(volumes[0].ID).To(Equal("aaaaaa"))
+					Expect(volumes[1].ID).To(Equal("cccccc"))
+				})
+			})
+		})
+
+		Context("when the ATC reports sizes and pages the volumes", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/volumes"),
+						ghttp.RespondWith(200, `[{"id":"aaaaaa","worker_name":"cccccc","size_in_bytes":1572864,"type":"task-cache"}]`, http.Header{
+							"Link": {`</api/v1/volumes?since=aaaaaa>; rel="next"`},
+						}),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/volumes", "since=aaaaaa"),
+						ghttp.RespondWith(200, `[{"id":"bbbbbb","worker_name":"cccccc","size_in_bytes":3221225472,"type":"container"}]`),
+					),
+				)
+			})
+
+			It("lists every page with human sizes", func() {
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				Expect(sess.Out).To(gbytes.Say(`aaaaaa .* task-cache\s+1.5 MiB`))
+				Expect(sess.Out).To(gbytes.Say(`bbbbbb .* container\s+3.0 GiB`))
+				Expect(atcServer.ReceivedRequests()).To(HaveLen(2))
+			})
 		})
 
 		Context("and the api returns an internal server error", func() {
@@ -100,7 +155,7 @@ var _ = Describe("Fly CLI", func() {
 			It("writes an error message to stderr", func() {
 				sess, err := gexec.Start(flyCmd, nil, nil)
 				Expect(err).ToNot(HaveOccurred())
-				Eventually(sess.Err).Should(gbytes.Say("Unexpected Response"))
+				Eventually(sess.Err).Should(gbytes.Say(`bad response listing volumes \(500 Internal Server Error\)`))
 				Eventually(sess).Should(gexec.Exit(1))
 			})
 		})
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"os"
 	"os/exec"
 
 	. "github.com/onsi/ginkgo"
@@ -98,8 +99,7 @@ var _ = Describe("Watching", func() {
 				ghttp.CombineHandlers(
 					ghttp.VerifyRequest("GET", "/api/v1/builds"),
 					ghttp.RespondWithJSONEncoded(200, []atc.Build{
-						{ID: 4, Name: "1", Status: "started", JobName: "some-job"},
-						{ID: 3, Name: "3", Status: "started"},
+						{ID: 3, Name: "1", Status: "started", JobName: "some-job"},
 						{ID: 2, Name: "2", Status: "started"},
 						{ID: 1, Name: "1", Status: "finished"},
 					}),
@@ -108,11 +108,66 @@ var _ = Describe("Watching", func() {
 			)
 		})
 
-		It("watches the most recent one-off build", func() {
+		It("watches the most recent build, even if it belongs to a job", func() {
 			watch()
 		})
 	})
 
+	Context("when there are no builds", func() {
+		BeforeEach(func() {
+			atcServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/builds"),
+					ghttp.RespondWithJSONEncoded(200, []atc.Build{}),
+				),
+			)
+		})
+
+		It("returns an error and exits", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "watch")
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
+			Expect(sess.Err).To(gbytes.Say("no builds found"))
+		})
+	})
+
+	Context("with FLY_TIMESTAMPS=1 set", func() {
+		BeforeEach(func() {
+			atcServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/builds"),
+					ghttp.RespondWithJSONEncoded(200, []atc.Build{
+						{ID: 3, Name: "3", Status: "started"},
+					}),
+				),
+				eventsHandler(),
+			)
+		})
+
+		It("prefixes each line of output with a timestamp", func() {
+			flyCmd := exec.Command(flyPath, "-t", atcServer.URL(), "watch")
+			flyCmd.Env = append(os.Environ(), "FLY_TIMESTAMPS=1")
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(streaming).Should(BeClosed())
+
+			events <- event.Log{Payload: "sup\n"}
+
+			Eventually(sess.Out).Should(gbytes.Say(`\d\d:\d\d:\d\d sup`))
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+		})
+	})
+
 	Context("with a specific job and pipeline", func() {
 		Context("when the job has no builds", func() {
 			BeforeEach(func() {
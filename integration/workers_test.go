@@ -1,6 +1,7 @@
 package integration_test
 
 import (
+	"encoding/json"
 	"os/exec"
 
 	"github.com/concourse/atc"
@@ -112,6 +113,47 @@ var _ = Describe("Fly CLI", func() {
 					Expect(flyCmd).To(HaveExited(0))
 				})
 			})
+
+			Context("when --sort containers is given", func() {
+				BeforeEach(func() {
+					flyCmd.Args = append(flyCmd.Args, "--sort", "containers")
+				})
+
+				It("lists the busiest workers first", func() {
+					Expect(flyCmd).To(PrintTable(ui.Table{
+						Headers: ui.Headers("name", "containers", "platform", "tags"),
+						Data: []ui.TableRow{
+							{{Contents: "worker-3"}, {Contents: "10"}, {Contents: "platform3"}, {Contents: "none", Color: color.New(color.Faint)}},
+							{{Contents: "worker-1"}, {Contents: "1"}, {Contents: "platform1"}, {Contents: "tag1"}},
+							{{Contents: "worker-2"}, {Contents: "0"}, {Contents: "platform2"}, {Contents: "tag2, tag3"}},
+						},
+					}))
+
+					Expect(flyCmd).To(HaveExited(0))
+				})
+			})
+
+			Context("when --json is given", func() {
+				BeforeEach(func() {
+					flyCmd.Args = append(flyCmd.Args, "--json")
+				})
+
+				It("prints the workers as JSON, ordered by name", func() {
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(0))
+
+					var workers []atc.Worker
+					err = json.Unmarshal(sess.Out.Contents(), &workers)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(workers).To(HaveLen(3))
+					Expect(workers[0].Name).To(Equal("worker-1"))
+					Expect(workers[0].ResourceTypes).To(HaveLen(2))
+				})
+			})
 		})
 
 		Context("and the api returns an internal server error", func() {
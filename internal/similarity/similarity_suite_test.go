@@ -0,0 +1,13 @@
+package similarity_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestSimilarity(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Similarity Suite")
+}
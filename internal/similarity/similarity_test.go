@@ -0,0 +1,25 @@
+package similarity_test
+
+import (
+	. "github.com/concourse/fly/internal/similarity"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EditDistance", func() {
+	It("is zero for identical strings", func() {
+		Expect(EditDistance("inputs", "inputs")).To(Equal(0))
+	})
+
+	It("counts insertions, deletions, and substitutions", func() {
+		Expect(EditDistance("input", "inputs")).To(Equal(1))
+		Expect(EditDistance("outputs", "output")).To(Equal(1))
+		Expect(EditDistance("platfrom", "platform")).To(Equal(2))
+	})
+
+	It("is the length of the other string when one is empty", func() {
+		Expect(EditDistance("", "run")).To(Equal(3))
+		Expect(EditDistance("run", "")).To(Equal(3))
+	})
+})
@@ -9,9 +9,15 @@ import (
 )
 
 func main() {
+	err := commands.Fly.LoadDefaultTarget()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+
 	parser := flags.NewParser(&commands.Fly, flags.HelpFlag|flags.PassDoubleDash)
 
-	_, err := parser.Parse()
+	_, err = parser.Parse()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %s\n", err)
 		os.Exit(1)
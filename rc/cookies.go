@@ -0,0 +1,9 @@
+package rc
+
+import "net/http/cookiejar"
+
+// cookieJar is shared by every connection for as long as fly runs, so that
+// cookies the ATC sets, e.g. for a load balancer's session affinity, are
+// sent back with later requests: a build's event stream, including any
+// reconnects, has to reach the same node that created the build
+var cookieJar, _ = cookiejar.New(nil)
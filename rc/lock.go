@@ -0,0 +1,34 @@
+package rc
+
+import (
+	"fmt"
+	"os"
+)
+
+// targetsLock is held around changes to .flyrc; it locks a file beside it
+// rather than .flyrc itself, which is replaced on every write
+type targetsLock struct {
+	file *os.File
+}
+
+func lockTargets(configFileLocation string) (*targetsLock, error) {
+	lockFileLocation := configFileLocation + ".lock"
+
+	file, err := os.OpenFile(lockFileLocation, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s: %s", lockFileLocation, err)
+	}
+
+	err = lockFile(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("could not lock %s: %s", lockFileLocation, err)
+	}
+
+	return &targetsLock{file: file}, nil
+}
+
+func (lock *targetsLock) release() {
+	unlockFile(lock.file)
+	lock.file.Close()
+}
@@ -0,0 +1,32 @@
+package rc
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// proxyFunc sends requests through the given proxy, or else whichever one
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY select for the request
+func proxyFunc(proxy string) (func(*http.Request) (*url.URL, error), error) {
+	if proxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	proxyURL, err := url.Parse(proxy)
+	if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL: %s", proxy)
+	}
+
+	return http.ProxyURL(proxyURL), nil
+}
+
+// ProxyURL returns the proxy to send the request through, if any
+func ProxyURL(proxy string, req *http.Request) (*url.URL, error) {
+	proxyFunc, err := proxyFunc(proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	return proxyFunc(req)
+}
@@ -0,0 +1,129 @@
+package rc_test
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+
+	"github.com/concourse/fly/rc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Proxies", func() {
+	var tmpDir string
+
+	var atcServer *httptest.Server
+	var proxyServer *httptest.Server
+
+	var tunneled chan string
+	var finishStream chan struct{}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "fly-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		if runtime.GOOS == "windows" {
+			os.Setenv("USERPROFILE", tmpDir)
+		} else {
+			os.Setenv("HOME", tmpDir)
+		}
+
+		tunneled = make(chan string, 1)
+		finishStream = make(chan struct{})
+
+		atcServer = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+
+			io.WriteString(w, "id: 0\nevent: event\ndata: hello\n\n")
+			w.(http.Flusher).Flush()
+
+			<-finishStream
+		}))
+
+		proxyServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "CONNECT" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+
+			tunneled <- r.Host
+
+			atcConn, err := net.Dial("tcp", r.Host)
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+
+			clientConn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				atcConn.Close()
+				return
+			}
+
+			go func() {
+				io.Copy(atcConn, clientConn)
+				atcConn.Close()
+			}()
+
+			io.Copy(clientConn, atcConn)
+			clientConn.Close()
+		}))
+
+		err = rc.SaveTarget("foo", atcServer.URL, true, nil, "")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		close(finishStream)
+		proxyServer.Close()
+		atcServer.Close()
+
+		os.RemoveAll(tmpDir)
+	})
+
+	It("streams events through a tunnel as soon as they are sent", func() {
+		connection, err := rc.TargetConnection("foo", proxyServer.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		resp, err := connection.HTTPClient().Get(atcServer.URL + "/api/v1/builds/1/events")
+		Expect(err).NotTo(HaveOccurred())
+
+		defer resp.Body.Close()
+
+		Expect(<-tunneled).To(Equal(atcServer.Listener.Addr().String()))
+
+		lines := make(chan string)
+		go func() {
+			defer GinkgoRecover()
+
+			reader := bufio.NewReader(resp.Body)
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+
+				lines <- line
+			}
+		}()
+
+		Eventually(lines).Should(Receive(Equal("id: 0\n")))
+		Eventually(lines).Should(Receive(Equal("event: event\n")))
+		Eventually(lines).Should(Receive(Equal("data: hello\n")))
+	})
+
+	It("returns an error for a proxy that isn't a URL", func() {
+		_, err := rc.TargetConnection("foo", "bogus")
+		Expect(err).To(MatchError("invalid proxy URL: bogus"))
+	})
+})
@@ -0,0 +1,32 @@
+package rc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const maxRedirects = 10
+
+// followRedirect lets a connection follow the target's redirects as long as
+// they stay on the same host, e.g. a router upgrading http:// to https://.
+// Every request carries the target's token, so following a redirect to
+// another host, or from https:// to http://, would give the token away.
+func followRedirect(request *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+
+	from := via[len(via)-1].URL
+	to := request.URL
+
+	if to.Hostname() != from.Hostname() {
+		return fmt.Errorf("the target redirected to %s, which is on a different host; if the ATC has moved, update the target's URL to %s://%s", to, to.Scheme, to.Host)
+	}
+
+	if from.Scheme == "https" && to.Scheme != "https" {
+		return errors.New("the target redirected from https to http; refusing to send its token unencrypted")
+	}
+
+	return nil
+}
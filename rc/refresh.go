@@ -0,0 +1,197 @@
+package rc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// tokens are refreshed when they're this close to expiring, so that they
+// don't expire partway through a request
+const refreshWindow = time.Minute
+
+// refreshTokenPath is where an ATC that issues refresh tokens (passing one
+// to the login callback along with the token) is expected to exchange them
+// for new tokens, using OAuth2's refresh_token grant. The ATC's own auth API
+// documents no such endpoint, so refreshing only happens for tokens that came
+// with a refresh token, and an ATC without the endpoint is taken not to
+// support refreshing at all.
+const refreshTokenPath = "/api/v1/auth/token"
+
+// errRefreshUnsupported is returned by exchange when the ATC has nowhere to
+// exchange refresh tokens
+var errRefreshUnsupported = errors.New("the targeted ATC does not support refreshing tokens")
+
+type refreshResponse struct {
+	TokenType    string `json:"token_type"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+func (source *targetTokenSource) expiresSoon() bool {
+	expiry, ok := source.token.ExpiresAt()
+	return ok && time.Now().Add(refreshWindow).After(expiry)
+}
+
+// refresh exchanges the refresh token for a new access token and saves it to
+// .flyrc; must be called with the lock held. The .flyrc lock is held too, so
+// that fly processes sharing the refresh token take turns using it.
+func (source *targetTokenSource) refresh() error {
+	lock, err := lockTargets(source.flyrc)
+	if err != nil {
+		return err
+	}
+
+	defer lock.release()
+
+	// another fly may have refreshed it first, using up the refresh token
+	stale := *source.token
+
+	err = source.reload()
+	if err != nil {
+		return err
+	}
+
+	if source.token.Value != stale.Value {
+		return nil
+	}
+
+	token, err := source.exchange(stale.RefreshToken)
+	if err == errRefreshUnsupported {
+		// keep using the token until it's rejected, but stop trying to
+		// refresh it
+		token = &stale
+		token.RefreshToken = ""
+	} else if err != nil {
+		// a token that hasn't quite expired is still worth trying
+		if expiry, ok := stale.ExpiresAt(); ok && time.Now().Before(expiry) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to refresh token for target '%s': %s", source.targetName, err)
+	}
+
+	err = updateLockedTargets(source.flyrc, func(flyTargets *targetDetailsYAML) error {
+		if target, found := flyTargets.Targets[source.targetName]; found {
+			target.Token = token
+			flyTargets.Targets[source.targetName] = target
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	source.token = token
+
+	if info, err := os.Stat(source.flyrc); err == nil {
+		source.modTime = info.ModTime()
+	}
+
+	return nil
+}
+
+func (source *targetTokenSource) exchange(refreshToken string) (*TargetToken, error) {
+	client := &http.Client{Transport: source.transport}
+
+	resp, err := client.PostForm(strings.TrimRight(source.api, "/")+refreshTokenPath, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		return nil, errRefreshUnsupported
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+
+	var refreshed refreshResponse
+	err = json.NewDecoder(resp.Body).Decode(&refreshed)
+	if err != nil {
+		return nil, err
+	}
+
+	token := &TargetToken{
+		Type:         refreshed.TokenType,
+		Value:        refreshed.AccessToken,
+		RefreshToken: refreshed.RefreshToken,
+	}
+
+	// the refresh token may be reused if a new one isn't issued
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+
+	if refreshed.ExpiresIn != 0 {
+		token.Expiry = time.Now().Add(time.Duration(refreshed.ExpiresIn) * time.Second).Unix()
+	}
+
+	return token, nil
+}
+
+// refreshingTransport refreshes the token and retries once if a request is
+// rejected, e.g. because the token was revoked before its expiry
+type refreshingTransport struct {
+	source *targetTokenSource
+	base   http.RoundTripper
+}
+
+func (transport *refreshingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := transport.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// requests with bodies can't be replayed
+	if req.Body != nil {
+		return resp, nil
+	}
+
+	source := transport.source
+
+	source.lock.Lock()
+
+	source.reload()
+
+	if source.token.RefreshToken == "" {
+		source.lock.Unlock()
+		return resp, nil
+	}
+
+	retry := true
+
+	// only refresh the token that was rejected; it may have been replaced
+	// while the request was in flight
+	if resp.Request == nil || resp.Request.Header.Get("Authorization") == source.token.Type+" "+source.token.Value {
+		rejected := source.token.Value
+
+		err = source.refresh()
+
+		// retrying with the same token would just be rejected again
+		retry = err == nil && source.token.Value != rejected
+	}
+
+	source.lock.Unlock()
+
+	if !retry {
+		return resp, nil
+	}
+
+	resp.Body.Close()
+
+	return transport.base.RoundTrip(req)
+}
@@ -1,7 +1,7 @@
 package rc
 
 import (
-	"crypto/tls"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -9,7 +9,10 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/oauth2"
 
@@ -19,18 +22,27 @@ import (
 )
 
 type TargetProps struct {
-	API      string       `yaml:"api"`
-	Insecure bool         `yaml:"insecure,omitempty"`
-	Token    *TargetToken `yaml:"token,omitempty"`
+	API      string `yaml:"api"`
+	Insecure bool   `yaml:"insecure,omitempty"`
+	CACert   string `yaml:"ca_cert,omitempty"`
+	// PEM-encoded, for ATCs that require client certificates
+	ClientCert string       `yaml:"client_cert,omitempty"`
+	ClientKey  string       `yaml:"client_key,omitempty"`
+	Token      *TargetToken `yaml:"token,omitempty"`
 }
 
 type TargetToken struct {
-	Type  string `yaml:"type"`
-	Value string `yaml:"value"`
+	Type  string `yaml:"type" json:"type"`
+	Value string `yaml:"value" json:"value"`
+
+	// optional; when present the token is refreshed as it expires
+	RefreshToken string `yaml:"refresh_token,omitempty" json:"refresh_token,omitempty"`
+	Expiry       int64  `yaml:"expiry,omitempty" json:"expiry,omitempty"`
 }
 
 type targetDetailsYAML struct {
-	Targets map[string]TargetProps
+	Targets       map[string]TargetProps
+	DefaultTarget string `yaml:"default_target,omitempty"`
 }
 
 func NewTarget(api string, insecure bool, token *TargetToken) TargetProps {
@@ -41,29 +53,171 @@ func NewTarget(api string, insecure bool, token *TargetToken) TargetProps {
 	}
 }
 
-func SaveTarget(targetName string, api string, insecure bool, token *TargetToken) error {
-	flyrc := filepath.Join(userHomeDir(), ".flyrc")
-	flyTargets, err := loadTargets(flyrc)
+func SaveTarget(targetName string, api string, insecure bool, token *TargetToken, caCert string) error {
+	api, err := NormalizeURL(api)
 	if err != nil {
 		return err
 	}
 
-	newInfo := flyTargets.Targets[targetName]
-	newInfo.API = api
-	newInfo.Insecure = insecure
-	newInfo.Token = token
+	flyrc := flyrcPath()
+	return updateTargets(flyrc, func(flyTargets *targetDetailsYAML) error {
+		newInfo := flyTargets.Targets[targetName]
+		newInfo.API = api
+		newInfo.Insecure = insecure
+		newInfo.CACert = caCert
+		newInfo.Token = token
+
+		flyTargets.Targets[targetName] = newInfo
+
+		return nil
+	})
+}
+
+func DeleteTarget(targetName string) error {
+	flyrc := flyrcPath()
+	return updateTargets(flyrc, func(flyTargets *targetDetailsYAML) error {
+		if _, ok := flyTargets.Targets[targetName]; !ok {
+			return fmt.Errorf("Unable to find target %s in %s", targetName, flyrc)
+		}
+
+		delete(flyTargets.Targets, targetName)
+
+		if flyTargets.DefaultTarget == targetName {
+			flyTargets.DefaultTarget = ""
+		}
+
+		return nil
+	})
+}
+
+// SaveClientCertificate sets the certificate the target's connections
+// present, replacing any saved before
+func SaveClientCertificate(targetName string, cert string, key string) error {
+	flyrc := flyrcPath()
+	return updateTargets(flyrc, func(flyTargets *targetDetailsYAML) error {
+		target, ok := flyTargets.Targets[targetName]
+		if !ok {
+			return fmt.Errorf("Unable to find target %s in %s", targetName, flyrc)
+		}
+
+		target.ClientCert = cert
+		target.ClientKey = key
+		flyTargets.Targets[targetName] = target
+
+		return nil
+	})
+}
+
+// RenameTarget moves a target to a new name. If force is set, any target
+// already by that name is replaced, but only once the old one is known to
+// exist.
+func RenameTarget(oldName string, newName string, force bool) error {
+	flyrc := flyrcPath()
+	return updateTargets(flyrc, func(flyTargets *targetDetailsYAML) error {
+		target, ok := flyTargets.Targets[oldName]
+		if !ok {
+			return fmt.Errorf("Unable to find target %s in %s", oldName, flyrc)
+		}
+
+		if _, exists := flyTargets.Targets[newName]; exists && newName != oldName {
+			if !force {
+				return fmt.Errorf("Target %s already exists in %s", newName, flyrc)
+			}
+
+			if flyTargets.DefaultTarget == newName {
+				flyTargets.DefaultTarget = ""
+			}
+		}
+
+		delete(flyTargets.Targets, oldName)
+		flyTargets.Targets[newName] = target
+
+		if flyTargets.DefaultTarget == oldName {
+			flyTargets.DefaultTarget = newName
+		}
+
+		return nil
+	})
+}
+
+func DeleteAllTargets() error {
+	flyrc := flyrcPath()
+	return updateTargets(flyrc, func(flyTargets *targetDetailsYAML) error {
+		flyTargets.Targets = map[string]TargetProps{}
+		flyTargets.DefaultTarget = ""
+
+		return nil
+	})
+}
+
+// SetDefaultTarget saves the target to use when none is given
+func SetDefaultTarget(targetName string) error {
+	flyrc := flyrcPath()
+	return updateTargets(flyrc, func(flyTargets *targetDetailsYAML) error {
+		if _, ok := flyTargets.Targets[targetName]; !ok {
+			return fmt.Errorf("Unable to find target %s in %s", targetName, flyrc)
+		}
+
+		flyTargets.DefaultTarget = targetName
+
+		return nil
+	})
+}
+
+// DefaultTarget returns the target to use when none is given, from
+// FLY_TARGET or else the default saved in .flyrc, and which of them it came
+// from; it is empty if neither is set
+func DefaultTarget() (string, string, error) {
+	if target := os.Getenv("FLY_TARGET"); target != "" {
+		return target, "FLY_TARGET", nil
+	}
+
+	flyTargets, err := loadTargets(flyrcPath())
+	if err != nil {
+		return "", "", err
+	}
+
+	if flyTargets.DefaultTarget == "" {
+		return "", "", nil
+	}
+
+	return flyTargets.DefaultTarget, "default target", nil
+}
+
+// NoTargetError explains how to choose a target, for when none was given
+func NoTargetError() error {
+	message := "no target specified; use -t, set FLY_TARGET, or run 'fly set-default-target <target>'"
+
+	targets, err := LoadTargets()
+	if err != nil || len(targets) == 0 {
+		return errors.New(message)
+	}
+
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
 
-	flyTargets.Targets[targetName] = newInfo
+	sort.Strings(names)
 
-	return writeTargets(flyrc, flyTargets)
+	return fmt.Errorf("%s\n\nsaved targets:\n  %s", message, strings.Join(names, "\n  "))
 }
 
 func SelectTarget(selectedTarget string) (TargetProps, error) {
+	if selectedTarget == "" {
+		return TargetProps{}, NoTargetError()
+	}
+
 	if isURL(selectedTarget) {
-		return NewTarget(selectedTarget, false, nil), nil
+		api, err := NormalizeURL(selectedTarget)
+		if err != nil {
+			return TargetProps{}, err
+		}
+
+		return NewTarget(api, false, nil), nil
 	}
 
-	flyrc := filepath.Join(userHomeDir(), ".flyrc")
+	flyrc := flyrcPath()
 	flyTargets, err := loadTargets(flyrc)
 	if err != nil {
 		return TargetProps{}, err
@@ -74,79 +228,200 @@ func SelectTarget(selectedTarget string) (TargetProps, error) {
 		return TargetProps{}, fmt.Errorf("Unable to find target %s in %s", selectedTarget, flyrc)
 	}
 
+	// targets saved before URLs were normalized may need it
+	target.API, err = NormalizeURL(target.API)
+	if err != nil {
+		return TargetProps{}, err
+	}
+
 	return target, nil
 }
 
-func NewConnection(atcURL string, insecure bool) (concourse.Connection, error) {
-	var tlsConfig *tls.Config
-	if insecure {
-		tlsConfig = &tls.Config{InsecureSkipVerify: insecure}
+// LoadTargets returns every target saved in .flyrc, by name
+func LoadTargets() (map[string]TargetProps, error) {
+	flyTargets, err := loadTargets(flyrcPath())
+	if err != nil {
+		return nil, err
+	}
+
+	if flyTargets.Targets == nil {
+		return map[string]TargetProps{}, nil
 	}
 
-	var transport http.RoundTripper
+	return flyTargets.Targets, nil
+}
 
-	transport = &http.Transport{
-		TLSClientConfig: tlsConfig,
+// NewConnection connects to the target without its token, e.g. to log in
+func NewConnection(target TargetProps, proxy string) (concourse.Connection, error) {
+	transport, err := newTransport(target, proxy)
+	if err != nil {
+		return nil, err
 	}
 
-	return concourse.NewConnection(atcURL, &http.Client{
-		Transport: transport,
+	return concourse.NewConnection(target.API, &http.Client{
+		Transport:     transport,
+		CheckRedirect: followRedirect,
+		Jar:           cookieJar,
 	})
 }
 
-func TargetConnection(selectedTarget string) (concourse.Connection, error) {
-	return CommandTargetConnection(selectedTarget, nil)
+// TargetConnection connects to the target with its token and TLS settings;
+// everything talking to the ATC, including event streams, goes through its
+// transport, which goes through the given proxy or else the one configured by
+// the environment
+func TargetConnection(selectedTarget string, proxy string) (concourse.Connection, error) {
+	target, err := SelectTarget(selectedTarget)
+	if err != nil {
+		return nil, err
+	}
+
+	var transport http.RoundTripper
+
+	transport, err = newTransport(target, proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.Token != nil {
+		source := newTargetTokenSource(selectedTarget, flyrcPath(), target.API, target.Token, transport)
+
+		transport = &refreshingTransport{
+			source: source,
+			base: &oauth2.Transport{
+				Source: source,
+				Base:   transport,
+			},
+		}
+	}
+
+	// the token is added to each request by the transport, so redirected
+	// requests carry it too
+	httpClient := &http.Client{
+		Transport:     transport,
+		CheckRedirect: followRedirect,
+		Jar:           cookieJar,
+	}
+
+	return concourse.NewConnection(target.API, httpClient)
 }
 
-func CommandTargetConnection(selectedTarget string, commandInsecure *bool) (concourse.Connection, error) {
-	if isURL(selectedTarget) {
-		return NewConnection(selectedTarget, false)
+func newTransport(target TargetProps, proxy string) (http.RoundTripper, error) {
+	tlsConfig, err := TLSConfig(target)
+	if err != nil {
+		return nil, err
 	}
 
-	flyrc := filepath.Join(userHomeDir(), ".flyrc")
-	flyTargets, err := loadTargets(flyrc)
+	proxyFunc, err := proxyFunc(proxy)
 	if err != nil {
 		return nil, err
 	}
 
-	target, ok := flyTargets.Targets[selectedTarget]
-	if !ok {
-		return nil, fmt.Errorf("Unable to find target %s in %s", selectedTarget, flyrc)
+	var transport http.RoundTripper = newHTTPTransport(proxyFunc, tlsConfig)
+
+	if traceVerbosity > 0 {
+		transport = newTracingTransport(transport, traceVerbosity)
 	}
 
-	var token *oauth2.Token
-	if target.Token != nil {
-		token = &oauth2.Token{
-			TokenType:   target.Token.Type,
-			AccessToken: target.Token.Value,
-		}
+	return userAgentTransport{
+		base: timeoutTransport{
+			base:    transport,
+			timeout: requestTimeout,
+		},
+	}, nil
+}
+
+// targetTokenSource provides the target's token, picking up any new one saved
+// to .flyrc, e.g. by logging in again partway through a long-running command
+type targetTokenSource struct {
+	targetName string
+	flyrc      string
+	api        string
+	transport  http.RoundTripper
+
+	lock    sync.Mutex
+	token   *TargetToken
+	modTime time.Time
+}
+
+func newTargetTokenSource(targetName string, flyrc string, api string, token *TargetToken, transport http.RoundTripper) *targetTokenSource {
+	source := &targetTokenSource{
+		targetName: targetName,
+		flyrc:      flyrc,
+		api:        api,
+		transport:  transport,
+		token:      token,
 	}
 
-	var tlsConfig *tls.Config
-	if commandInsecure != nil {
-		tlsConfig = &tls.Config{InsecureSkipVerify: *commandInsecure}
-	} else if target.Insecure {
-		tlsConfig = &tls.Config{InsecureSkipVerify: true}
+	if info, err := os.Stat(flyrc); err == nil {
+		source.modTime = info.ModTime()
 	}
 
-	var transport http.RoundTripper
+	return source
+}
 
-	transport = &http.Transport{
-		TLSClientConfig: tlsConfig,
+func (source *targetTokenSource) Token() (*oauth2.Token, error) {
+	source.lock.Lock()
+	defer source.lock.Unlock()
+
+	err := source.reload()
+	if err != nil {
+		return nil, err
 	}
 
-	if token != nil {
-		transport = &oauth2.Transport{
-			Source: oauth2.StaticTokenSource(token),
-			Base:   transport,
+	if source.token.RefreshToken != "" && source.expiresSoon() {
+		err := source.refresh()
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	httpClient := &http.Client{
-		Transport: transport,
+	return &oauth2.Token{
+		TokenType:   source.token.Type,
+		AccessToken: source.token.Value,
+	}, nil
+}
+
+// reload picks up the target's token from .flyrc if it has been written to
+// since it was last read
+func (source *targetTokenSource) reload() error {
+	info, err := os.Stat(source.flyrc)
+	if err != nil || info.ModTime().Equal(source.modTime) {
+		return nil
 	}
 
-	return concourse.NewConnection(target.API, httpClient)
+	flyTargets, err := loadTargets(source.flyrc)
+	if err != nil {
+		return err
+	}
+
+	if target, found := flyTargets.Targets[source.targetName]; found && target.Token != nil {
+		source.token = target.Token
+	}
+
+	source.modTime = info.ModTime()
+
+	return nil
+}
+
+// flyrcOverride is the path given with --flyrc, if any
+var flyrcOverride string
+
+// SetFlyrc saves and loads targets at the given path from now on, instead of
+// $FLYRC or ~/.flyrc
+func SetFlyrc(path string) {
+	flyrcOverride = path
+}
+
+func flyrcPath() string {
+	if flyrcOverride != "" {
+		return flyrcOverride
+	}
+
+	if path := os.Getenv("FLYRC"); path != "" {
+		return path
+	}
+
+	return filepath.Join(userHomeDir(), ".flyrc")
 }
 
 func userHomeDir() string {
@@ -188,14 +463,66 @@ func loadTargets(configFileLocation string) (*targetDetailsYAML, error) {
 	return flyTargets, nil
 }
 
+// updateTargets changes .flyrc while holding its lock, so that concurrent
+// fly processes don't lose each other's changes
+func updateTargets(configFileLocation string, update func(*targetDetailsYAML) error) error {
+	err := os.MkdirAll(filepath.Dir(configFileLocation), 0700)
+	if err != nil {
+		return fmt.Errorf("could not create directory for %s", configFileLocation)
+	}
+
+	lock, err := lockTargets(configFileLocation)
+	if err != nil {
+		return err
+	}
+
+	defer lock.release()
+
+	return updateLockedTargets(configFileLocation, update)
+}
+
+// updateLockedTargets is updateTargets for when the lock is already held
+func updateLockedTargets(configFileLocation string, update func(*targetDetailsYAML) error) error {
+	flyTargets, err := loadTargets(configFileLocation)
+	if err != nil {
+		return err
+	}
+
+	err = update(flyTargets)
+	if err != nil {
+		return err
+	}
+
+	return writeTargets(configFileLocation, flyTargets)
+}
+
+// writeTargets replaces .flyrc in one go, so that concurrent fly processes
+// never see it half-written
 func writeTargets(configFileLocation string, targetsToWrite *targetDetailsYAML) error {
 	yamlBytes, err := yaml.Marshal(targetsToWrite)
 	if err != nil {
 		return fmt.Errorf("could not marshal %s", configFileLocation)
 	}
 
-	err = ioutil.WriteFile(configFileLocation, yamlBytes, os.ModePerm)
+	tmpFile, err := ioutil.TempFile(filepath.Dir(configFileLocation), ".flyrc")
+	if err != nil {
+		return fmt.Errorf("could not write %s", configFileLocation)
+	}
+
+	_, err = tmpFile.Write(yamlBytes)
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+
+	tmpFile.Close()
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return fmt.Errorf("could not write %s", configFileLocation)
+	}
+
+	err = os.Rename(tmpFile.Name(), configFileLocation)
 	if err != nil {
+		os.Remove(tmpFile.Name())
 		return fmt.Errorf("could not write %s", configFileLocation)
 	}
 
@@ -1,14 +1,26 @@
 package rc_test
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
 	"io/ioutil"
+	"math/big"
+	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sync"
+	"time"
 
 	"github.com/concourse/fly/rc"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
 )
 
 var _ = Describe("Targets", func() {
@@ -40,9 +52,10 @@ var _ = Describe("Targets", func() {
 				targetName = "foo"
 				err := rc.SaveTarget(
 					targetName,
-					"some api url",
+					"https://some-api.example.com",
 					false,
 					nil,
+					"",
 				)
 				Expect(err).ToNot(HaveOccurred())
 			})
@@ -60,9 +73,10 @@ var _ = Describe("Targets", func() {
 				targetName = "foo"
 				err := rc.SaveTarget(
 					targetName,
-					"some api url",
+					"https://some-api.example.com",
 					true,
 					nil,
+					"",
 				)
 				Expect(err).ToNot(HaveOccurred())
 			})
@@ -87,4 +101,625 @@ var _ = Describe("Targets", func() {
 			})
 		})
 	})
+
+	Describe("TargetConnection", func() {
+		var atcServer *ghttp.Server
+
+		BeforeEach(func() {
+			atcServer = ghttp.NewServer()
+
+			err := rc.SaveTarget("foo", atcServer.URL(), false, &rc.TargetToken{Type: "Bearer", Value: "old-token"}, "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			atcServer.Close()
+		})
+
+		It("picks up a token saved after the connection was made", func() {
+			atcServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("Authorization", "Bearer old-token"),
+					ghttp.RespondWith(http.StatusOK, nil),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("Authorization", "Bearer new-token"),
+					ghttp.RespondWith(http.StatusOK, nil),
+				),
+			)
+
+			connection, err := rc.TargetConnection("foo", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = connection.HTTPClient().Get(atcServer.URL())
+			Expect(err).NotTo(HaveOccurred())
+
+			err = rc.SaveTarget("foo", atcServer.URL(), false, &rc.TargetToken{Type: "Bearer", Value: "new-token"}, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			// ensure the change is visible even with coarse modification times
+			future := time.Now().Add(time.Minute)
+			err = os.Chtimes(flyrc, future, future)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = connection.HTTPClient().Get(atcServer.URL())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(atcServer.ReceivedRequests()).To(HaveLen(2))
+		})
+	})
+
+	Describe("TLS settings", func() {
+		var atcServer *ghttp.Server
+		var caCert string
+
+		BeforeEach(func() {
+			atcServer = ghttp.NewTLSServer()
+			atcServer.AppendHandlers(ghttp.RespondWith(http.StatusOK, nil))
+
+			caCert = string(pem.EncodeToMemory(&pem.Block{
+				Type:  "CERTIFICATE",
+				Bytes: atcServer.HTTPTestServer.TLS.Certificates[0].Certificate[0],
+			}))
+		})
+
+		AfterEach(func() {
+			atcServer.Close()
+		})
+
+		get := func() error {
+			connection, err := rc.TargetConnection("foo", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = connection.HTTPClient().Get(atcServer.URL())
+			return err
+		}
+
+		It("verifies the target's certificate against its saved CA certificate", func() {
+			err := rc.SaveTarget("foo", atcServer.URL(), false, nil, caCert)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(get()).To(Succeed())
+		})
+
+		It("fails to verify the certificate without the CA certificate", func() {
+			err := rc.SaveTarget("foo", atcServer.URL(), false, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(get()).To(MatchError(ContainSubstring("certificate signed by unknown authority")))
+		})
+
+		It("skips verification for insecure targets", func() {
+			err := rc.SaveTarget("foo", atcServer.URL(), true, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(get()).To(Succeed())
+		})
+
+		It("returns an error for a CA certificate that isn't PEM", func() {
+			err := rc.SaveTarget("foo", atcServer.URL(), false, nil, "bogus")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = rc.TargetConnection("foo", "")
+			Expect(err).To(MatchError("CA certificate is not valid PEM"))
+		})
+	})
+
+	Describe("client certificates", func() {
+		var atcServer *ghttp.Server
+		var clientCert string
+		var clientKey *rsa.PrivateKey
+
+		BeforeEach(func() {
+			atcServer = ghttp.NewTLSServer()
+			atcServer.HTTPTestServer.TLS.ClientAuth = tls.RequireAnyClientCert
+			atcServer.AppendHandlers(ghttp.RespondWith(http.StatusOK, nil))
+
+			var err error
+			clientKey, err = rsa.GenerateKey(rand.Reader, 1024)
+			Expect(err).NotTo(HaveOccurred())
+
+			template := &x509.Certificate{
+				SerialNumber: big.NewInt(1),
+				Subject:      pkix.Name{CommonName: "fly"},
+				NotBefore:    time.Now().Add(-time.Hour),
+				NotAfter:     time.Now().Add(time.Hour),
+				ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			}
+
+			der, err := x509.CreateCertificate(rand.Reader, template, template, &clientKey.PublicKey, clientKey)
+			Expect(err).NotTo(HaveOccurred())
+
+			clientCert = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+			err = rc.SaveTarget("foo", atcServer.URL(), true, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			atcServer.Close()
+		})
+
+		It("presents the target's client certificate", func() {
+			key := pem.EncodeToMemory(&pem.Block{
+				Type:  "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(clientKey),
+			})
+
+			err := rc.SaveClientCertificate("foo", clientCert, string(key))
+			Expect(err).NotTo(HaveOccurred())
+
+			connection, err := rc.TargetConnection("foo", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = connection.HTTPClient().Get(atcServer.URL())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(atcServer.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("is rejected by the target without one", func() {
+			connection, err := rc.TargetConnection("foo", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = connection.HTTPClient().Get(atcServer.URL())
+			Expect(err).To(HaveOccurred())
+
+			Expect(atcServer.ReceivedRequests()).To(BeEmpty())
+		})
+
+		It("keeps the client certificate when the target is saved again", func() {
+			err := rc.SaveClientCertificate("foo", clientCert, "some-key")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = rc.SaveTarget("foo", atcServer.URL(), true, &rc.TargetToken{Type: "Bearer", Value: "some-token"}, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			target, err := rc.SelectTarget("foo")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(target.ClientCert).To(Equal(clientCert))
+			Expect(target.ClientKey).To(Equal("some-key"))
+		})
+
+		It("returns an error for an encrypted key when stdin is not a terminal", func() {
+			block, err := x509.EncryptPEMBlock(
+				rand.Reader,
+				"RSA PRIVATE KEY",
+				x509.MarshalPKCS1PrivateKey(clientKey),
+				[]byte("some-passphrase"),
+				x509.PEMCipherAES256,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = rc.SaveClientCertificate("foo", clientCert, string(pem.EncodeToMemory(block)))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = rc.TargetConnection("foo", "")
+			Expect(err).To(MatchError(ContainSubstring("client key is encrypted")))
+		})
+
+		It("returns an error for a key that isn't PEM", func() {
+			err := rc.SaveClientCertificate("foo", clientCert, "bogus")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = rc.TargetConnection("foo", "")
+			Expect(err).To(MatchError("client key is not valid PEM"))
+		})
+	})
+
+	Describe("refreshing tokens", func() {
+		var atcServer *ghttp.Server
+
+		BeforeEach(func() {
+			atcServer = ghttp.NewServer()
+		})
+
+		AfterEach(func() {
+			atcServer.Close()
+		})
+
+		refreshHandler := func(refreshToken string) http.HandlerFunc {
+			return ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/api/v1/auth/token"),
+				func(w http.ResponseWriter, r *http.Request) {
+					defer GinkgoRecover()
+
+					err := r.ParseForm()
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(r.PostForm.Get("grant_type")).To(Equal("refresh_token"))
+					Expect(r.PostForm.Get("refresh_token")).To(Equal(refreshToken))
+				},
+				ghttp.RespondWith(http.StatusOK, `{
+					"token_type": "Bearer",
+					"access_token": "new-token",
+					"refresh_token": "new-refresh-token",
+					"expires_in": 3600
+				}`),
+			)
+		}
+
+		Context("when the token is about to expire", func() {
+			BeforeEach(func() {
+				err := rc.SaveTarget("foo", atcServer.URL(), false, &rc.TargetToken{
+					Type:         "Bearer",
+					Value:        "old-token",
+					RefreshToken: "some-refresh-token",
+					Expiry:       time.Now().Add(30 * time.Second).Unix(),
+				}, "")
+				Expect(err).NotTo(HaveOccurred())
+
+				atcServer.AppendHandlers(
+					refreshHandler("some-refresh-token"),
+					ghttp.CombineHandlers(
+						ghttp.VerifyHeaderKV("Authorization", "Bearer new-token"),
+						ghttp.RespondWith(http.StatusOK, nil),
+					),
+				)
+			})
+
+			It("refreshes it before making the request, and saves the new one", func() {
+				connection, err := rc.TargetConnection("foo", "")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = connection.HTTPClient().Get(atcServer.URL())
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(atcServer.ReceivedRequests()).To(HaveLen(2))
+
+				target, err := rc.SelectTarget("foo")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(target.Token.Value).To(Equal("new-token"))
+				Expect(target.Token.RefreshToken).To(Equal("new-refresh-token"))
+				Expect(target.Token.Expiry).To(BeNumerically("~", time.Now().Add(time.Hour).Unix(), 5))
+			})
+		})
+
+		Context("when the ATC does not support refreshing tokens", func() {
+			BeforeEach(func() {
+				err := rc.SaveTarget("foo", atcServer.URL(), false, &rc.TargetToken{
+					Type:         "Bearer",
+					Value:        "old-token",
+					RefreshToken: "some-refresh-token",
+					Expiry:       time.Now().Add(30 * time.Second).Unix(),
+				}, "")
+				Expect(err).NotTo(HaveOccurred())
+
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/api/v1/auth/token"),
+						ghttp.RespondWith(http.StatusNotFound, nil),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyHeaderKV("Authorization", "Bearer old-token"),
+						ghttp.RespondWith(http.StatusOK, nil),
+					),
+				)
+			})
+
+			It("uses the token it has, and stops trying to refresh it", func() {
+				connection, err := rc.TargetConnection("foo", "")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = connection.HTTPClient().Get(atcServer.URL())
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(atcServer.ReceivedRequests()).To(HaveLen(2))
+
+				target, err := rc.SelectTarget("foo")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(target.Token.Value).To(Equal("old-token"))
+				Expect(target.Token.RefreshToken).To(BeEmpty())
+			})
+		})
+
+		Context("when a request is rejected", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyHeaderKV("Authorization", "Bearer old-token"),
+						ghttp.RespondWith(http.StatusUnauthorized, nil),
+					),
+				)
+			})
+
+			Context("and the token can be refreshed", func() {
+				BeforeEach(func() {
+					err := rc.SaveTarget("foo", atcServer.URL(), false, &rc.TargetToken{
+						Type:         "Bearer",
+						Value:        "old-token",
+						RefreshToken: "some-refresh-token",
+					}, "")
+					Expect(err).NotTo(HaveOccurred())
+
+					atcServer.AppendHandlers(
+						refreshHandler("some-refresh-token"),
+						ghttp.CombineHandlers(
+							ghttp.VerifyHeaderKV("Authorization", "Bearer new-token"),
+							ghttp.RespondWith(http.StatusOK, nil),
+						),
+					)
+				})
+
+				It("refreshes it and retries the request", func() {
+					connection, err := rc.TargetConnection("foo", "")
+					Expect(err).NotTo(HaveOccurred())
+
+					resp, err := connection.HTTPClient().Get(atcServer.URL())
+					Expect(err).NotTo(HaveOccurred())
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+					target, err := rc.SelectTarget("foo")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(target.Token.Value).To(Equal("new-token"))
+				})
+			})
+
+			Context("and the token has no refresh token", func() {
+				BeforeEach(func() {
+					err := rc.SaveTarget("foo", atcServer.URL(), false, &rc.TargetToken{Type: "Bearer", Value: "old-token"}, "")
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("returns the rejection", func() {
+					connection, err := rc.TargetConnection("foo", "")
+					Expect(err).NotTo(HaveOccurred())
+
+					resp, err := connection.HTTPClient().Get(atcServer.URL())
+					Expect(err).NotTo(HaveOccurred())
+					Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+
+					Expect(atcServer.ReceivedRequests()).To(HaveLen(1))
+				})
+			})
+		})
+	})
+
+	Describe("DeleteTarget", func() {
+		BeforeEach(func() {
+			err := rc.SaveTarget("foo", "https://foo.example.com", false, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = rc.SaveTarget("bar", "https://bar.example.com", true, &rc.TargetToken{Type: "Bearer", Value: "some-token"}, "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("removes only the given target", func() {
+			err := rc.DeleteTarget("foo")
+			Expect(err).NotTo(HaveOccurred())
+
+			targets, err := rc.LoadTargets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(targets).To(Equal(map[string]rc.TargetProps{
+				"bar": {
+					API:      "https://bar.example.com",
+					Insecure: true,
+					Token:    &rc.TargetToken{Type: "Bearer", Value: "some-token"},
+				},
+			}))
+		})
+
+		It("returns an error if the target does not exist", func() {
+			err := rc.DeleteTarget("baz")
+			Expect(err).To(MatchError(ContainSubstring("Unable to find target baz")))
+		})
+	})
+
+	Describe("RenameTarget", func() {
+		BeforeEach(func() {
+			err := rc.SaveTarget("prod-ci", "https://ci.example.com", true, &rc.TargetToken{Type: "Bearer", Value: "some-token"}, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = rc.SaveTarget("staging", "https://staging.example.com", false, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("moves the target to its new name, keeping its properties", func() {
+			err := rc.RenameTarget("prod-ci", "production", false)
+			Expect(err).NotTo(HaveOccurred())
+
+			targets, err := rc.LoadTargets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(targets).NotTo(HaveKey("prod-ci"))
+			Expect(targets).To(HaveKey("staging"))
+			Expect(targets["production"]).To(Equal(rc.TargetProps{
+				API:      "https://ci.example.com",
+				Insecure: true,
+				Token:    &rc.TargetToken{Type: "Bearer", Value: "some-token"},
+			}))
+		})
+
+		It("returns an error if the old target does not exist", func() {
+			err := rc.RenameTarget("bogus", "production", false)
+			Expect(err).To(MatchError(ContainSubstring("Unable to find target bogus")))
+		})
+
+		It("returns an error if the new name is already taken", func() {
+			err := rc.RenameTarget("prod-ci", "staging", false)
+			Expect(err).To(MatchError(ContainSubstring("Target staging already exists")))
+
+			targets, err := rc.LoadTargets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(targets["staging"].API).To(Equal("https://staging.example.com"))
+			Expect(targets).To(HaveKey("prod-ci"))
+		})
+
+		Context("when forced", func() {
+			It("replaces the target already by the new name", func() {
+				err := rc.SetDefaultTarget("staging")
+				Expect(err).NotTo(HaveOccurred())
+
+				err = rc.RenameTarget("prod-ci", "staging", true)
+				Expect(err).NotTo(HaveOccurred())
+
+				targets, err := rc.LoadTargets()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(targets).NotTo(HaveKey("prod-ci"))
+				Expect(targets["staging"].API).To(Equal("https://ci.example.com"))
+
+				target, _, err := rc.DefaultTarget()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(target).To(BeEmpty())
+			})
+
+			It("leaves the target by the new name alone if the old target does not exist", func() {
+				err := rc.RenameTarget("bogus", "staging", true)
+				Expect(err).To(MatchError(ContainSubstring("Unable to find target bogus")))
+
+				targets, err := rc.LoadTargets()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(targets["staging"].API).To(Equal("https://staging.example.com"))
+			})
+		})
+	})
+
+	Describe("DeleteAllTargets", func() {
+		It("removes every target", func() {
+			err := rc.SaveTarget("foo", "https://foo.example.com", false, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = rc.DeleteAllTargets()
+			Expect(err).NotTo(HaveOccurred())
+
+			targets, err := rc.LoadTargets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(targets).To(BeEmpty())
+		})
+	})
+
+	Describe("SaveTarget", func() {
+		It("keeps every target when they are saved concurrently", func() {
+			wg := new(sync.WaitGroup)
+
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+
+					err := rc.SaveTarget(fmt.Sprintf("target-%d", i), fmt.Sprintf("https://%d.example.com", i), false, nil, "")
+					Expect(err).NotTo(HaveOccurred())
+				}(i)
+			}
+
+			wg.Wait()
+
+			targets, err := rc.LoadTargets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(targets).To(HaveLen(50))
+
+			for i := 0; i < 50; i++ {
+				Expect(targets[fmt.Sprintf("target-%d", i)].API).To(Equal(fmt.Sprintf("https://%d.example.com", i)))
+			}
+		})
+
+		It("leaves no temporary files behind", func() {
+			err := rc.SaveTarget("foo", "https://foo.example.com", false, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			files, err := filepath.Glob(filepath.Join(tmpDir, ".flyrc?*"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(ConsistOf(flyrc + ".lock"))
+		})
+	})
+
+	Describe("DefaultTarget", func() {
+		BeforeEach(func() {
+			os.Setenv("FLY_TARGET", "")
+
+			err := rc.SaveTarget("foo", "https://foo.example.com", false, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("is empty until one is set", func() {
+			target, source, err := rc.DefaultTarget()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(target).To(BeEmpty())
+			Expect(source).To(BeEmpty())
+		})
+
+		It("returns the target set with SetDefaultTarget", func() {
+			err := rc.SetDefaultTarget("foo")
+			Expect(err).NotTo(HaveOccurred())
+
+			target, source, err := rc.DefaultTarget()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(target).To(Equal("foo"))
+			Expect(source).To(Equal("default target"))
+		})
+
+		It("prefers FLY_TARGET", func() {
+			err := rc.SetDefaultTarget("foo")
+			Expect(err).NotTo(HaveOccurred())
+
+			os.Setenv("FLY_TARGET", "bar")
+			defer os.Setenv("FLY_TARGET", "")
+
+			target, source, err := rc.DefaultTarget()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(target).To(Equal("bar"))
+			Expect(source).To(Equal("FLY_TARGET"))
+		})
+
+		It("follows the target when it is renamed", func() {
+			err := rc.SetDefaultTarget("foo")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = rc.RenameTarget("foo", "bar", false)
+			Expect(err).NotTo(HaveOccurred())
+
+			target, _, err := rc.DefaultTarget()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(target).To(Equal("bar"))
+		})
+
+		It("is cleared when the target is deleted", func() {
+			err := rc.SetDefaultTarget("foo")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = rc.DeleteTarget("foo")
+			Expect(err).NotTo(HaveOccurred())
+
+			target, _, err := rc.DefaultTarget()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(target).To(BeEmpty())
+		})
+	})
+
+	Describe("choosing the file", func() {
+		AfterEach(func() {
+			os.Setenv("FLYRC", "")
+			rc.SetFlyrc("")
+		})
+
+		It("uses $FLYRC, creating its directory", func() {
+			envFlyrc := filepath.Join(tmpDir, "env", "flyrc")
+			os.Setenv("FLYRC", envFlyrc)
+
+			err := rc.SaveTarget("foo", "https://foo.example.com", false, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(envFlyrc).To(BeARegularFile())
+			Expect(flyrc).NotTo(BeAnExistingFile())
+
+			targets, err := rc.LoadTargets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(targets).To(HaveKey("foo"))
+		})
+
+		It("prefers the path given to SetFlyrc to $FLYRC", func() {
+			envFlyrc := filepath.Join(tmpDir, "env", "flyrc")
+			os.Setenv("FLYRC", envFlyrc)
+
+			flagFlyrc := filepath.Join(tmpDir, "flag", "flyrc")
+			rc.SetFlyrc(flagFlyrc)
+
+			err := rc.SaveTarget("foo", "https://foo.example.com", false, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(flagFlyrc).To(BeARegularFile())
+			Expect(envFlyrc).NotTo(BeAnExistingFile())
+		})
+	})
 })
@@ -0,0 +1,153 @@
+package rc
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultRequestTimeout bounds connecting to the target, the TLS handshake,
+// and waiting for each response's headers; reading the body is not bounded,
+// so that event streams and downloads may take as long as they need
+const DefaultRequestTimeout = time.Minute
+
+var requestTimeout = DefaultRequestTimeout
+
+var commandTimeout time.Duration
+var commandDeadline time.Time
+
+// SetRequestTimeout changes the timeout for each request made by connections
+// made from now on; 0 has them wait forever.
+func SetRequestTimeout(timeout time.Duration) {
+	requestTimeout = timeout
+}
+
+// SetCommandTimeout bounds the whole command, counting from now: requests
+// still going when it runs out fail, naming the request that stalled. 0 lifts
+// the bound, as long-running commands such as execute do.
+func SetCommandTimeout(timeout time.Duration) {
+	commandTimeout = timeout
+
+	if timeout == 0 {
+		commandDeadline = time.Time{}
+	} else {
+		commandDeadline = time.Now().Add(timeout)
+	}
+}
+
+func newHTTPTransport(proxyFunc func(*http.Request) (*url.URL, error), tlsConfig *tls.Config) *http.Transport {
+	return &http.Transport{
+		Proxy:           proxyFunc,
+		TLSClientConfig: tlsConfig,
+		DialContext: (&net.Dialer{
+			Timeout:   requestTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   requestTimeout,
+		ResponseHeaderTimeout: requestTimeout,
+	}
+}
+
+// timeoutTransport enforces the command's timeout, and explains timeouts so
+// that the error says what to do about them
+type timeoutTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+func (transport timeoutTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if commandDeadline.IsZero() {
+		response, err := transport.base.RoundTrip(r)
+		if err != nil {
+			return nil, transport.explain(err)
+		}
+
+		return response, nil
+	}
+
+	ctx, cancel := context.WithDeadline(r.Context(), commandDeadline)
+
+	response, err := transport.base.RoundTrip(r.WithContext(ctx))
+	if err != nil {
+		cancel()
+
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, commandTimeoutError{timeout: commandTimeout}
+		}
+
+		return nil, transport.explain(err)
+	}
+
+	response.Body = &deadlineBody{
+		body:    response.Body,
+		ctx:     ctx,
+		cancel:  cancel,
+		request: r.Method + " " + r.URL.String(),
+		timeout: commandTimeout,
+	}
+
+	return response, nil
+}
+
+func (transport timeoutTransport) explain(err error) error {
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() && transport.timeout > 0 {
+		return requestTimeoutError{err: err, timeout: transport.timeout}
+	}
+
+	return err
+}
+
+// requestTimeoutError is still a timeout, so that it is retried like any
+// other
+type requestTimeoutError struct {
+	err     error
+	timeout time.Duration
+}
+
+func (err requestTimeoutError) Error() string {
+	return fmt.Sprintf("%s (nothing within %s; if the target is just slow, raise --request-timeout or set FLY_REQUEST_TIMEOUT)", err.err, err.timeout)
+}
+
+func (err requestTimeoutError) Timeout() bool   { return true }
+func (err requestTimeoutError) Temporary() bool { return true }
+
+type commandTimeoutError struct {
+	timeout time.Duration
+}
+
+func (err commandTimeoutError) Error() string {
+	return fmt.Sprintf("still waiting when the command's --timeout of %s ran out", err.timeout)
+}
+
+func (err commandTimeoutError) Timeout() bool   { return true }
+func (err commandTimeoutError) Temporary() bool { return false }
+
+// deadlineBody keeps the command's deadline in force until the body is
+// closed; the response is already out of the client's hands by then, so it
+// names the request itself
+type deadlineBody struct {
+	body    io.ReadCloser
+	ctx     context.Context
+	cancel  context.CancelFunc
+	request string
+	timeout time.Duration
+}
+
+func (body *deadlineBody) Read(p []byte) (int, error) {
+	n, err := body.body.Read(p)
+	if err != nil && err != io.EOF && body.ctx.Err() == context.DeadlineExceeded {
+		return n, fmt.Errorf("reading the response to %s: %s", body.request, commandTimeoutError{timeout: body.timeout})
+	}
+
+	return n, err
+}
+
+func (body *deadlineBody) Close() error {
+	body.cancel()
+	return body.body.Close()
+}
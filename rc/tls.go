@@ -0,0 +1,93 @@
+package rc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+
+	"github.com/mattn/go-isatty"
+	"github.com/vito/go-interact/interact"
+)
+
+// TLSConfig verifies the target's certificate against its CA certificate as
+// well as the system's, unless verification is skipped, and presents its
+// client certificate if it has one
+func TLSConfig(target TargetProps) (*tls.Config, error) {
+	if !target.Insecure && target.CACert == "" && target.ClientCert == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: target.Insecure}
+
+	if target.CACert != "" && !target.Insecure {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM([]byte(target.CACert)) {
+			return nil, errors.New("CA certificate is not valid PEM")
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if target.ClientCert != "" {
+		certificate, err := clientCertificate(target.ClientCert, target.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+func clientCertificate(cert string, key string) (tls.Certificate, error) {
+	keyBlock, _ := pem.Decode([]byte(key))
+	if keyBlock == nil {
+		return tls.Certificate{}, errors.New("client key is not valid PEM")
+	}
+
+	keyPEM := []byte(key)
+
+	if x509.IsEncryptedPEMBlock(keyBlock) {
+		decrypted, err := decryptKey(keyBlock)
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+
+		keyPEM = pem.EncodeToMemory(&pem.Block{Type: keyBlock.Type, Bytes: decrypted})
+	}
+
+	certificate, err := tls.X509KeyPair([]byte(cert), keyPEM)
+	if err != nil {
+		return tls.Certificate{}, errors.New("invalid client certificate: " + err.Error())
+	}
+
+	return certificate, nil
+}
+
+// decryptKey asks for the key's passphrase, which can only be done on a
+// terminal
+func decryptKey(block *pem.Block) ([]byte, error) {
+	if !isatty.IsTerminal(os.Stdin.Fd()) {
+		return nil, errors.New("client key is encrypted, and its passphrase can only be entered on a terminal")
+	}
+
+	var passphrase interact.Password
+	err := interact.NewInteraction("client key passphrase").Resolve(interact.Required(&passphrase))
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted, err := x509.DecryptPEMBlock(block, []byte(passphrase))
+	if err != nil {
+		return nil, errors.New("failed to decrypt client key: " + err.Error())
+	}
+
+	return decrypted, nil
+}
@@ -0,0 +1,46 @@
+package rc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// ExpiresAt returns the token's saved expiry, or else decodes the exp claim
+// of a JWT token; it returns false if neither is known
+func (token *TargetToken) ExpiresAt() (time.Time, bool) {
+	if token.Expiry != 0 {
+		return time.Unix(token.Expiry, 0), true
+	}
+
+	segments := strings.Split(token.Value, ".")
+	if len(segments) != 3 {
+		return time.Time{}, false
+	}
+
+	payload, err := base64.URLEncoding.DecodeString(padBase64(segments[1]))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+
+	err = json.Unmarshal(payload, &claims)
+	if err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(claims.Exp, 0), true
+}
+
+// JWTs strip the padding off their base64 segments
+func padBase64(segment string) string {
+	if remainder := len(segment) % 4; remainder != 0 {
+		segment += strings.Repeat("=", 4-remainder)
+	}
+
+	return segment
+}
@@ -0,0 +1,52 @@
+package rc_test
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/concourse/fly/rc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TargetToken", func() {
+	Describe("ExpiresAt", func() {
+		jwt := func(claims string) string {
+			segment := func(s string) string {
+				return base64.RawURLEncoding.EncodeToString([]byte(s))
+			}
+
+			return segment(`{"alg":"RS256","typ":"JWT"}`) + "." + segment(claims) + "." + segment("signature")
+		}
+
+		It("returns the expiry of a JWT", func() {
+			token := &rc.TargetToken{Type: "Bearer", Value: jwt(`{"exp":1457000000,"teamName":"main"}`)}
+
+			expiry, ok := token.ExpiresAt()
+			Expect(ok).To(BeTrue())
+			Expect(expiry).To(Equal(time.Unix(1457000000, 0)))
+		})
+
+		It("prefers the saved expiry", func() {
+			token := &rc.TargetToken{Type: "Bearer", Value: jwt(`{"exp":1457000000}`), Expiry: 1458000000}
+
+			expiry, ok := token.ExpiresAt()
+			Expect(ok).To(BeTrue())
+			Expect(expiry).To(Equal(time.Unix(1458000000, 0)))
+		})
+
+		It("returns false for a JWT without an exp claim", func() {
+			token := &rc.TargetToken{Type: "Bearer", Value: jwt(`{"teamName":"main"}`)}
+
+			_, ok := token.ExpiresAt()
+			Expect(ok).To(BeFalse())
+		})
+
+		It("returns false for a token that is not a JWT", func() {
+			token := &rc.TargetToken{Type: "Basic", Value: "dXNlcm5hbWU6cGFzc3dvcmQ="}
+
+			_, ok := token.ExpiresAt()
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,190 @@
+package rc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// how much of each body is shown when tracing headers and bodies
+const maxTracedBody = 4 * 1024
+
+var traceVerbosity int
+
+// headers whose values are credentials, shown as [redacted]
+var redactedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// SetVerbosity has connections made from now on log their requests to
+// stderr: at 1, each request's method, URL, status and duration; at 2, their
+// headers and the start of their bodies too.
+func SetVerbosity(verbosity int) {
+	traceVerbosity = verbosity
+}
+
+// tracingTransport sits beneath the others, so it sees requests exactly as
+// they're sent, including each attempt at a retried request
+type tracingTransport struct {
+	base      http.RoundTripper
+	verbosity int
+	log       *lockedWriter
+}
+
+type lockedWriter struct {
+	lock sync.Mutex
+	dst  io.Writer
+}
+
+// write logs a whole entry at once, so that concurrent requests' entries
+// don't interleave
+func (writer *lockedWriter) write(entry string) {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+
+	fmt.Fprint(writer.dst, entry)
+}
+
+func newTracingTransport(base http.RoundTripper, verbosity int) http.RoundTripper {
+	return tracingTransport{
+		base:      base,
+		verbosity: verbosity,
+		log:       &lockedWriter{dst: os.Stderr},
+	}
+}
+
+func (transport tracingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if transport.verbosity >= 2 {
+		// requests must not be modified by transports
+		traced := new(http.Request)
+		*traced = *request
+
+		entry := fmt.Sprintf("%s %s\n%s", request.Method, request.URL, traceHeaders(request.Header))
+		if holdsTokens(request) {
+			entry += redactedBody
+		} else {
+			traced.Body, entry = traceBody(request.Body, entry)
+		}
+
+		transport.log.write(entry + "\n")
+
+		request = traced
+	}
+
+	started := time.Now()
+
+	response, err := transport.base.RoundTrip(request)
+
+	took := time.Since(started)
+
+	if err != nil {
+		transport.log.write(fmt.Sprintf("%s %s: %s (%s)\n", request.Method, request.URL, err, took))
+		return nil, err
+	}
+
+	entry := fmt.Sprintf("%s %s: %s (%s)\n", request.Method, request.URL, response.Status, took)
+
+	if transport.verbosity >= 2 {
+		entry += traceHeaders(response.Header)
+
+		if strings.HasPrefix(response.Header.Get("Content-Type"), "text/event-stream") {
+			entry += "  (event stream)\n"
+		} else if holdsTokens(request) {
+			entry += redactedBody
+		} else {
+			response.Body, entry = traceBody(response.Body, entry)
+		}
+
+		entry += "\n"
+	}
+
+	transport.log.write(entry)
+
+	return response, nil
+}
+
+func traceHeaders(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	traced := ""
+	for _, name := range names {
+		for _, value := range header[name] {
+			if redactedHeaders[name] {
+				value = "[redacted]"
+			}
+
+			traced += fmt.Sprintf("  %s: %s\n", name, value)
+		}
+	}
+
+	return traced
+}
+
+const redactedBody = "\n  (body redacted)\n"
+
+// holdsTokens determines whether the request is to the endpoint that tokens
+// are issued by, whose bodies hold refresh tokens on the way there and tokens
+// on the way back
+func holdsTokens(request *http.Request) bool {
+	return request.URL != nil && strings.HasSuffix(request.URL.Path, refreshTokenPath)
+}
+
+// traceBody adds the start of the body to the entry, returning a body that
+// still reads from the beginning
+func traceBody(body io.ReadCloser, entry string) (io.ReadCloser, string) {
+	if body == nil {
+		return nil, entry
+	}
+
+	start := make([]byte, maxTracedBody)
+	n, err := io.ReadFull(body, start)
+	start = start[:n]
+
+	switch {
+	case n == 0:
+	case !utf8.Valid(start):
+		entry += fmt.Sprintf("\n  (%d bytes of binary data)\n", n)
+	default:
+		entry += "\n" + string(start) + "\n"
+	}
+
+	if n == maxTracedBody {
+		entry += "  ... (truncated)\n"
+	}
+
+	var rest io.Reader = body
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		// the failure is left for whatever reads the body to see
+		rest = errReader{err}
+	}
+
+	return tracedBody{io.MultiReader(bytes.NewReader(start), rest), body}, entry
+}
+
+type tracedBody struct {
+	io.Reader
+	io.Closer
+}
+
+type errReader struct {
+	err error
+}
+
+func (reader errReader) Read([]byte) (int, error) {
+	return 0, reader.err
+}
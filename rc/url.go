@@ -0,0 +1,38 @@
+package rc
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// NormalizeURL cleans up a target's URL so that API paths can be appended to
+// it: the scheme defaults to https, the host is lowercased and any trailing
+// slash is removed; URLs with a path, query or fragment are rejected
+func NormalizeURL(api string) (string, error) {
+	if !strings.Contains(api, "://") {
+		api = "https://" + api
+	}
+
+	atcURL, err := url.Parse(api)
+	if err != nil {
+		return "", fmt.Errorf("invalid target URL '%s': %s", api, err)
+	}
+
+	if atcURL.Scheme != "http" && atcURL.Scheme != "https" {
+		return "", fmt.Errorf("invalid target URL '%s': scheme must be http or https", api)
+	}
+
+	if atcURL.Host == "" {
+		return "", fmt.Errorf("invalid target URL '%s': missing host", api)
+	}
+
+	if strings.Trim(atcURL.Path, "/") != "" || atcURL.RawQuery != "" || atcURL.Fragment != "" {
+		return "", fmt.Errorf("invalid target URL '%s': must not have a path, query or fragment", api)
+	}
+
+	atcURL.Host = strings.ToLower(atcURL.Host)
+	atcURL.Path = ""
+
+	return atcURL.String(), nil
+}
@@ -0,0 +1,50 @@
+package rc_test
+
+import (
+	"github.com/concourse/fly/rc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NormalizeURL", func() {
+	normalize := func(api string) string {
+		normalized, err := rc.NormalizeURL(api)
+		Expect(err).NotTo(HaveOccurred())
+		return normalized
+	}
+
+	It("leaves a clean URL alone", func() {
+		Expect(normalize("https://ci.example.com")).To(Equal("https://ci.example.com"))
+		Expect(normalize("http://127.0.0.1:8080")).To(Equal("http://127.0.0.1:8080"))
+	})
+
+	It("strips trailing slashes", func() {
+		Expect(normalize("https://ci.example.com/")).To(Equal("https://ci.example.com"))
+		Expect(normalize("https://ci.example.com//")).To(Equal("https://ci.example.com"))
+	})
+
+	It("defaults the scheme to https", func() {
+		Expect(normalize("ci.example.com")).To(Equal("https://ci.example.com"))
+		Expect(normalize("ci.example.com:8443/")).To(Equal("https://ci.example.com:8443"))
+	})
+
+	It("lowercases the host", func() {
+		Expect(normalize("https://CI.Example.com")).To(Equal("https://ci.example.com"))
+	})
+
+	It("rejects URLs with a path, query or fragment", func() {
+		for _, api := range []string{
+			"https://ci.example.com/some/path",
+			"https://ci.example.com?foo=bar",
+			"https://ci.example.com/#frag",
+		} {
+			_, err := rc.NormalizeURL(api)
+			Expect(err).To(MatchError("invalid target URL '" + api + "': must not have a path, query or fragment"))
+		}
+	})
+
+	It("rejects schemes other than http and https", func() {
+		_, err := rc.NormalizeURL("ftp://ci.example.com")
+		Expect(err).To(MatchError("invalid target URL 'ftp://ci.example.com': scheme must be http or https"))
+	})
+})
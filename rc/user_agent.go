@@ -0,0 +1,28 @@
+package rc
+
+import (
+	"net/http"
+
+	"github.com/concourse/fly/version"
+)
+
+// userAgentTransport identifies fly on every request, so that ATC operators
+// can see which versions are in use
+type userAgentTransport struct {
+	base http.RoundTripper
+}
+
+func (transport userAgentTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	// requests must not be modified by transports
+	withAgent := new(http.Request)
+	*withAgent = *r
+
+	withAgent.Header = make(http.Header, len(r.Header)+1)
+	for k, v := range r.Header {
+		withAgent.Header[k] = v
+	}
+
+	withAgent.Header.Set("User-Agent", version.UserAgent())
+
+	return transport.base.RoundTrip(withAgent)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"sort"
 
 	"github.com/hashicorp/go-multierror"
 )
@@ -27,3 +28,21 @@ func Evaluate(content []byte, variables Variables) ([]byte, error) {
 		return []byte(saveValue)
 	}), variableErrors
 }
+
+func Unreferenced(content []byte, variables Variables) []string {
+	referenced := map[string]bool{}
+	for _, match := range templateFormatRegex.FindAllSubmatch(content, -1) {
+		referenced[string(match[1])] = true
+	}
+
+	unreferenced := []string{}
+	for key := range variables {
+		if !referenced[key] {
+			unreferenced = append(unreferenced, key)
+		}
+	}
+
+	sort.Strings(unreferenced)
+
+	return unreferenced
+}
@@ -97,4 +97,27 @@ var _ = Describe("Template", func() {
 		Expect(err).NotTo(HaveOccurred())
 		Expect(result).To(Equal([]byte("{{}")))
 	})
+
+	Describe("Unreferenced", func() {
+		It("returns the sorted names of variables not used by the template", func() {
+			byteSlice := []byte("{{key}}={{value}}")
+			variables := template.Variables{
+				"key":      "foo",
+				"value":    "bar",
+				"unused-b": "b",
+				"unused-a": "a",
+			}
+
+			Expect(template.Unreferenced(byteSlice, variables)).To(Equal([]string{"unused-a", "unused-b"}))
+		})
+
+		It("returns nothing when every variable is used", func() {
+			byteSlice := []byte("{{key}}")
+			variables := template.Variables{
+				"key": "foo",
+			}
+
+			Expect(template.Unreferenced(byteSlice, variables)).To(BeEmpty())
+		})
+	})
 })
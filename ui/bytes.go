@@ -0,0 +1,20 @@
+package ui
+
+import "fmt"
+
+// FormatBytes renders a number of bytes in binary units, e.g. 1.5 MiB.
+func FormatBytes(n float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+
+	unit := 0
+	for n >= 1024 && unit < len(units)-1 {
+		n /= 1024
+		unit++
+	}
+
+	if unit == 0 {
+		return fmt.Sprintf("%d %s", int64(n), units[unit])
+	}
+
+	return fmt.Sprintf("%.1f %s", n, units[unit])
+}
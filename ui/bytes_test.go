@@ -0,0 +1,17 @@
+package ui_test
+
+import (
+	. "github.com/concourse/fly/ui"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FormatBytes", func() {
+	It("uses the largest binary unit that fits", func() {
+		Expect(FormatBytes(512)).To(Equal("512 B"))
+		Expect(FormatBytes(1536)).To(Equal("1.5 KiB"))
+		Expect(FormatBytes(3 * 1024 * 1024)).To(Equal("3.0 MiB"))
+		Expect(FormatBytes(2.5 * 1024 * 1024 * 1024)).To(Equal("2.5 GiB"))
+	})
+})
@@ -108,3 +108,22 @@ func (table Table) renderRow(dst io.Writer, row TableRow, widths map[int]int, is
 
 	return nil
 }
+
+// Headers builds a header row in the style shared by all of fly's listings.
+func Headers(names ...string) TableRow {
+	row := make(TableRow, len(names))
+	for i, name := range names {
+		row[i] = TableCell{Contents: name, Color: color.New(color.Bold)}
+	}
+
+	return row
+}
+
+// YesNo renders a boolean column, highlighting "yes".
+func YesNo(value bool) TableCell {
+	if value {
+		return TableCell{Contents: "yes", Color: color.New(color.FgCyan)}
+	}
+
+	return TableCell{Contents: "no"}
+}
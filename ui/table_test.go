@@ -82,4 +82,17 @@ var _ = Describe("Table", func() {
 			Eventually(buf.Contents).Should(Equal([]byte(expectedOutput)))
 		})
 	})
+
+	Describe("Headers", func() {
+		It("makes a bold cell for each name", func() {
+			Expect(Headers("column1", "column2")).To(Equal(table.Headers))
+		})
+	})
+
+	Describe("YesNo", func() {
+		It("highlights yes", func() {
+			Expect(YesNo(true)).To(Equal(TableCell{Contents: "yes", Color: color.New(color.FgCyan)}))
+			Expect(YesNo(false)).To(Equal(TableCell{Contents: "no"}))
+		})
+	})
 })
@@ -0,0 +1,19 @@
+package version
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// These are set when building a release, e.g. with
+// -ldflags "-X github.com/concourse/fly/version.Version=1.2.3"
+var (
+	Version   = "0.0.0-dev"
+	GitSHA    = ""
+	BuildDate = ""
+)
+
+// UserAgent identifies fly to the ATC, e.g. "fly/1.2.3 (darwin/amd64)"
+func UserAgent() string {
+	return fmt.Sprintf("fly/%s (%s/%s)", Version, runtime.GOOS, runtime.GOARCH)
+}